@@ -3,42 +3,84 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/cucumber/godog/colors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/Andres09xZ/latacunga_clean_app/internal/database"
-	"github.com/Andres09xZ/latacunga_clean_app/internal/models"
-	"github.com/Andres09xZ/latacunga_clean_app/internal/server"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/server"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/sms"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/health"
 	"gorm.io/gorm"
 )
 
 var testCtx *testContext
 
+var (
+	testCfg *config.Config
+	// postgresURL runs the suite against that database instead of the
+	// in-memory repositories; the migrations are applied at start
+	postgresURL = os.Getenv("TEST_DB_URL")
+	// smsOutbox captures the OTP messages instead of sending them
+	smsOutbox = sms.NewMemorySender()
+	otpCode   = regexp.MustCompile(`\b(\d{6})\b`)
+)
+
+// Placeholders in a request body: the last OTP code sent and the current
+// refresh token
+const (
+	otpPlaceholder     = "{{otp}}"
+	refreshPlaceholder = "{{refresh_token}}"
+)
+
+// signingKey is the key auth-service signs with (JWT_KEYS_DIR holds it as
+// bdd.pem), so the steps can forge expired tokens
+var (
+	signingKey ed25519.PrivateKey
+	keysDir    string
+)
+
 var opt = godog.Options{
 	Output: colors.Colored(os.Stdout),
 	Format: "progress", // can be progress, pretty, json, etc.
 }
 
 type testContext struct {
+	// db is nil on the in-memory repositories
 	db         *gorm.DB
+	repos      repository.Repositories
 	router     *gin.Engine
 	lastResp   *httptest.ResponseRecorder
 	lastTokens map[string]string
-	users      map[string]*models.User
+	// lastBody is the JSON body of the last POST
+	lastBody map[string]string
+	users    map[string]*models.User
+	otp      string
+	otpPhone string
 }
 
 var ctx *testContext
@@ -63,12 +105,7 @@ func TestMain(m *testing.M) {
 
 func InitializeTestSuite(ctx *godog.TestSuiteContext) {
 	ctx.BeforeSuite(func() {
-		// Load test environment
-		if err := godotenv.Load("../.env"); err != nil {
-			fmt.Printf("Error loading .env file: %v\n", err)
-		}
-
-		// Setup test database
+		// Setup test database and the same wiring as server.Start
 		setupTestDatabase()
 	})
 
@@ -81,9 +118,20 @@ func InitializeTestSuite(ctx *godog.TestSuiteContext) {
 func InitializeScenario(sc *godog.ScenarioContext) {
 	sc.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
 		// Setup scenario context
+		// Fresh repositories per scenario; on Postgres the tables are emptied after it
+		repos := repository.NewMemory()
+		var db *gorm.DB
+		if postgresURL != "" {
+			db = database.DB
+			repos = repository.New(db)
+		}
+		server.InitWith(testCfg, db, repos)
+		sms.SetSender(smsOutbox)
+		smsOutbox.Reset()
 		testCtx = &testContext{
-			db:         database.DB,
-			router:     server.SetupRouter(),
+			db:         db,
+			repos:      repos,
+			router:     server.SetupRouter(testCfg, health.New(nil)),
 			lastResp:   nil,
 			lastTokens: make(map[string]string),
 			users:      make(map[string]*models.User),
@@ -93,9 +141,11 @@ func InitializeScenario(sc *godog.ScenarioContext) {
 
 	sc.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
 		// Cleanup after scenario
-		if testCtx != nil {
+		if testCtx != nil && testCtx.db != nil {
 			testCtx.db.Exec("DELETE FROM refresh_tokens")
-			// testCtx.db.Exec("DELETE FROM otp_requests") // Keep OTP for cross-scenario tests
+			testCtx.db.Exec("DELETE FROM otp_codes")
+			testCtx.db.Exec("DELETE FROM otp_limit_events")
+			testCtx.db.Exec("DELETE FROM otp_locks")
 			testCtx.db.Exec("DELETE FROM users")
 		}
 		return ctx, nil
@@ -124,7 +174,7 @@ func InitializeScenario(sc *godog.ScenarioContext) {
 	sc.Step(`^que estoy autenticado con access_token válido$`, queEstoyAutenticado)
 	sc.Step(`^que estoy autenticado con access_token rol "([^"]*)"$`, queEstoyAutenticadoConRol)
 	sc.Step(`^que se solicitó OTP para "([^"]*)"$`, queSeSolicitoOTPPara)
-	sc.Step(`^el código generado fue "([^"]*)"$`, elCodigoGeneradoFue)
+	sc.Step(`^el SMS enviado a "([^"]*)" contiene un código de (\d+) dígitos$`, elSMSEnviadoContieneCodigo)
 	sc.Step(`^no existe usuario con ese teléfono$`, noExisteUsuarioConEseTelefono)
 	sc.Step(`^he realizado (\d+) intentos fallidos$`, heRealizadoIntentosFallidos)
 	sc.Step(`^el sistema crea un usuario con rol "([^"]*)"$`, elSistemaCreaUsuarioConRol)
@@ -144,36 +194,91 @@ func InitializeScenario(sc *godog.ScenarioContext) {
 }
 
 func setupTestDatabase() {
-	// Setup test database connection
-	database.Connect()
-
-	// Clean up any existing tables
-	database.DB.Exec("DROP TABLE IF EXISTS refresh_tokens CASCADE")
-	database.DB.Exec("DROP TABLE IF EXISTS otp_requests CASCADE")
-	database.DB.Exec("DROP TABLE IF EXISTS users CASCADE")
+	// Development mode allows the in-memory SMS/mail providers and an
+	// ephemeral signing key
+	os.Setenv("APP_ENV", config.EnvDevelopment)
+	os.Setenv("SMS_PROVIDER", "memory")
+	os.Setenv("MAIL_PROVIDER", "memory")
+	if err := writeSigningKey(); err != nil {
+		log.Fatalf("Error writing the test signing key: %v", err)
+	}
+	os.Setenv("JWT_KEYS_DIR", keysDir)
+	if postgresURL != "" {
+		os.Setenv("DB_URL", postgresURL)
+	} else {
+		// Validate requires DB_URL; the in-memory repositories never open it
+		os.Setenv("DB_URL", "postgres://localhost/unused")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading test configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid test configuration: %v", err)
+	}
+	testCfg = cfg
+	if postgresURL == "" {
+		return
+	}
 
-	// Run GORM migrations first to create tables
-	database.DB.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.OTPRequest{})
+	// Applies the embedded migrations, like the server at startup
+	cfg.Database.Migrate = true
+	database.InitDB(cfg.Database)
+}
 
-	// Run SQL migrations for additional alterations
-	migrationFiles := []string{
-		"../migrations/001_add_id_user.sql",
-		"../migrations/002_nullable_email_password.sql",
+// writeSigningKey stores a fresh Ed25519 key in a temporary JWT_KEYS_DIR
+func writeSigningKey() error {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	if keysDir, err = os.MkdirTemp("", "bdd-keys"); err != nil {
+		return err
 	}
+	signingKey = priv
+	return os.WriteFile(filepath.Join(keysDir, "bdd.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
 
-	for _, file := range migrationFiles {
-		sqlBytes, err := os.ReadFile(file)
-		if err != nil {
-			log.Fatalf("Error reading migration file %s: %v", file, err)
-		}
-		sql := string(sqlBytes)
-		if err := database.DB.Exec(sql).Error; err != nil {
-			log.Fatalf("Error executing migration %s: %v", file, err)
-		}
+// forgeAccessToken signs an access token with key under the kid of the test key
+func forgeAccessToken(key ed25519.PrivateKey, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, auth.Claims{
+		UserID:    uuid.NewString(),
+		Role:      "admin",
+		TokenType: auth.TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	token.Header["kid"] = "bdd"
+	return token.SignedString(key)
+}
+
+// authenticate creates an account with role and signs it in
+func authenticate(role string) error {
+	email := role + "@bdd.test"
+	user := &models.User{Email: &email, Role: role, DisplayName: "BDD " + role}
+	if err := testCtx.repos.Users.Create(context.Background(), user); err != nil {
+		return err
 	}
+	access, refresh, err := auth.IssueTokens(context.Background(), *user, auth.Device{})
+	if err != nil {
+		return err
+	}
+	testCtx.users[email] = user
+	testCtx.lastTokens = map[string]string{"access_token": access, "refresh_token": refresh}
+	return nil
 }
 
 func cleanupTestDatabase() {
+	os.RemoveAll(keysDir)
+	if database.DB == nil {
+		return
+	}
 	// Close database connection
 	sqlDB, _ := database.DB.DB()
 	sqlDB.Close()
@@ -231,14 +336,13 @@ func existeUnUsuario(email, password, role string) error {
 
 	// Create test user
 	user := &models.User{
-		IDUser:       fmt.Sprintf("test-%s", email),
 		Email:        &email,
 		PasswordHash: &hashedPasswordStr,
 		Role:         role,
-		Name:         "Test User",
+		DisplayName:  "Test User",
 	}
 
-	if err := testCtx.db.Create(user).Error; err != nil {
+	if err := testCtx.repos.Users.Create(context.Background(), user); err != nil {
 		return err
 	}
 
@@ -247,11 +351,21 @@ func existeUnUsuario(email, password, role string) error {
 }
 
 func hagoPOSTaCon(endpoint string, jsonStr *godog.DocString) error {
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(jsonStr.Content))
+	body := strings.NewReplacer(
+		otpPlaceholder, testCtx.otp,
+		refreshPlaceholder, testCtx.lastTokens["refresh_token"],
+	).Replace(jsonStr.Content)
+	testCtx.lastBody = map[string]string{}
+	_ = json.Unmarshal([]byte(body), &testCtx.lastBody)
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if token := testCtx.lastTokens["access_token"]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	testCtx.lastResp = httptest.NewRecorder()
 	testCtx.router.ServeHTTP(testCtx.lastResp, req)
@@ -352,25 +466,20 @@ func queTengoUnTokenValido(tokenType string) error {
 			hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), 12)
 			hashedPasswordStr := string(hashedPassword)
 			testUser := &models.User{
-				IDUser:       "test-refresh-user",
 				Email:        &email,
 				PasswordHash: &hashedPasswordStr,
 				Role:         "user",
-				Name:         "Test Refresh User",
+				DisplayName:  "Test Refresh User",
 			}
-			if err := testCtx.db.Create(testUser).Error; err != nil {
+			if err := testCtx.repos.Users.Create(context.Background(), testUser); err != nil {
 				return err
 			}
-			// Create a test refresh token in database
-			testRefreshToken := &models.RefreshToken{
-				UserID:    testUser.ID,
-				Token:     "test_refresh_token_valid",
-				ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
-			}
-			if err := testCtx.db.Create(testRefreshToken).Error; err != nil {
+			// Issue a real refresh token (stored as the start of a session)
+			_, refresh, err := auth.IssueTokens(context.Background(), *testUser, auth.Device{})
+			if err != nil {
 				return err
 			}
-			testCtx.lastTokens["refresh_token"] = "test_refresh_token_valid"
+			testCtx.lastTokens["refresh_token"] = refresh
 		}
 	}
 	return nil
@@ -384,23 +493,73 @@ func queTengoUnTokenInvalido(tokenType string) error {
 	return nil
 }
 func queEstoyAutenticado() error {
-	testCtx.lastTokens = map[string]string{
-		"access_token": "valid_access_token_placeholder",
+	return authenticate("user")
+}
+
+func queEstoyAutenticadoConRol(role string) error {
+	return authenticate(role)
+}
+
+// queSeSolicitoOTPPara requests a code through the API; the code is read
+// from the SMS captured by smsOutbox
+func queSeSolicitoOTPPara(phone string) error {
+	if err := hagoPOSTaCon("/api/v1/auth/otp/send", &godog.DocString{
+		Content: fmt.Sprintf(`{ "phone": %q }`, phone),
+	}); err != nil {
+		return err
+	}
+	if err := laRespuestaEs(http.StatusOK); err != nil {
+		return fmt.Errorf("otp/send: %w: %s", err, testCtx.lastResp.Body.String())
+	}
+	msg, ok := smsOutbox.Last(phone)
+	if !ok {
+		return fmt.Errorf("no SMS sent to %s", phone)
+	}
+	m := otpCode.FindStringSubmatch(msg.Body)
+	if m == nil {
+		return fmt.Errorf("SMS to %s has no code: %q", phone, msg.Body)
 	}
+	testCtx.otp, testCtx.otpPhone = m[1], phone
 	return nil
 }
-func queEstoyAutenticadoConRol(role string) error {
-	testCtx.lastTokens = map[string]string{
-		"access_token": "valid_access_token_" + role,
+
+func elSMSEnviadoContieneCodigo(phone string, digits int) error {
+	msg, ok := smsOutbox.Last(phone)
+	if !ok {
+		return fmt.Errorf("no SMS sent to %s", phone)
+	}
+	if testCtx.otp == "" || len(testCtx.otp) != digits {
+		return fmt.Errorf("expected a %d-digit code, got %q", digits, testCtx.otp)
+	}
+	if !strings.Contains(msg.Body, testCtx.otp) {
+		return fmt.Errorf("SMS to %s does not contain the code %s: %q", phone, testCtx.otp, msg.Body)
+	}
+	return nil
+}
+
+func noExisteUsuarioConEseTelefono() error { return nil }
+
+// heRealizadoIntentosFallidos sends wrong codes for the last requested OTP
+func heRealizadoIntentosFallidos(attempts int) error {
+	if testCtx.otpPhone == "" {
+		return fmt.Errorf("no OTP requested")
+	}
+	wrong := "000000"
+	if testCtx.otp == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < attempts; i++ {
+		if err := hagoPOSTaCon("/api/v1/auth/otp/verify", &godog.DocString{
+			Content: fmt.Sprintf(`{ "phone": %q, "code": %q }`, testCtx.otpPhone, wrong),
+		}); err != nil {
+			return err
+		}
 	}
 	return nil
 }
-func queSeSolicitoOTPPara(phone string) error        { return nil }
-func elCodigoGeneradoFue(code string) error          { return nil }
-func noExisteUsuarioConEseTelefono() error           { return nil }
-func heRealizadoIntentosFallidos(attempts int) error { return nil }
-func elSistemaCreaUsuarioConRol(role string) error   { return nil }
-func queNoEstoyAutenticado() error                   { return nil }
+
+func elSistemaCreaUsuarioConRol(role string) error { return nil }
+func queNoEstoyAutenticado() error                 { return nil }
 func hagoGETa(endpoint string) error {
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
@@ -419,16 +578,20 @@ func hagoGETa(endpoint string) error {
 	return nil
 }
 func queEstoyAutenticadoConTokenExpirado() error {
-	testCtx.lastTokens = map[string]string{
-		"access_token": "expired_token",
-	}
-	return nil
+	token, err := forgeAccessToken(signingKey, time.Now().Add(-time.Minute))
+	testCtx.lastTokens = map[string]string{"access_token": token}
+	return err
 }
+
+// quePresentoTokenConFirmaInvalida signs with another key under the test kid
 func quePresentoTokenConFirmaInvalida() error {
-	testCtx.lastTokens = map[string]string{
-		"access_token": "invalid_token",
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
 	}
-	return nil
+	token, err := forgeAccessToken(other, time.Now().Add(time.Hour))
+	testCtx.lastTokens = map[string]string{"access_token": token}
+	return err
 }
 
 // Missing step implementations
@@ -456,13 +619,23 @@ func elCuerpoContieneUnNuevoYUnNuevo(field1, field2 string) error {
 }
 
 func elNuevoUsuarioQuedaRegistradoConRol(role string) error {
-	// Check if user was created with the specified role
-	// This would require checking the database
+	email := testCtx.lastBody["email"]
+	user, err := testCtx.repos.Users.FindByEmail(context.Background(), email)
+	if err != nil {
+		return fmt.Errorf("user %s: %w", email, err)
+	}
+	if user.Role != role {
+		return fmt.Errorf("user %s has role %q, want %q", email, user.Role, role)
+	}
 	return nil
 }
 
+// elQuedaInvalidado checks that the refresh token can no longer be used
 func elQuedaInvalidado(tokenType string) error {
-	// Check if token was invalidated
+	token := testCtx.lastTokens[tokenType]
+	if _, _, err := auth.RotateRefreshToken(context.Background(), token, auth.Device{}); err == nil {
+		return fmt.Errorf("%s still works", tokenType)
+	}
 	return nil
 }
 
@@ -497,10 +670,10 @@ func hagoPOSTa(endpoint string) error {
 	return nil
 }
 
+// tengoUnActivo checks the session opened by the authentication step
 func tengoUnActivo(tokenType string) error {
-	// Store active token
-	testCtx.lastTokens = map[string]string{
-		tokenType: "active_" + tokenType,
+	if testCtx.lastTokens[tokenType] == "" {
+		return fmt.Errorf("no %s: authenticate first", tokenType)
 	}
 	return nil
 }
@@ -41,14 +41,14 @@ Feature: Autenticación y control de acceso (JWT + OTP)
       { "email": "user@ciudad.com", "password": "mala" }
       """
     Then la respuesta es 401
-    And el cuerpo contiene "message" con "Credenciales inválidas"
+    And el cuerpo contiene "error" con "Invalid credentials"
 
   @auth @refresh
   Scenario: Refresh token exitoso
     Given que tengo un "refresh_token" válido
     When hago POST a "/api/v1/auth/refresh" con:
       """
-      { "refresh_token": "test_refresh_token_valid" }
+      { "refresh_token": "{{refresh_token}}" }
       """
     Then la respuesta es 200
     And el cuerpo contiene un nuevo "access_token" y un nuevo "refresh_token"
@@ -69,7 +69,7 @@ Feature: Autenticación y control de acceso (JWT + OTP)
     And tengo un "refresh_token" activo
     When hago POST a "/api/v1/auth/logout" con:
       """
-      { "refresh_token": "test_refresh_token_valid" }
+      { "refresh_token": "{{refresh_token}}" }
       """
     Then la respuesta es 204
     And el "refresh_token" queda invalidado
@@ -83,16 +83,16 @@ Feature: Autenticación y control de acceso (JWT + OTP)
       { "phone": "+593983020282" }
       """
     Then la respuesta es 200
-    And el cuerpo contiene "message" con "otp_sent"
+    And el cuerpo contiene "message" con "OTP enviado"
 
   @otp
   Scenario: Verificación de OTP crea usuario si no existe y devuelve tokens
     Given que se solicitó OTP para "+593983020282"
-    And el código generado fue "123456"
+    And el SMS enviado a "+593983020282" contiene un código de 6 dígitos
     And no existe usuario con ese teléfono
     When hago POST a "/api/v1/auth/otp/verify" con:
       """
-      { "phone": "+593983020282", "code": "123456" }
+      { "phone": "+593983020282", "code": "{{otp}}" }
       """
     Then la respuesta es 200
     And el sistema crea un usuario con rol "user"
@@ -102,7 +102,7 @@ Feature: Autenticación y control de acceso (JWT + OTP)
   @otp
   Scenario: Verificación de OTP con código incorrecto
     Given que se solicitó OTP para "+593983020282"
-    And el código generado fue "123456"
+    And el SMS enviado a "+593983020282" contiene un código de 6 dígitos
     When hago POST a "/api/v1/auth/otp/verify" con:
       """
       { "phone": "+593983020282", "code": "000000" }
@@ -124,7 +124,7 @@ Feature: Autenticación y control de acceso (JWT + OTP)
   @otp
   Scenario: Verificación de OTP con código incorrecto
     Given que se solicitó OTP para "+593983020282"
-    And el código generado fue "123456"
+    And el SMS enviado a "+593983020282" contiene un código de 6 dígitos
     When hago POST a "/api/v1/auth/otp/verify" con:
       """
       { "phone": "+593983020282", "code": "000000" }
@@ -160,25 +160,25 @@ Feature: Autenticación y control de acceso (JWT + OTP)
       | admin2@muni.com     | admin      |
 
   @roles @register
-  Scenario: Registro público acepta rol solicitado (no user)
+  Scenario: Registro público no acepta roles de personal
     Given que no estoy autenticado
     When hago POST a "/api/v1/auth/register" con:
       """
       { "email": "nuevo@ciudad.com", "password": "password123", "role": "admin" }
       """
-    Then la respuesta es 201
-    And el usuario se registra con rol "admin"
+    Then la respuesta es 401
+    And el cuerpo contiene "message" con "Se requiere sesión de administrador o invitación"
 
   @security
   Scenario: Access token expirado devuelve 401
     Given que estoy autenticado con access_token expirado
     When hago GET a "/api/v1/admin/users"
     Then la respuesta es 401
-    And el cuerpo contiene "message" con "Token expirado"
+    And el cuerpo contiene "error" con "invalid token"
 
   @security
   Scenario: JWT mal firmado es rechazado
     Given que presento un access_token con firma inválida
     When hago GET a "/api/v1/admin/users"
     Then la respuesta es 401
-    And el cuerpo contiene "message" con "Token inválido"
\ No newline at end of file
+    And el cuerpo contiene "error" con "invalid token"
\ No newline at end of file
@@ -10,25 +10,17 @@ import (
 	"log"
 
 	_ "github.com/Andres09xZ/latacunga_clean_app/auth-service/docs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/server"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load .env file - try multiple possible locations
-	envPaths := []string{".env", "../.env", "../../auth-service/.env"}
-	envLoaded := false
-
-	for _, path := range envPaths {
-		if err := godotenv.Load(path); err == nil {
-			envLoaded = true
-			break
-		}
-	}
-
-	if !envLoaded {
-		log.Println("No .env file found in any expected location, using system environment variables")
+	// Configuration comes from .env, CONFIG_FILE and the environment; refuse
+	// to start if anything is missing or invalid
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	server.Start()
+	server.Start(cfg)
 }
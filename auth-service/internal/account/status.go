@@ -0,0 +1,83 @@
+// Package account decide si una cuenta puede autenticarse según su estado
+// (ACTIVE, SUSPENDED o BANNED). Se consulta al iniciar sesión, al verificar
+// OTP, al renovar tokens y al validar cada access token.
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+var (
+	ErrSuspended = errors.New("account suspended")
+	ErrBanned    = errors.New("account banned")
+	ErrInactive  = errors.New("account inactive")
+)
+
+// Códigos de error devueltos a los clientes
+const (
+	CodeSuspended = "ACCOUNT_SUSPENDED"
+	CodeBanned    = "ACCOUNT_BANNED"
+	CodeInactive  = "ACCOUNT_INACTIVE"
+)
+
+// SuspendedError es el ErrSuspended devuelto por Check junto con el fin de la
+// suspensión (nil si es indefinida)
+type SuspendedError struct {
+	Until *time.Time
+}
+
+func (e *SuspendedError) Error() string { return ErrSuspended.Error() }
+
+func (e *SuspendedError) Is(target error) bool { return target == ErrSuspended }
+
+// Check devuelve nil si la cuenta puede autenticarse. Una suspensión cuyo
+// plazo ya venció se levanta aquí (en users) y la cuenta vuelve a ACTIVE.
+func Check(ctx context.Context, users repository.UserRepository, user *models.User) error {
+	switch user.Status {
+	case models.UserStatusActive:
+		return nil
+	case models.UserStatusSuspended:
+		if user.SuspendedUntil == nil || time.Now().Before(*user.SuspendedUntil) {
+			return &SuspendedError{Until: user.SuspendedUntil}
+		}
+		if err := users.LiftSuspension(ctx, user.ID, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "failed to lift expired suspension", "user_id", user.ID, "error", err)
+			return &SuspendedError{Until: user.SuspendedUntil}
+		}
+		user.Status = models.UserStatusActive
+		user.SuspendedUntil = nil
+		return nil
+	case models.UserStatusBanned:
+		return ErrBanned
+	default:
+		return ErrInactive
+	}
+}
+
+// Code devuelve el código de error para err, o "" si no es un error de estado
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrSuspended):
+		return CodeSuspended
+	case errors.Is(err, ErrBanned):
+		return CodeBanned
+	case errors.Is(err, ErrInactive):
+		return CodeInactive
+	}
+	return ""
+}
+
+// SuspendedUntil devuelve el fin de la suspensión de err, o nil
+func SuspendedUntil(err error) *time.Time {
+	var se *SuspendedError
+	if errors.As(err, &se) {
+		return se.Until
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package account_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// failingLift simula una base caída al levantar la suspensión
+type failingLift struct {
+	repository.UserRepository
+}
+
+func (failingLift) LiftSuspension(context.Context, uuid.UUID, time.Time) error {
+	return errors.New("connection refused")
+}
+
+func TestCheck(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		status     string
+		until      *time.Time
+		failLift   bool
+		wantErr    error
+		wantCode   string
+		wantStatus string
+	}{
+		{name: "active", status: models.UserStatusActive, wantStatus: models.UserStatusActive},
+		{name: "indefinite suspension", status: models.UserStatusSuspended, wantErr: account.ErrSuspended, wantCode: account.CodeSuspended, wantStatus: models.UserStatusSuspended},
+		{name: "running suspension", status: models.UserStatusSuspended, until: &future, wantErr: account.ErrSuspended, wantCode: account.CodeSuspended, wantStatus: models.UserStatusSuspended},
+		{name: "expired suspension is lifted", status: models.UserStatusSuspended, until: &past, wantStatus: models.UserStatusActive},
+		{
+			name:       "lift fails",
+			status:     models.UserStatusSuspended,
+			until:      &past,
+			failLift:   true,
+			wantErr:    account.ErrSuspended,
+			wantCode:   account.CodeSuspended,
+			wantStatus: models.UserStatusSuspended,
+		},
+		{name: "banned", status: models.UserStatusBanned, wantErr: account.ErrBanned, wantCode: account.CodeBanned, wantStatus: models.UserStatusBanned},
+		{name: "unknown status", status: "DELETED", wantErr: account.ErrInactive, wantCode: account.CodeInactive, wantStatus: "DELETED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryUserRepository()
+			email := "staff@example.com"
+			user := &models.User{Email: &email, Role: "operador", DisplayName: email, Status: tt.status, SuspendedUntil: tt.until}
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			var users repository.UserRepository = repo
+			if tt.failLift {
+				users = failingLift{repo}
+			}
+
+			err := account.Check(ctx, users, user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check = %v, want %v", err, tt.wantErr)
+			}
+			if got := account.Code(err); got != tt.wantCode {
+				t.Errorf("Code = %q, want %q", got, tt.wantCode)
+			}
+			// El fin de la suspensión solo acompaña a ErrSuspended
+			var wantUntil *time.Time
+			if tt.wantErr == account.ErrSuspended {
+				wantUntil = tt.until
+			}
+			if got := account.SuspendedUntil(err); (got == nil) != (wantUntil == nil) || (got != nil && !got.Equal(*wantUntil)) {
+				t.Errorf("SuspendedUntil = %v, want %v", got, wantUntil)
+			}
+
+			stored, _ := repo.FindByID(ctx, user.ID)
+			if user.Status != tt.wantStatus || stored.Status != tt.wantStatus {
+				t.Errorf("status = %s (stored %s), want %s", user.Status, stored.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == models.UserStatusActive && (user.SuspendedUntil != nil || stored.SuspendedUntil != nil) {
+				t.Error("suspension end kept after lifting it")
+			}
+		})
+	}
+}
+
+func TestCodeWrapped(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	err := fmt.Errorf("refresh: %w", &account.SuspendedError{Until: &until})
+	if account.Code(err) != account.CodeSuspended {
+		t.Errorf("Code(%v) = %q, want %q", err, account.Code(err), account.CodeSuspended)
+	}
+	if got := account.SuspendedUntil(err); got == nil || !got.Equal(until) {
+		t.Errorf("SuspendedUntil = %v, want %v", got, until)
+	}
+	if account.Code(errors.New("invalid credentials")) != "" || account.Code(nil) != "" {
+		t.Error("Code returned a status code for an unrelated error")
+	}
+}
@@ -0,0 +1,143 @@
+// Package audit guarda y consulta el registro de eventos de seguridad
+// (audit_events). La tabla es de solo inserción: un trigger rechaza UPDATE y
+// DELETE, así que los eventos no se pueden alterar desde la aplicación.
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// Acciones registradas
+const (
+	ActionRegister  = "auth.register"
+	ActionLogin     = "auth.login"
+	ActionMFAVerify = "auth.mfa.verify"
+	ActionOTPSend   = "auth.otp.send"
+	ActionOTPVerify = "auth.otp.verify"
+
+	ActionUserRoleUpdate   = "admin.user.role_update"
+	ActionUserSuspend      = "admin.user.suspend"
+	ActionUserBan          = "admin.user.ban"
+	ActionUserReactivate   = "admin.user.reactivate"
+	ActionUserDelete       = "admin.user.delete"
+	ActionUserUnlock       = "admin.user.unlock"
+	ActionSessionRevoke    = "admin.session.revoke"
+	ActionInvitationCreate = "admin.invitation.create"
+	ActionMFAPolicyUpdate  = "admin.mfa_policy.update"
+)
+
+// Resultados
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Record guarda el evento en repo. Se guarda fuera de la transacción que
+// lleve ctx: los intentos fallidos deshacen la transacción del comando y el
+// evento debe quedar igual. Un fallo al auditar no interrumpe la petición: se
+// registra en el log. Los intentos de login y OTP además actualizan las
+// métricas (ver metrics.go).
+func Record(ctx context.Context, repo repository.AuditRepository, e models.AuditEvent) {
+	countEvent(e)
+	if err := repo.Create(database.WithoutTx(ctx), &e); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit event", "action", e.Action, "outcome", e.Outcome, "error", err)
+	}
+}
+
+// Filter son los criterios de List y Export. Action admite prefijos
+// terminados en "*" (p. ej. "admin.*").
+type Filter = repository.AuditFilter
+
+// Page es una página de resultados de List
+type Page struct {
+	Data     []models.AuditEvent `json:"data"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+// List devuelve los eventos que cumplen el filtro, los más recientes primero
+func List(ctx context.Context, repo repository.AuditRepository, f Filter) (Page, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = DefaultPageSize
+	}
+	if f.PageSize > MaxPageSize {
+		f.PageSize = MaxPageSize
+	}
+
+	page := Page{Page: f.Page, PageSize: f.PageSize, Data: []models.AuditEvent{}}
+	data, total, err := repo.List(ctx, f)
+	if err != nil {
+		return page, err
+	}
+	page.Data, page.Total = data, total
+	return page, nil
+}
+
+// Export escribe en w, como CSV, todos los eventos que cumplen el filtro
+// (sin paginar), los más recientes primero
+func Export(ctx context.Context, repo repository.AuditRepository, f Filter, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "created_at", "actor_id", "action", "target", "outcome", "reason", "ip", "user_agent"}); err != nil {
+		return err
+	}
+
+	var last *models.AuditEvent
+	for {
+		batch, err := repo.ListBefore(ctx, f, last, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range batch {
+			actor := ""
+			if e.ActorID != nil {
+				actor = e.ActorID.String()
+			}
+			if err := cw.Write([]string{
+				e.ID.String(), e.CreatedAt.UTC().Format(time.RFC3339), actor, csvCell(e.Action),
+				csvCell(e.Target), csvCell(e.Outcome), csvCell(e.Reason), csvCell(e.IP), csvCell(e.UserAgent),
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		last = &batch[len(batch)-1]
+	}
+}
+
+// csvCell neutraliza las fórmulas (CSV injection): el target, el motivo y el
+// User-Agent los controla el cliente, y una hoja de cálculo ejecutaría
+// "=HYPERLINK(...)". Las celdas que empiezan con = + - @ tab o CR llevan ' delante.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// exportBatchSize es la cantidad de eventos que Export lee por consulta
+const exportBatchSize = 1000
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"auth.login", "auth.login"},
+		{"Mozilla/5.0 (X11; Linux x86_64)", "Mozilla/5.0 (X11; Linux x86_64)"},
+		{`=HYPERLINK("http://evil.example","x")`, `'=HYPERLINK("http://evil.example","x")`},
+		{"+593987654321", "'+593987654321"},
+		{"-1+1", "'-1+1"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		// Solo cuenta el primer carácter
+		{"a=1", "a=1"},
+		{" =1", " =1"},
+	}
+	for _, tt := range tests {
+		if got := csvCell(tt.in); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// countingRepo cuenta las consultas de Export
+type countingRepo struct {
+	repository.AuditRepository
+	calls int
+}
+
+func (r *countingRepo) ListBefore(ctx context.Context, f Filter, before *models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	r.calls++
+	return r.AuditRepository.ListBefore(ctx, f, before, limit)
+}
+
+func export(t *testing.T, repo repository.AuditRepository, f Filter) [][]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Export(context.Background(), repo, f, &buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 || rows[0][0] != "id" {
+		t.Fatalf("missing header: %v", rows)
+	}
+	return rows[1:]
+}
+
+func TestExportPaging(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{AuditRepository: repository.NewMemoryAuditRepository()}
+
+	// Más de dos lotes, casi todos con el mismo created_at: el corte entre
+	// lotes cae dentro de un mismo instante y solo el id lo desempata
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	total := 2*exportBatchSize + 1
+	for i := 0; i < total; i++ {
+		at := base
+		if i%500 == 0 {
+			at = base.Add(time.Duration(i) * time.Second)
+		}
+		if err := repo.Create(ctx, &models.AuditEvent{Action: ActionLogin, Outcome: OutcomeSuccess, CreatedAt: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows := export(t, repo, Filter{})
+	if len(rows) != total {
+		t.Fatalf("exported %d rows, want %d", len(rows), total)
+	}
+	if repo.calls != 3 {
+		t.Errorf("Export made %d queries, want 3", repo.calls)
+	}
+	seen := map[string]bool{}
+	for i, row := range rows {
+		if seen[row[0]] {
+			t.Fatalf("event %s exported twice", row[0])
+		}
+		seen[row[0]] = true
+		if i > 0 && row[1] > rows[i-1][1] {
+			t.Fatalf("row %d (%s) is newer than row %d (%s)", i, row[1], i-1, rows[i-1][1])
+		}
+	}
+}
+
+func TestExportExactBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{AuditRepository: repository.NewMemoryAuditRepository()}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < exportBatchSize; i++ {
+		if err := repo.Create(ctx, &models.AuditEvent{Action: ActionLogin, Outcome: OutcomeSuccess, CreatedAt: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Un lote lleno obliga a una consulta más, que vuelve vacía
+	if rows := export(t, repo, Filter{}); len(rows) != exportBatchSize {
+		t.Errorf("exported %d rows, want %d", len(rows), exportBatchSize)
+	}
+	if repo.calls != 2 {
+		t.Errorf("Export made %d queries, want 2", repo.calls)
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryAuditRepository()
+	admin := uuid.New()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.AuditEvent{
+		{ActorID: &admin, Action: ActionUserSuspend, Target: "u1", Outcome: OutcomeSuccess, CreatedAt: base},
+		{ActorID: &admin, Action: ActionUserBan, Target: "u2", Outcome: OutcomeDenied, CreatedAt: base.Add(time.Minute)},
+		{Action: ActionLogin, Target: "=cmd|' /C calc'!A0", Outcome: OutcomeFailure, IP: "203.0.113.7", CreatedAt: base.Add(2 * time.Minute)},
+		{Action: ActionLogin, Target: "u3", Outcome: OutcomeSuccess, IP: "198.51.100.1", CreatedAt: base.Add(3 * time.Minute)},
+	}
+	for i := range events {
+		if err := repo.Create(ctx, &events[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from, to := base.Add(time.Minute), base.Add(3*time.Minute)
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		targets []string
+	}{
+		{name: "no filter", targets: []string{"u3", "'=cmd|' /C calc'!A0", "u2", "u1"}},
+		{name: "action prefix", filter: Filter{Action: "admin.*"}, targets: []string{"u2", "u1"}},
+		{name: "exact action", filter: Filter{Action: ActionLogin}, targets: []string{"u3", "'=cmd|' /C calc'!A0"}},
+		{name: "actor", filter: Filter{ActorID: &admin}, targets: []string{"u2", "u1"}},
+		{name: "outcome", filter: Filter{Outcome: OutcomeDenied}, targets: []string{"u2"}},
+		{name: "ip", filter: Filter{IP: "198.51.100.1"}, targets: []string{"u3"}},
+		{name: "time range", filter: Filter{From: &from, To: &to}, targets: []string{"'=cmd|' /C calc'!A0", "u2"}},
+		{name: "no match", filter: Filter{Target: "nobody"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := export(t, repo, tt.filter)
+			if len(rows) != len(tt.targets) {
+				t.Fatalf("exported %d rows, want %d: %v", len(rows), len(tt.targets), rows)
+			}
+			for i, row := range rows {
+				if row[4] != tt.targets[i] {
+					t.Errorf("row %d target = %q, want %q", i, row[4], tt.targets[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Los contadores de autenticación se derivan de los eventos de auditoría, que
+// ya traen el resultado y el motivo de cada intento. Los motivos son códigos
+// fijos (bad_password, account_locked, invalid_code...), así que las series
+// quedan acotadas.
+
+var (
+	loginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Login attempts by method (password, mfa, otp), outcome and reason.",
+	}, []string{"method", "outcome", "reason"})
+
+	otpSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_otp_sent_total",
+		Help: "OTP send requests by outcome and reason.",
+	}, []string{"outcome", "reason"})
+
+	otpVerified = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_otp_verifications_total",
+		Help: "OTP verification attempts by outcome and reason.",
+	}, []string{"outcome", "reason"})
+)
+
+// loginMethods asocia cada acción que abre sesión con su método
+var loginMethods = map[string]string{
+	ActionLogin:     "password",
+	ActionMFAVerify: "mfa",
+	ActionOTPVerify: "otp",
+}
+
+func countEvent(e models.AuditEvent) {
+	if method, ok := loginMethods[e.Action]; ok {
+		loginAttempts.WithLabelValues(method, e.Outcome, e.Reason).Inc()
+	}
+	switch e.Action {
+	case ActionOTPSend:
+		otpSent.WithLabelValues(e.Outcome, e.Reason).Inc()
+	case ActionOTPVerify:
+		otpVerified.WithLabelValues(e.Outcome, e.Reason).Inc()
+	}
+}
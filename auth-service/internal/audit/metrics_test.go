@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// counter devuelve el valor de la serie name con exactamente esas etiquetas
+func counter(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			if len(m.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestRecordCountsAuthOutcomes(t *testing.T) {
+	type series struct {
+		name   string
+		labels map[string]string
+	}
+	login := func(method, outcome, reason string) series {
+		return series{"auth_login_attempts_total", map[string]string{"method": method, "outcome": outcome, "reason": reason}}
+	}
+	otp := func(name, outcome, reason string) series {
+		return series{name, map[string]string{"outcome": outcome, "reason": reason}}
+	}
+
+	tests := []struct {
+		name  string
+		event models.AuditEvent
+		// want son las series que suben en uno
+		want []series
+	}{
+		{
+			name:  "password login",
+			event: models.AuditEvent{Action: ActionLogin, Outcome: OutcomeFailure, Reason: "bad_password"},
+			want:  []series{login("password", OutcomeFailure, "bad_password")},
+		},
+		{
+			name:  "mfa login",
+			event: models.AuditEvent{Action: ActionMFAVerify, Outcome: OutcomeSuccess},
+			want:  []series{login("mfa", OutcomeSuccess, "")},
+		},
+		{
+			name:  "otp verification",
+			event: models.AuditEvent{Action: ActionOTPVerify, Outcome: OutcomeFailure, Reason: "invalid_code"},
+			want:  []series{login("otp", OutcomeFailure, "invalid_code"), otp("auth_otp_verifications_total", OutcomeFailure, "invalid_code")},
+		},
+		{
+			name:  "otp send",
+			event: models.AuditEvent{Action: ActionOTPSend, Outcome: OutcomeDenied, Reason: "rate_limited"},
+			want:  []series{otp("auth_otp_sent_total", OutcomeDenied, "rate_limited")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := make([]float64, len(tt.want))
+			for i, s := range tt.want {
+				before[i] = counter(t, s.name, s.labels)
+			}
+			Record(context.Background(), repository.NewMemoryAuditRepository(), tt.event)
+			for i, s := range tt.want {
+				if got := counter(t, s.name, s.labels) - before[i]; got != 1 {
+					t.Errorf("%s%v increased by %v, want 1", s.name, s.labels, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordIgnoresOtherActions(t *testing.T) {
+	total := func() float64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sum float64
+		for _, f := range families {
+			switch f.GetName() {
+			case "auth_login_attempts_total", "auth_otp_sent_total", "auth_otp_verifications_total":
+				for _, m := range f.GetMetric() {
+					sum += m.GetCounter().GetValue()
+				}
+			}
+		}
+		return sum
+	}
+
+	before := total()
+	for _, action := range []string{ActionRegister, ActionUserSuspend, ActionSessionRevoke} {
+		Record(context.Background(), repository.NewMemoryAuditRepository(), models.AuditEvent{Action: action, Outcome: OutcomeSuccess})
+	}
+	if got := total() - before; got != 0 {
+		t.Errorf("administrative events moved the auth counters by %v", got)
+	}
+}
@@ -1,94 +1,168 @@
 package auth
 
 import (
-	"os"
-	"strconv"
+	"errors"
 	"time"
 
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Vigencia de los tokens; la fija InitKeys desde la configuración
 var (
-	jwtSecret            = []byte(os.Getenv("JWT_SECRET"))
-	accessExpiration, _  = strconv.Atoi(os.Getenv("JWT_EXPIRATION_HOURS"))
-	refreshExpiration, _ = strconv.Atoi(os.Getenv("REFRESH_EXPIRATION_HOURS"))
+	accessTTL  = time.Hour
+	refreshTTL = 7 * 24 * time.Hour
 )
 
-type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
-}
+// Tipos de token; un refresh token nunca debe aceptarse como access token y viceversa.
+const (
+	TokenTypeAccess     = authz.TokenTypeAccess
+	TokenTypeRefresh    = authz.TokenTypeRefresh
+	TokenTypeInvitation = "invitation"
+
+	// Tokens del login en dos pasos: el paso de contraseña devuelve uno de
+	// estos en lugar de access/refresh tokens
+	TokenTypeMFAChallenge  = "mfa_challenge"
+	TokenTypeMFAEnrollment = "mfa_enrollment"
+)
+
+// MFATokenTTL es la vigencia del token entre el paso de contraseña y el de 2FA
+const MFATokenTTL = 5 * time.Minute
+
+// ErrWrongTokenType se devuelve cuando el token es válido pero de otro tipo.
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// Claims son las claims compartidas con report-service (ver shared/authz)
+type Claims = authz.Claims
 
 // Helper to compute expiry times
 func AccessExpiry() time.Time {
-	if accessExpiration <= 0 {
-		accessExpiration = 1 // default 1 hour
-	}
-	return time.Now().Add(time.Duration(accessExpiration) * time.Hour)
+	return time.Now().Add(accessTTL)
 }
 
 func RefreshExpiry() time.Time {
-	if refreshExpiration <= 0 {
-		refreshExpiration = 24 * 7 // default 7 days (in hours)
-	}
-	return time.Now().Add(time.Duration(refreshExpiration) * time.Hour)
+	return time.Now().Add(refreshTTL)
 }
 
-// GenerateTokens crea access y refresh tokens para un usuario
-func GenerateTokens(userID, email, role string) (string, string, error) {
-	// Access token
-	accessClaims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+// GenerateAccessToken crea un access token para un usuario dentro de una sesión
+func GenerateAccessToken(userID, email, role, sessionID string) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		TokenType: TokenTypeAccess,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(AccessExpiry()),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err := at.SignedString(jwtSecret)
-	if err != nil {
-		return "", "", err
+	return signClaims(claims)
+}
+
+// GenerateRefreshToken crea un refresh token cuyo jti es el ID con el que
+// se guarda en la tabla refresh_tokens
+func GenerateRefreshToken(userID, email, role, tokenID string, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		TokenType: TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
+	return signClaims(claims)
+}
 
-	// Refresh token
-	refreshClaims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+// GenerateInvitationToken firma una invitación de registro; el jti es el ID de
+// la invitación guardada y Email queda vacío si la invitación no es nominativa
+func GenerateInvitationToken(invitationID, email, role string, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		Email:     email,
+		Role:      role,
+		TokenType: TokenTypeInvitation,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(RefreshExpiry()),
+			ID:        invitationID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err := rt.SignedString(jwtSecret)
+	return signClaims(claims)
+}
+
+// ValidateInvitationToken valida firma, expiración y tipo de una invitación
+func ValidateInvitationToken(tokenStr string) (*Claims, error) {
+	claims, err := parseToken(tokenStr)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeInvitation || claims.ID == "" {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
+
+// GenerateMFAToken crea el token de corta duración del login en dos pasos
+func GenerateMFAToken(userID, tokenType string) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFATokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
+	return signClaims(claims)
+}
 
-	return accessToken, refreshToken, nil
+// ValidateMFAToken valida un token del login en dos pasos del tipo indicado
+func ValidateMFAToken(tokenStr, tokenType string) (*Claims, error) {
+	claims, err := parseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != tokenType || claims.UserID == "" {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
 }
 
-// ValidateToken valida y parsea un token JWT retornando las claims
+// ValidateToken valida y parsea un access token JWT retornando las claims
 func ValidateToken(tokenStr string) (*Claims, error) {
-	parser := jwt.NewParser(jwt.WithValidMethods([]string{"HS256"}))
-	token, err := parser.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	claims, err := parseToken(tokenStr)
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	if claims.TokenType != TokenTypeAccess {
+		return nil, ErrWrongTokenType
 	}
-	return nil, jwt.ErrTokenInvalidClaims
+	return claims, nil
 }
 
-// ValidateRefreshToken valida un refresh token
+// ValidateRefreshToken valida un refresh token. Solo comprueba firma, expiración
+// y tipo; el estado (revocado, rotado) se consulta en la base de datos.
 func ValidateRefreshToken(tokenStr string) (*Claims, error) {
-	return ValidateToken(tokenStr) // Same validation for now
+	claims, err := parseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
+
+func parseToken(tokenStr string) (*Claims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
+	token, err := parser.ParseWithClaims(tokenStr, &Claims{}, verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, jwt.ErrTokenInvalidClaims
 }
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeysNotLoaded = errors.New("signing keys not loaded")
+	ErrUnknownKey    = errors.New("unknown signing key")
+)
+
+// signingKey es una llave identificada por kid. Las llaves retiradas solo
+// tienen parte pública: siguen validando tokens emitidos pero ya no firman.
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+type keySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+	order  []string
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   *keySet
+)
+
+// InitKeys carga las llaves de firma y fija la vigencia de los tokens.
+//
+// JWT_KEYS_DIR apunta a un directorio con llaves PEM; el nombre del archivo
+// sin extensión es el kid. Se admiten llaves privadas RSA (RS256) y Ed25519
+// (EdDSA) y llaves públicas (*.pub.pem) de llaves retiradas. JWT_ACTIVE_KID
+// indica con qué llave se firma; por defecto la última en orden alfabético.
+// Sin JWT_KEYS_DIR se genera una llave Ed25519 efímera, solo si development
+// (APP_ENV=development); en otro entorno es un error de arranque.
+func InitKeys(c config.JWT, development bool) {
+	accessTTL, refreshTTL = c.AccessTTL(), c.RefreshTTL()
+
+	dir := c.KeysDir
+	var (
+		ks  *keySet
+		err error
+	)
+	if dir == "" {
+		if !development {
+			log.Fatal("JWT_KEYS_DIR is required unless APP_ENV=development")
+		}
+		slog.Warn("JWT_KEYS_DIR not set, using an ephemeral Ed25519 signing key")
+		ks, err = ephemeralKeySet()
+	} else {
+		ks, err = loadKeySet(dir, c.ActiveKID)
+	}
+	if err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+
+	keysMu.Lock()
+	keys = ks
+	keysMu.Unlock()
+	slog.Info("JWT signing key loaded", "kid", ks.active.kid, "alg", ks.active.method.Alg(), "verification_keys", len(ks.keys))
+}
+
+func loadKeySet(dir, activeKid string) (*keySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	ks := &keySet{keys: map[string]*signingKey{}}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+		if _, dup := ks.keys[kid]; dup {
+			return nil, fmt.Errorf("duplicate kid %q", kid)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		k, err := parseKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ks.keys[kid] = k
+		ks.order = append(ks.order, kid)
+		if k.private != nil && (activeKid == "" || activeKid == kid) {
+			ks.active = k
+		}
+	}
+	if ks.active == nil {
+		if activeKid != "" {
+			return nil, fmt.Errorf("active kid %q not found or has no private key", activeKid)
+		}
+		return nil, fmt.Errorf("no private keys found in %s", dir)
+	}
+	return ks, nil
+}
+
+func parseKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := &signingKey{kid: kid}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if pub, ok := k.public.(*rsa.PublicKey); ok && pub.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+	return k, nil
+}
+
+func ephemeralKeySet() (*keySet, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := "ephemeral-" + hex.EncodeToString(pub[:4])
+	k := &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, private: priv, public: pub}
+	return &keySet{active: k, keys: map[string]*signingKey{kid: k}, order: []string{kid}}, nil
+}
+
+func currentKeys() (*keySet, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if keys == nil {
+		return nil, ErrKeysNotLoaded
+	}
+	return keys, nil
+}
+
+// signClaims firma las claims con la llave activa e incluye su kid en la cabecera
+func signClaims(claims Claims) (string, error) {
+	ks, err := currentKeys()
+	if err != nil {
+		return "", err
+	}
+	t := jwt.NewWithClaims(ks.active.method, claims)
+	t.Header["kid"] = ks.active.kid
+	return t.SignedString(ks.active.private)
+}
+
+// verificationKey resuelve la llave pública según el kid del token
+func verificationKey(t *jwt.Token) (interface{}, error) {
+	ks, err := currentKeys()
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return k.public, nil
+}
+
+// JWK es una llave pública en formato RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS es el documento publicado en /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS devuelve las llaves públicas con las que se pueden verificar los tokens
+func PublicJWKS() (JWKS, error) {
+	ks, err := currentKeys()
+	if err != nil {
+		return JWKS{}, err
+	}
+
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		k := ks.keys[kid]
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
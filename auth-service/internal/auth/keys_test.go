@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Las llaves RSA tardan en generarse: se crean una vez para todo el paquete
+var (
+	rsaOnce sync.Once
+	rsaKey  *rsa.PrivateKey
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	rsaOnce.Do(func() {
+		var err error
+		if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return rsaKey
+}
+
+func pemBlock(t *testing.T, typ string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func pkcs8(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pemBlock(t, "PRIVATE KEY", der)
+}
+
+func pkix(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pemBlock(t, "PUBLIC KEY", der)
+}
+
+func newEd25519(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// keysDir escribe files (nombre → PEM) en un directorio temporal
+func keysDir(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseKey(t *testing.T) {
+	rsaPriv := testRSAKey(t)
+	edPriv := newEd25519(t)
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantAlg     string
+		wantPrivate bool
+		wantErr     string
+	}{
+		{name: "rsa pkcs1", data: pemBlock(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPriv)), wantAlg: "RS256", wantPrivate: true},
+		{name: "rsa pkcs8", data: pkcs8(t, rsaPriv), wantAlg: "RS256", wantPrivate: true},
+		{name: "rsa public", data: pkix(t, &rsaPriv.PublicKey), wantAlg: "RS256"},
+		{name: "ed25519 pkcs8", data: pkcs8(t, edPriv), wantAlg: "EdDSA", wantPrivate: true},
+		{name: "ed25519 public", data: pkix(t, edPriv.Public()), wantAlg: "EdDSA"},
+		{name: "not PEM", data: []byte("not a key"), wantErr: "invalid PEM data"},
+		{name: "unsupported PEM block", data: pemBlock(t, "EC PRIVATE KEY", ecDER), wantErr: `unsupported PEM block "EC PRIVATE KEY"`},
+		{name: "unsupported key type", data: pkcs8(t, ecPriv), wantErr: "unsupported key type *ecdsa.PrivateKey"},
+		{name: "unsupported public key type", data: pkix(t, &ecPriv.PublicKey), wantErr: "unsupported key type *ecdsa.PublicKey"},
+		{name: "short rsa key", data: pkcs8(t, smallRSA), wantErr: "at least 2048 bits"},
+		{name: "corrupt DER", data: pemBlock(t, "PRIVATE KEY", []byte{1, 2, 3}), wantErr: "asn1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := parseKey("k1", tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.kid != "k1" || k.method.Alg() != tt.wantAlg || (k.private != nil) != tt.wantPrivate || k.public == nil {
+				t.Errorf("key = kid %q alg %s private %t, want alg %s private %t", k.kid, k.method.Alg(), k.private != nil, tt.wantAlg, tt.wantPrivate)
+			}
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaPriv := testRSAKey(t)
+	edOld, edNew := newEd25519(t), newEd25519(t)
+
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		activeKid string
+		wantKids  []string
+		// wantActive es el kid con el que se firma
+		wantActive string
+		wantErr    string
+	}{
+		{
+			name:       "kid is the file name",
+			files:      map[string][]byte{"2024-01.pem": pkcs8(t, edOld)},
+			wantKids:   []string{"2024-01"},
+			wantActive: "2024-01",
+		},
+		{
+			name:       "latest private key signs by default",
+			files:      map[string][]byte{"2024-01.pem": pkcs8(t, edOld), "2024-06.pem": pkcs8(t, edNew), "2023-rsa.pem": pkcs8(t, rsaPriv)},
+			wantKids:   []string{"2023-rsa", "2024-01", "2024-06"},
+			wantActive: "2024-06",
+		},
+		{
+			name:       "active kid picks the signing key",
+			files:      map[string][]byte{"2024-01.pem": pkcs8(t, edOld), "2024-06.pem": pkcs8(t, edNew)},
+			activeKid:  "2024-01",
+			wantKids:   []string{"2024-01", "2024-06"},
+			wantActive: "2024-01",
+		},
+		{
+			name:       "retired public keys only verify",
+			files:      map[string][]byte{"2023.pub.pem": pkix(t, edOld.Public()), "2024.pem": pkcs8(t, edNew)},
+			wantKids:   []string{"2023", "2024"},
+			wantActive: "2024",
+		},
+		{
+			name:       "a later retired key is not active",
+			files:      map[string][]byte{"2024.pem": pkcs8(t, edNew), "2025.pub.pem": pkix(t, edOld.Public())},
+			wantKids:   []string{"2024", "2025"},
+			wantActive: "2024",
+		},
+		{
+			name:    "duplicate kid",
+			files:   map[string][]byte{"k1.pem": pkcs8(t, edNew), "k1.pub.pem": pkix(t, edNew.Public())},
+			wantErr: `duplicate kid "k1"`,
+		},
+		{
+			name:    "only retired keys",
+			files:   map[string][]byte{"k1.pub.pem": pkix(t, edOld.Public())},
+			wantErr: "no private keys found",
+		},
+		{
+			name:    "empty directory",
+			wantErr: "no private keys found",
+		},
+		{
+			name:      "active kid is retired",
+			files:     map[string][]byte{"k1.pub.pem": pkix(t, edOld.Public()), "k2.pem": pkcs8(t, edNew)},
+			activeKid: "k1",
+			wantErr:   `active kid "k1" not found or has no private key`,
+		},
+		{
+			name:    "unsupported key in the directory",
+			files:   map[string][]byte{"k1.pem": pkcs8(t, edNew), "k2.pem": []byte("garbage")},
+			wantErr: "k2.pem: invalid PEM data",
+		},
+		{
+			name:       "other files are ignored",
+			files:      map[string][]byte{"k1.pem": pkcs8(t, edNew), "README": []byte("keys")},
+			wantKids:   []string{"k1"},
+			wantActive: "k1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := loadKeySet(keysDir(t, tt.files), tt.activeKid)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(ks.order, ",") != strings.Join(tt.wantKids, ",") {
+				t.Errorf("kids = %v, want %v", ks.order, tt.wantKids)
+			}
+			if ks.active.kid != tt.wantActive {
+				t.Errorf("active kid = %q, want %q", ks.active.kid, tt.wantActive)
+			}
+		})
+	}
+}
+
+// TestKeyRotation carga llaves desde JWT_KEYS_DIR como al arrancar: los
+// tokens firmados con una llave retirada siguen validando y el JWKS la publica
+func TestKeyRotation(t *testing.T) {
+	t.Cleanup(func() { keys = nil })
+	rsaPriv := testRSAKey(t)
+	edOld, edNew := newEd25519(t), newEd25519(t)
+
+	// Antes de la rotación firma la llave vieja
+	InitKeys(config.JWT{KeysDir: keysDir(t, map[string][]byte{"old.pem": pkcs8(t, edOld)}), AccessHours: 1, RefreshHours: 168}, false)
+	oldToken, err := GenerateAccessToken("u1", "a@example.com", "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := keysDir(t, map[string][]byte{
+		"old.pub.pem": pkix(t, edOld.Public()),
+		"new.pem":     pkcs8(t, edNew),
+		"rsa.pem":     pemBlock(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPriv)),
+	})
+	InitKeys(config.JWT{KeysDir: dir, ActiveKID: "new", AccessHours: 1, RefreshHours: 168}, false)
+
+	newToken, err := GenerateAccessToken("u1", "a@example.com", "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("new token header = %v, want kid new and EdDSA", parsed.Header)
+	}
+	for name, token := range map[string]string{"retired key": oldToken, "active key": newToken} {
+		if _, err := ValidateToken(token); err != nil {
+			t.Errorf("%s: ValidateToken: %v", name, err)
+		}
+	}
+
+	set, err := PublicJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]JWK{}
+	for _, k := range set.Keys {
+		got[k.Kid] = k
+	}
+	if len(got) != 3 {
+		t.Fatalf("JWKS has %d keys, want 3: %+v", len(got), set.Keys)
+	}
+	if k := got["old"]; k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" || k.Use != "sig" {
+		t.Errorf("retired key = %+v", k)
+	}
+	if k := got["rsa"]; k.Kty != "RSA" || k.Alg != "RS256" || k.N == "" || k.E != "AQAB" {
+		t.Errorf("rsa key = %+v", k)
+	}
+}
+
+func TestVerificationKeyRejects(t *testing.T) {
+	t.Cleanup(func() { keys = nil })
+	edKey := newEd25519(t)
+	InitKeys(config.JWT{KeysDir: keysDir(t, map[string][]byte{"k1.pem": pkcs8(t, edKey)}), AccessHours: 1, RefreshHours: 168}, false)
+
+	claims := Claims{
+		UserID:           "u1",
+		TokenType:        TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		t.Helper()
+		tok := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			tok.Header["kid"] = kid
+		}
+		s, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "unknown kid", token: sign(jwt.SigningMethodEdDSA, "k2", edKey), wantErr: ErrUnknownKey},
+		{name: "missing kid", token: sign(jwt.SigningMethodEdDSA, "", edKey), wantErr: ErrUnknownKey},
+		{name: "alg does not match the key", token: sign(jwt.SigningMethodRS256, "k1", testRSAKey(t)), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "hmac with the public key", token: sign(jwt.SigningMethodHS256, "k1", []byte(edKey.Public().(ed25519.PublicKey))), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "another key with the same kid", token: sign(jwt.SigningMethodEdDSA, "k1", newEd25519(t)), wantErr: jwt.ErrTokenSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateToken(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeysNotLoaded(t *testing.T) {
+	keysMu.Lock()
+	saved := keys
+	keys = nil
+	keysMu.Unlock()
+	t.Cleanup(func() { keys = saved })
+
+	if _, err := GenerateAccessToken("u1", "", "user", "s1"); !errors.Is(err, ErrKeysNotLoaded) {
+		t.Errorf("GenerateAccessToken err = %v, want ErrKeysNotLoaded", err)
+	}
+	if _, err := PublicJWKS(); !errors.Is(err, ErrKeysNotLoaded) {
+		t.Errorf("PublicJWKS err = %v, want ErrKeysNotLoaded", err)
+	}
+}
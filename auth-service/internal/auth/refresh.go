@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// Device identifica el dispositivo desde el que se emite o usa un refresh token
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// InitRepositories fija los repositorios con que se emiten, rotan y revocan
+// los tokens. db abre la transacción de la rotación; nil con repositorios en memoria.
+func InitRepositories(r repository.Repositories, db *gorm.DB) {
+	users = r.Users
+	tokens = r.RefreshTokens
+	txDB = db
+}
+
+var (
+	users  repository.UserRepository
+	tokens repository.RefreshTokenRepository
+	txDB   *gorm.DB
+)
+
+// IssueTokens genera un par access/refresh para el usuario e inicia una nueva
+// familia de refresh tokens (una sesión por inicio de sesión). Las cuentas que
+// no están activas reciben el error de account correspondiente.
+func IssueTokens(ctx context.Context, user models.User, device Device) (string, string, error) {
+	if err := account.Check(ctx, users, &user); err != nil {
+		return "", "", err
+	}
+	session := models.RefreshToken{FamilyID: uuid.New(), SessionStartedAt: time.Now()}
+	return issueTokens(ctx, user, session, uuid.New(), device)
+}
+
+// RotateRefreshToken canjea un refresh token por un par nuevo de la misma familia.
+// El token presentado queda revocado; si se vuelve a presentar un token que ya
+// fue rotado se asume que fue robado y se revoca la familia completa.
+func RotateRefreshToken(ctx context.Context, tokenStr string, device Device) (string, string, error) {
+	claims, err := ValidateRefreshToken(tokenStr)
+	if err != nil {
+		return "", "", ErrRefreshTokenInvalid
+	}
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return "", "", ErrRefreshTokenInvalid
+	}
+
+	stored, err := tokens.FindByID(ctx, tokenID)
+	if err != nil {
+		return "", "", ErrRefreshTokenInvalid
+	}
+	if stored.Revoked {
+		if stored.ReplacedByID != nil {
+			return "", "", revokeReusedFamily(database.WithoutTx(ctx), stored.FamilyID)
+		}
+		return "", "", ErrRefreshTokenInvalid
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return "", "", ErrRefreshTokenInvalid
+	}
+
+	var accessToken, refreshToken string
+	err = database.Transaction(ctx, txDB, func(ctx context.Context) error {
+		user, err := users.FindByID(ctx, stored.UserID)
+		if err != nil {
+			return ErrRefreshTokenInvalid
+		}
+		if err := account.Check(ctx, users, user); err != nil {
+			return err
+		}
+
+		newID := uuid.New()
+		rotated, err := tokens.Rotate(ctx, stored.ID, newID, time.Now())
+		if err != nil {
+			return err
+		}
+		// Otra petición rotó el mismo token entre la lectura y la actualización
+		if !rotated {
+			return ErrRefreshTokenReused
+		}
+
+		accessToken, refreshToken, err = issueTokens(ctx, *user, *stored, newID, device)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return "", "", revokeReusedFamily(database.WithoutTx(ctx), stored.FamilyID)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// RevokeFamily revoca todos los refresh tokens activos de una familia.
+func RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return tokens.RevokeFamily(ctx, familyID)
+}
+
+// RevokeRefreshToken cierra la sesión a la que pertenece el refresh token
+// revocando su familia. El token debe pertenecer al usuario indicado.
+func RevokeRefreshToken(ctx context.Context, tokenStr, userID string) error {
+	claims, err := ValidateRefreshToken(tokenStr)
+	if err != nil || claims.UserID != userID {
+		return ErrRefreshTokenInvalid
+	}
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return ErrRefreshTokenInvalid
+	}
+
+	stored, err := tokens.FindByID(ctx, tokenID)
+	if err != nil {
+		return ErrRefreshTokenInvalid
+	}
+	return RevokeFamily(ctx, stored.FamilyID)
+}
+
+// RevokeUserTokens revoca todos los refresh tokens activos del usuario (todas sus sesiones).
+func RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	return tokens.RevokeUser(ctx, userID)
+}
+
+// revokeReusedFamily revoca la familia y devuelve ErrRefreshTokenReused. Quien
+// lo llame dentro de un comando debe pasar un contexto sin transacción: el
+// error la deshace.
+func revokeReusedFamily(ctx context.Context, familyID uuid.UUID) error {
+	slog.WarnContext(ctx, "refresh token reuse detected, revoking family", "family_id", familyID)
+	if err := RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// issueTokens emite un par de tokens dentro de la sesión (familia) de session
+func issueTokens(ctx context.Context, user models.User, session models.RefreshToken, tokenID uuid.UUID, device Device) (string, string, error) {
+	subject := tokenSubject(user)
+
+	accessToken, err := GenerateAccessToken(user.ID.String(), subject, user.Role, session.FamilyID.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	expiresAt := RefreshExpiry()
+	refreshToken, err := GenerateRefreshToken(user.ID.String(), subject, user.Role, tokenID.String(), expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+
+	stored := models.RefreshToken{
+		ID:               tokenID,
+		UserID:           user.ID,
+		FamilyID:         session.FamilyID,
+		SessionStartedAt: session.SessionStartedAt,
+		UserAgent:        truncate(device.UserAgent, 512),
+		IP:               device.IP,
+		ExpiresAt:        expiresAt,
+	}
+	if err := tokens.Create(ctx, &stored); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// tokenSubject devuelve el email del usuario o, para ciudadanos registrados por OTP, su teléfono
+func tokenSubject(user models.User) string {
+	if user.Email != nil {
+		return *user.Email
+	}
+	if user.Phone != nil {
+		return *user.Phone
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var device = auth.Device{UserAgent: "test", IP: "203.0.113.7"}
+
+// setup inicia sesión con un usuario nuevo sobre repositorios en memoria y
+// devuelve los repositorios, el usuario y su refresh token
+func setup(t *testing.T) (repository.Repositories, *models.User, string) {
+	t.Helper()
+	repos := repository.NewMemory()
+	auth.InitKeys(config.JWT{AccessHours: 1, RefreshHours: 168}, true)
+	auth.InitRepositories(repos, nil)
+
+	email := "staff@example.com"
+	user := &models.User{Email: &email, Role: "operador", DisplayName: "Staff"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	_, refresh, err := auth.IssueTokens(context.Background(), *user, device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repos, user, refresh
+}
+
+// tokenID devuelve el jti de un refresh token
+func tokenID(t *testing.T, refresh string) uuid.UUID {
+	t.Helper()
+	claims, err := auth.ValidateRefreshToken(refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uuid.MustParse(claims.ID)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	repos, user, refresh := setup(t)
+
+	access, rotated, err := auth.RotateRefreshToken(ctx, refresh, device)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if rotated == refresh {
+		t.Fatal("refresh token was not rotated")
+	}
+	if claims, err := auth.ValidateToken(access); err != nil || claims.UserID != user.ID.String() {
+		t.Fatalf("access token for %v: %v", claims, err)
+	}
+
+	old, _ := repos.RefreshTokens.FindByID(ctx, tokenID(t, refresh))
+	next, _ := repos.RefreshTokens.FindByID(ctx, tokenID(t, rotated))
+	if !old.Revoked || old.ReplacedByID == nil || *old.ReplacedByID != next.ID {
+		t.Errorf("old token = %+v, want revoked and replaced by %s", old, next.ID)
+	}
+	if next.FamilyID != old.FamilyID || !next.SessionStartedAt.Equal(old.SessionStartedAt) {
+		t.Errorf("rotated token left the session: family %s → %s", old.FamilyID, next.FamilyID)
+	}
+}
+
+func TestRotateRefreshTokenRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		// token prepara el estado y devuelve el token a presentar
+		token   func(t *testing.T, repos repository.Repositories, user *models.User, refresh string) string
+		wantErr error
+		// familyRevoked indica si la sesión debe quedar cerrada
+		familyRevoked bool
+	}{
+		{
+			name: "reused token revokes the family",
+			token: func(t *testing.T, _ repository.Repositories, _ *models.User, refresh string) string {
+				if _, _, err := auth.RotateRefreshToken(context.Background(), refresh, device); err != nil {
+					t.Fatal(err)
+				}
+				return refresh
+			},
+			wantErr:       auth.ErrRefreshTokenReused,
+			familyRevoked: true,
+		},
+		{
+			name: "revoked token",
+			token: func(t *testing.T, repos repository.Repositories, user *models.User, refresh string) string {
+				if err := auth.RevokeUserTokens(context.Background(), user.ID); err != nil {
+					t.Fatal(err)
+				}
+				return refresh
+			},
+			wantErr:       auth.ErrRefreshTokenInvalid,
+			familyRevoked: true,
+		},
+		{
+			name: "access token",
+			token: func(t *testing.T, _ repository.Repositories, user *models.User, _ string) string {
+				access, err := auth.GenerateAccessToken(user.ID.String(), *user.Email, user.Role, uuid.NewString())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return access
+			},
+			wantErr: auth.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "unknown token",
+			token: func(t *testing.T, _ repository.Repositories, user *models.User, _ string) string {
+				forged, err := auth.GenerateRefreshToken(user.ID.String(), *user.Email, user.Role, uuid.NewString(), auth.RefreshExpiry())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return forged
+			},
+			wantErr: auth.ErrRefreshTokenInvalid,
+		},
+		{
+			name:    "garbage",
+			token:   func(*testing.T, repository.Repositories, *models.User, string) string { return "not-a-jwt" },
+			wantErr: auth.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "suspended account",
+			token: func(t *testing.T, repos repository.Repositories, user *models.User, refresh string) string {
+				until := time.Now().Add(time.Hour)
+				if err := repos.Users.SetStatus(context.Background(), user.ID, models.UserStatusSuspended, &until); err != nil {
+					t.Fatal(err)
+				}
+				return refresh
+			},
+			wantErr: account.ErrSuspended,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user, refresh := setup(t)
+			family, _ := repos.RefreshTokens.FindByID(ctx, tokenID(t, refresh))
+
+			_, _, err := auth.RotateRefreshToken(ctx, tt.token(t, repos, user, refresh), device)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			open, err := repos.RefreshTokens.HasActiveFamily(ctx, user.ID, family.FamilyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if open == tt.familyRevoked {
+				t.Errorf("session open = %t, want %t", open, !tt.familyRevoked)
+			}
+		})
+	}
+}
+
+// Dos peticiones que rotan el mismo token a la vez: solo una obtiene tokens y
+// la otra se trata como reutilización
+func TestRotateRefreshTokenConcurrent(t *testing.T) {
+	_, _, refresh := setup(t)
+
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = auth.RotateRefreshToken(context.Background(), refresh, device)
+		}(i)
+	}
+	wg.Wait()
+
+	ok := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case !errors.Is(err, auth.ErrRefreshTokenReused):
+			t.Errorf("err = %v, want ErrRefreshTokenReused", err)
+		}
+	}
+	if ok != 1 {
+		t.Errorf("%d rotations succeeded, want 1", ok)
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  func(user *models.User) string
+		wantErr error
+	}{
+		{name: "own token", userID: func(u *models.User) string { return u.ID.String() }},
+		{name: "someone else's token", userID: func(*models.User) string { return uuid.NewString() }, wantErr: auth.ErrRefreshTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, user, refresh := setup(t)
+
+			if err := auth.RevokeRefreshToken(ctx, refresh, tt.userID(user)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			_, _, err := auth.RotateRefreshToken(ctx, refresh, device)
+			if tt.wantErr == nil && !errors.Is(err, auth.ErrRefreshTokenInvalid) {
+				t.Errorf("rotate after logout err = %v, want ErrRefreshTokenInvalid", err)
+			}
+			if tt.wantErr != nil && err != nil {
+				t.Errorf("token revoked by another user: %v", err)
+			}
+		})
+	}
+}
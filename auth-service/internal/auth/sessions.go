@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound se devuelve si la sesión no existe, no está activa o es de otro usuario
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session es una sesión activa: la familia de refresh tokens de un inicio de sesión
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ListSessions devuelve las sesiones activas del usuario, la más reciente primero.
+// currentSessionID marca la sesión del access token con que se consulta.
+func ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]Session, error) {
+	// Cada sesión activa tiene exactamente un refresh token vigente (el último rotado)
+	active, err := tokens.ListActive(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(active))
+	for _, t := range active {
+		sessions = append(sessions, Session{
+			ID:         t.FamilyID,
+			UserAgent:  t.UserAgent,
+			IP:         t.IP,
+			CreatedAt:  t.SessionStartedAt,
+			LastUsedAt: t.CreatedAt,
+			ExpiresAt:  t.ExpiresAt,
+			Current:    t.FamilyID.String() == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession cierra una sesión del usuario
+func RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	active, err := tokens.HasActiveFamily(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSessionNotFound
+	}
+	return RevokeFamily(ctx, sessionID)
+}
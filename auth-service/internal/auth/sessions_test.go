@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// login abre una sesión nueva de user desde device y devuelve su id y su refresh token
+func login(t *testing.T, user *models.User, device auth.Device) (string, string) {
+	t.Helper()
+	access, refresh, err := auth.IssueTokens(context.Background(), *user, device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := auth.ValidateToken(access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims.SessionID, refresh
+}
+
+func TestListSessions(t *testing.T) {
+	ctx := context.Background()
+	repos, user, _ := setup(t)
+	phone := auth.Device{UserAgent: "Android " + strings.Repeat("x", 600), IP: "198.51.100.9"}
+	phoneSession, phoneRefresh := login(t, user, phone)
+	laptopSession, _ := login(t, user, auth.Device{UserAgent: "Firefox", IP: "192.0.2.1"})
+
+	// Rotar el refresh token no abre otra sesión
+	if _, _, err := auth.RotateRefreshToken(ctx, phoneRefresh, phone); err != nil {
+		t.Fatal(err)
+	}
+	// Las sesiones de otros usuarios no aparecen
+	email := "other@example.com"
+	other := &models.User{Email: &email, Role: "operador", DisplayName: "Other"}
+	if err := repos.Users.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	login(t, other, device)
+
+	sessions, err := auth.ListSessions(ctx, user.ID, laptopSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La de setup, la del teléfono y la del portátil
+	if len(sessions) != 3 {
+		t.Fatalf("%d sessions, want 3: %+v", len(sessions), sessions)
+	}
+	byID := map[string]auth.Session{}
+	for i, s := range sessions {
+		byID[s.ID.String()] = s
+		if i > 0 && s.LastUsedAt.After(sessions[i-1].LastUsedAt) {
+			t.Errorf("session %d is more recent than session %d", i, i-1)
+		}
+		if s.Current != (s.ID.String() == laptopSession) {
+			t.Errorf("session %s current = %t", s.ID, s.Current)
+		}
+	}
+
+	got, ok := byID[phoneSession]
+	if !ok {
+		t.Fatalf("phone session %s missing", phoneSession)
+	}
+	if got.IP != phone.IP || len(got.UserAgent) != 512 || !strings.HasPrefix(got.UserAgent, "Android ") {
+		t.Errorf("phone session device = %q (%d bytes) from %s", got.UserAgent[:16], len(got.UserAgent), got.IP)
+	}
+	if !got.LastUsedAt.After(got.CreatedAt) || !got.ExpiresAt.After(got.LastUsedAt) {
+		t.Errorf("phone session times: created %v, last used %v, expires %v", got.CreatedAt, got.LastUsedAt, got.ExpiresAt)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	repos, user, _ := setup(t)
+	session, refresh := login(t, user, device)
+	sessionID := uuid.MustParse(session)
+
+	email := "other@example.com"
+	other := &models.User{Email: &email, Role: "operador", DisplayName: "Other"}
+	if err := repos.Users.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// No se cierra la sesión de otro usuario ni una inexistente
+	for name, tt := range map[string]struct{ userID, sessionID uuid.UUID }{
+		"other user's session": {other.ID, sessionID},
+		"unknown session":      {user.ID, uuid.New()},
+	} {
+		if err := auth.RevokeSession(ctx, tt.userID, tt.sessionID); !errors.Is(err, auth.ErrSessionNotFound) {
+			t.Errorf("%s: RevokeSession = %v, want ErrSessionNotFound", name, err)
+		}
+	}
+
+	if err := auth.RevokeSession(ctx, user.ID, sessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, _, err := auth.RotateRefreshToken(ctx, refresh, device); err == nil {
+		t.Error("refresh token of a revoked session still rotates")
+	}
+	sessions, _ := auth.ListSessions(ctx, user.ID, "")
+	for _, s := range sessions {
+		if s.ID == sessionID {
+			t.Error("revoked session still listed")
+		}
+	}
+	if len(sessions) != 1 {
+		t.Errorf("%d sessions left, want the one from setup", len(sessions))
+	}
+	if err := auth.RevokeSession(ctx, user.ID, sessionID); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Errorf("revoking twice = %v, want ErrSessionNotFound", err)
+	}
+}
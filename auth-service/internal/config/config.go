@@ -0,0 +1,238 @@
+// Package config define la configuración tipada del auth-service. Se carga
+// una sola vez al arrancar (Load) y cada paquete recibe su parte; ninguno lee
+// variables de entorno por su cuenta.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
+)
+
+// EnvFiles son los .env que se prueban en orden al arrancar
+var EnvFiles = []string{".env", "../.env", "../../auth-service/.env"}
+
+// Entornos de APP_ENV
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Config es la configuración completa del servicio
+type Config struct {
+	// Env es el entorno (APP_ENV). Solo en development se admiten los
+	// proveedores de SMS y correo locales y la llave JWT efímera.
+	Env  string `yaml:"app_env" env:"APP_ENV" default:"production"`
+	Port int    `yaml:"port" env:"PORT" default:"8080"`
+	// ShutdownTimeout es el plazo para drenar peticiones al recibir SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"15s"`
+	// PublicURL es la URL con la que los clientes llegan al servicio (Swagger)
+	PublicURL string `yaml:"public_url" env:"PUBLIC_URL" default:"http://localhost:8080"`
+	// CORSOrigins son los orígenes permitidos; "*" permite cualquiera
+	CORSOrigins []string `yaml:"cors_origins" env:"CORS_ORIGINS" default:"*"`
+	// TrustedProxies son los proxies (IP o CIDR) cuyo X-Forwarded-For se
+	// acepta como IP del cliente. Vacío no confía en ninguno: los límites por
+	// IP usan la dirección de la conexión.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	Database         Database `yaml:"database"`
+	JWT              JWT      `yaml:"jwt"`
+	SMS              SMS      `yaml:"sms"`
+	Mail             Mail     `yaml:"mail"`
+	Lockout          Lockout  `yaml:"lockout"`
+	OTP              OTP      `yaml:"otp"`
+	MFAIssuer        string   `yaml:"mfa_issuer" env:"MFA_ISSUER" default:"Latacunga Limpia"`
+	PasswordResetURL string   `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+
+	Log     logging.Config `yaml:"log"`
+	Tracing tracing.Config `yaml:"tracing"`
+}
+
+// Database configura la conexión a Postgres
+type Database struct {
+	URL string `yaml:"url" env:"DB_URL"`
+	// Migrate aplica las migraciones pendientes al arrancar (DB_MIGRATE=off lo desactiva)
+	Migrate bool `yaml:"migrate" env:"DB_MIGRATE" default:"on"`
+}
+
+// JWT configura la firma y vigencia de los tokens
+type JWT struct {
+	// KeysDir tiene las llaves PEM de firma; vacío usa una llave efímera
+	// (solo con APP_ENV=development)
+	KeysDir   string `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+	ActiveKID string `yaml:"active_kid" env:"JWT_ACTIVE_KID"`
+	// Vigencias en horas, como en las variables originales
+	AccessHours  int `yaml:"access_hours" env:"JWT_EXPIRATION_HOURS" default:"1"`
+	RefreshHours int `yaml:"refresh_hours" env:"REFRESH_EXPIRATION_HOURS" default:"168"`
+}
+
+// AccessTTL es la vigencia de los access tokens
+func (j JWT) AccessTTL() time.Duration { return time.Duration(j.AccessHours) * time.Hour }
+
+// RefreshTTL es la vigencia de los refresh tokens
+func (j JWT) RefreshTTL() time.Duration { return time.Duration(j.RefreshHours) * time.Hour }
+
+// SMS configura el proveedor de mensajes de texto (ver sms.InitSender)
+type SMS struct {
+	Provider   string `yaml:"provider" env:"SMS_PROVIDER" default:"http"`
+	HTTPURL    string `yaml:"http_url" env:"SMS_HTTP_URL"`
+	HTTPToken  string `yaml:"http_token" env:"SMS_HTTP_TOKEN"`
+	From       string `yaml:"from" env:"SMS_FROM"`
+	OutboxPath string `yaml:"outbox_path" env:"SMS_OUTBOX_PATH" default:"sms_outbox.jsonl"`
+}
+
+// Mail configura el proveedor de correo (ver mail.InitMailer)
+type Mail struct {
+	Provider string `yaml:"provider" env:"MAIL_PROVIDER" default:"smtp"`
+	Host     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	Port     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	Username string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	Password string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"smtp_from" env:"SMTP_FROM"`
+}
+
+// Lockout configura el bloqueo por contraseñas incorrectas (ver lockout.Policy)
+type Lockout struct {
+	Threshold        int           `yaml:"threshold" env:"LOGIN_LOCK_THRESHOLD" default:"5"`
+	LockDuration     time.Duration `yaml:"lock_duration" env:"LOGIN_LOCK_DURATION" default:"15m"`
+	BaseDelay        time.Duration `yaml:"delay_base" env:"LOGIN_DELAY_BASE" default:"1s"`
+	MaxDelay         time.Duration `yaml:"delay_max" env:"LOGIN_DELAY_MAX" default:"30s"`
+	IPFailuresHourly int           `yaml:"ip_failures_hourly" env:"LOGIN_IP_FAILURES_HOURLY" default:"30"`
+}
+
+// OTP configura los límites de envío y verificación de códigos OTP (ver
+// ratelimit.OTPPolicy)
+type OTP struct {
+	ResendCooldown       time.Duration `yaml:"resend_cooldown" env:"OTP_RESEND_COOLDOWN" default:"60s"`
+	PhoneHourly          int           `yaml:"phone_hourly" env:"OTP_PHONE_HOURLY" default:"5"`
+	PhoneDaily           int           `yaml:"phone_daily" env:"OTP_PHONE_DAILY" default:"10"`
+	IPHourly             int           `yaml:"ip_hourly" env:"OTP_IP_HOURLY" default:"20"`
+	IPDaily              int           `yaml:"ip_daily" env:"OTP_IP_DAILY" default:"100"`
+	IPFailedVerifyHourly int           `yaml:"ip_failed_verify_hourly" env:"OTP_IP_FAILED_VERIFY_HOURLY" default:"30"`
+	MaxFailedRounds      int           `yaml:"max_failed_rounds" env:"OTP_MAX_FAILED_ROUNDS" default:"3"`
+	FailedRoundsWindow   time.Duration `yaml:"failed_rounds_window" env:"OTP_FAILED_ROUNDS_WINDOW" default:"24h"`
+	Lockout              time.Duration `yaml:"lockout" env:"OTP_LOCKOUT" default:"1h"`
+}
+
+// minTokenLength es el largo mínimo de los secretos compartidos con terceros
+const minTokenLength = 16
+
+// Development indica si el servicio corre en desarrollo local
+func (c *Config) Development() bool {
+	return c.Env == EnvDevelopment
+}
+
+// Load lee .env, CONFIG_FILE y el entorno, y valida el resultado
+func Load() (*Config, error) {
+	var c Config
+	if err := config.Load(&c, config.Options{EnvFiles: EnvFiles}); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate comprueba todos los valores y devuelve todos los errores juntos
+func (c *Config) Validate() error {
+	errs := []error{
+		config.OneOf("APP_ENV", c.Env, EnvDevelopment, EnvStaging, EnvProduction),
+		config.Port("PORT", c.Port),
+		config.Positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout),
+		config.URL("PUBLIC_URL", c.PublicURL),
+		config.Origins("CORS_ORIGINS", c.CORSOrigins),
+		config.Proxies("TRUSTED_PROXIES", c.TrustedProxies),
+		config.Required("DB_URL", c.Database.URL),
+		config.PositiveInt("JWT_EXPIRATION_HOURS", c.JWT.AccessHours),
+		config.PositiveInt("REFRESH_EXPIRATION_HOURS", c.JWT.RefreshHours),
+		config.URL("PASSWORD_RESET_URL", c.PasswordResetURL),
+		config.Required("MFA_ISSUER", c.MFAIssuer),
+		c.Log.Validate(),
+		c.Tracing.Validate(),
+	}
+	// DB_URL también puede ser un DSN clave=valor; solo se valida como URL si lo es
+	if strings.Contains(c.Database.URL, "://") {
+		errs = append(errs, config.URL("DB_URL", c.Database.URL, "postgres", "postgresql"))
+	}
+	if c.JWT.AccessHours >= c.JWT.RefreshHours {
+		errs = append(errs, errors.New("JWT_EXPIRATION_HOURS must be shorter than REFRESH_EXPIRATION_HOURS"))
+	}
+	if c.JWT.KeysDir != "" {
+		if info, err := os.Stat(c.JWT.KeysDir); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("JWT_KEYS_DIR %q is not a directory", c.JWT.KeysDir))
+		}
+	} else if c.JWT.ActiveKID != "" {
+		errs = append(errs, errors.New("JWT_ACTIVE_KID requires JWT_KEYS_DIR"))
+	} else if !c.Development() {
+		// Una llave efímera invalida todas las sesiones en cada reinicio y
+		// no la comparten las réplicas
+		errs = append(errs, errors.New("JWT_KEYS_DIR is required unless APP_ENV=development"))
+	}
+
+	// Los proveedores locales no entregan los mensajes: fuera de desarrollo
+	// los códigos OTP y los enlaces de recuperación nunca llegarían
+	smsProviders, mailProviders := []string{"http"}, []string{"smtp"}
+	if c.Development() {
+		smsProviders = append(smsProviders, "file", "memory")
+		mailProviders = append(mailProviders, "memory")
+	}
+
+	errs = append(errs, config.OneOf("SMS_PROVIDER", c.SMS.Provider, smsProviders...))
+	if c.SMS.Provider == "http" {
+		errs = append(errs,
+			config.Required("SMS_HTTP_URL", c.SMS.HTTPURL),
+			config.URL("SMS_HTTP_URL", c.SMS.HTTPURL),
+			config.MinLength("SMS_HTTP_TOKEN", c.SMS.HTTPToken, minTokenLength),
+		)
+	}
+	if c.SMS.Provider == "file" {
+		errs = append(errs, config.Required("SMS_OUTBOX_PATH", c.SMS.OutboxPath))
+	}
+
+	errs = append(errs, config.OneOf("MAIL_PROVIDER", c.Mail.Provider, mailProviders...))
+	if c.Mail.Provider == "smtp" {
+		errs = append(errs,
+			config.Required("SMTP_HOST", c.Mail.Host),
+			config.Port("SMTP_PORT", c.Mail.Port),
+			config.Required("SMTP_FROM", c.Mail.From),
+		)
+		if c.Mail.Username != "" {
+			errs = append(errs, config.Required("SMTP_PASSWORD", c.Mail.Password))
+		}
+	}
+
+	errs = append(errs,
+		config.PositiveInt("LOGIN_LOCK_THRESHOLD", c.Lockout.Threshold),
+		config.Positive("LOGIN_LOCK_DURATION", c.Lockout.LockDuration),
+		config.Positive("LOGIN_DELAY_BASE", c.Lockout.BaseDelay),
+		config.Positive("LOGIN_DELAY_MAX", c.Lockout.MaxDelay),
+		config.PositiveInt("LOGIN_IP_FAILURES_HOURLY", c.Lockout.IPFailuresHourly),
+	)
+	if c.Lockout.BaseDelay > c.Lockout.MaxDelay {
+		errs = append(errs, errors.New("LOGIN_DELAY_BASE must not exceed LOGIN_DELAY_MAX"))
+	}
+
+	errs = append(errs,
+		config.Positive("OTP_RESEND_COOLDOWN", c.OTP.ResendCooldown),
+		config.PositiveInt("OTP_PHONE_HOURLY", c.OTP.PhoneHourly),
+		config.PositiveInt("OTP_PHONE_DAILY", c.OTP.PhoneDaily),
+		config.PositiveInt("OTP_IP_HOURLY", c.OTP.IPHourly),
+		config.PositiveInt("OTP_IP_DAILY", c.OTP.IPDaily),
+		config.PositiveInt("OTP_IP_FAILED_VERIFY_HOURLY", c.OTP.IPFailedVerifyHourly),
+		config.PositiveInt("OTP_MAX_FAILED_ROUNDS", c.OTP.MaxFailedRounds),
+		config.Positive("OTP_FAILED_ROUNDS_WINDOW", c.OTP.FailedRoundsWindow),
+		config.Positive("OTP_LOCKOUT", c.OTP.Lockout),
+	)
+	if c.OTP.PhoneHourly > c.OTP.PhoneDaily {
+		errs = append(errs, errors.New("OTP_PHONE_HOURLY must not exceed OTP_PHONE_DAILY"))
+	}
+	if c.OTP.IPHourly > c.OTP.IPDaily {
+		errs = append(errs, errors.New("OTP_IP_HOURLY must not exceed OTP_IP_DAILY"))
+	}
+	return errors.Join(errs...)
+}
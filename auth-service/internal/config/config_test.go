@@ -0,0 +1,124 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+)
+
+// prodEnv es una configuración de producción válida
+func prodEnv(t *testing.T) map[string]string {
+	return map[string]string{
+		"APP_ENV":        "production",
+		"DB_URL":         "postgres://auth:secret@db:5432/auth",
+		"JWT_KEYS_DIR":   t.TempDir(),
+		"SMS_PROVIDER":   "http",
+		"SMS_HTTP_URL":   "https://sms.example.com/send",
+		"SMS_HTTP_TOKEN": "0123456789abcdef",
+		"MAIL_PROVIDER":  "smtp",
+		"SMTP_HOST":      "smtp.example.com",
+		"SMTP_FROM":      "no-reply@example.com",
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		// env se aplica sobre prodEnv; un valor vacío quita la variable
+		env  map[string]string
+		want []string
+	}{
+		{name: "valid production"},
+		{name: "DSN database URL", env: map[string]string{"DB_URL": "host=db user=auth dbname=auth"}},
+		{
+			name: "development allows local providers and an ephemeral key",
+			env:  map[string]string{"APP_ENV": "development", "JWT_KEYS_DIR": "", "SMS_PROVIDER": "file", "MAIL_PROVIDER": "memory"},
+		},
+		{name: "file SMS provider in production", env: map[string]string{"SMS_PROVIDER": "file"}, want: []string{"SMS_PROVIDER must be one of [http]"}},
+		{name: "memory SMS provider in staging", env: map[string]string{"APP_ENV": "staging", "SMS_PROVIDER": "memory"}, want: []string{"SMS_PROVIDER must be one of [http]"}},
+		{name: "memory mail provider in production", env: map[string]string{"MAIL_PROVIDER": "memory"}, want: []string{"MAIL_PROVIDER must be one of [smtp]"}},
+		{name: "ephemeral key in production", env: map[string]string{"JWT_KEYS_DIR": ""}, want: []string{"JWT_KEYS_DIR is required unless APP_ENV=development"}},
+		{name: "active kid without keys dir", env: map[string]string{"APP_ENV": "development", "JWT_KEYS_DIR": "", "JWT_ACTIVE_KID": "k1"}, want: []string{"JWT_ACTIVE_KID requires JWT_KEYS_DIR"}},
+		{name: "keys dir that does not exist", env: map[string]string{"JWT_KEYS_DIR": "/nonexistent/keys"}, want: []string{"is not a directory"}},
+		{name: "unknown environment", env: map[string]string{"APP_ENV": "prod"}, want: []string{"APP_ENV must be one of"}},
+		{name: "missing database", env: map[string]string{"DB_URL": ""}, want: []string{"DB_URL is required"}},
+		{name: "database URL of another engine", env: map[string]string{"DB_URL": "mysql://db/auth"}, want: []string{"DB_URL must be an absolute"}},
+		{name: "short SMS token", env: map[string]string{"SMS_HTTP_TOKEN": "short"}, want: []string{"SMS_HTTP_TOKEN must be at least 16"}},
+		{name: "SMTP user without password", env: map[string]string{"SMTP_USERNAME": "mailer"}, want: []string{"SMTP_PASSWORD is required"}},
+		{name: "access longer than refresh", env: map[string]string{"JWT_EXPIRATION_HOURS": "200"}, want: []string{"JWT_EXPIRATION_HOURS must be shorter"}},
+		{name: "base delay over max", env: map[string]string{"LOGIN_DELAY_BASE": "1m"}, want: []string{"LOGIN_DELAY_BASE must not exceed LOGIN_DELAY_MAX"}},
+		{name: "OTP hourly cap over daily", env: map[string]string{"OTP_PHONE_HOURLY": "20"}, want: []string{"OTP_PHONE_HOURLY must not exceed OTP_PHONE_DAILY"}},
+		{name: "zero OTP lockout", env: map[string]string{"OTP_LOCKOUT": "0s"}, want: []string{"OTP_LOCKOUT"}},
+		{name: "CORS origin with a path", env: map[string]string{"CORS_ORIGINS": "https://app.example.com/app"}, want: []string{"CORS_ORIGINS: invalid origin"}},
+		{name: "unparsable duration", env: map[string]string{"LOGIN_LOCK_DURATION": "15"}, want: []string{`LOGIN_LOCK_DURATION: invalid duration "15"`}},
+		{
+			// Todos los errores se informan juntos
+			name: "several errors at once",
+			env:  map[string]string{"SMS_PROVIDER": "file", "DB_URL": "", "PORT": "70000"},
+			want: []string{"SMS_PROVIDER", "DB_URL is required", "PORT must be a TCP port"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sin .env ni YAML: solo las variables del caso
+			saved := config.EnvFiles
+			config.EnvFiles = nil
+			t.Cleanup(func() { config.EnvFiles = saved })
+			t.Setenv("CONFIG_FILE", "")
+
+			env := prodEnv(t)
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+
+			c, err := config.Load()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if c.Env != env["APP_ENV"] || c.Development() != (env["APP_ENV"] == "development") {
+					t.Errorf("env = %q, development = %t", c.Env, c.Development())
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Load accepted an invalid configuration")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	saved := config.EnvFiles
+	config.EnvFiles = nil
+	t.Cleanup(func() { config.EnvFiles = saved })
+	for k, v := range prodEnv(t) {
+		t.Setenv(k, v)
+	}
+	file := filepath.Join(t.TempDir(), "auth.yaml")
+	if err := os.WriteFile(file, []byte("port: 9090\nlockout:\n  threshold: 7\nsms:\n  from: Latacunga\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	// El entorno gana sobre el YAML
+	t.Setenv("LOGIN_LOCK_THRESHOLD", "9")
+
+	c, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 9090 || c.Lockout.Threshold != 9 || c.SMS.From != "Latacunga" {
+		t.Errorf("port = %d, threshold = %d, sms from = %q", c.Port, c.Lockout.Threshold, c.SMS.From)
+	}
+}
@@ -0,0 +1,135 @@
+// Package cqrs implementa el bus de comandos y consultas del auth-service.
+// Cada mensaje (un struct) tiene un único handler tipado; los comandos se
+// ejecutan dentro de una transacción y todos los mensajes pasan por la cadena
+// de middleware (validación, logging). Los handlers HTTP solo traducen la
+// petición a un mensaje y el resultado a la respuesta, así que la lógica se
+// puede probar sin Gin.
+package cqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"gorm.io/gorm"
+)
+
+// Kind distingue comandos (modifican estado) de consultas (solo leen)
+type Kind string
+
+const (
+	KindCommand Kind = "command"
+	KindQuery   Kind = "query"
+)
+
+// Handler procesa un mensaje M y devuelve un resultado R. Normalmente es el
+// método Handle de un struct que guarda sus dependencias.
+type Handler[M, R any] func(ctx context.Context, msg M) (R, error)
+
+// Info describe el mensaje que se está despachando
+type Info struct {
+	Kind Kind
+	Name string
+}
+
+// Next es el siguiente eslabón de la cadena de middleware
+type Next func(ctx context.Context, msg any) (any, error)
+
+// Middleware envuelve el despacho de cada mensaje
+type Middleware func(info Info, next Next) Next
+
+// Bus enruta cada mensaje a su handler
+type Bus struct {
+	db         *gorm.DB
+	middleware []Middleware
+
+	mu     sync.RWMutex
+	routes map[reflect.Type]route
+}
+
+type route struct {
+	info   Info
+	handle Next
+}
+
+// New crea un bus. db abre las transacciones de los comandos (con db nil,
+// p. ej. sobre repositorios en memoria, corren sin transacción); middleware
+// se aplica en orden, el primero es el más externo.
+func New(db *gorm.DB, middleware ...Middleware) *Bus {
+	return &Bus{db: db, middleware: middleware, routes: make(map[reflect.Type]route)}
+}
+
+// HandleCommand registra h como handler del comando M. Cada despacho corre en
+// una transacción (database.Transaction) que se confirma solo si h no
+// devuelve error; los repositorios la toman del contexto.
+func HandleCommand[M, R any](b *Bus, h Handler[M, R]) {
+	handle := adapt(h)
+	b.register(reflect.TypeFor[M](), KindCommand, func(ctx context.Context, msg any) (any, error) {
+		var res any
+		err := database.Transaction(ctx, b.db, func(ctx context.Context) error {
+			var err error
+			res, err = handle(ctx, msg)
+			return err
+		})
+		return res, err
+	})
+}
+
+// HandleQuery registra h como handler de la consulta M
+func HandleQuery[M, R any](b *Bus, h Handler[M, R]) {
+	b.register(reflect.TypeFor[M](), KindQuery, adapt(h))
+}
+
+// Send despacha el comando cmd
+func Send[R, M any](ctx context.Context, b *Bus, cmd M) (R, error) {
+	return dispatch[R](ctx, b, KindCommand, cmd)
+}
+
+// Ask despacha la consulta q
+func Ask[R, M any](ctx context.Context, b *Bus, q M) (R, error) {
+	return dispatch[R](ctx, b, KindQuery, q)
+}
+
+func (b *Bus) register(t reflect.Type, kind Kind, handle Next) {
+	info := Info{Kind: kind, Name: t.Name()}
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handle = b.middleware[i](info, handle)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, dup := b.routes[t]; dup {
+		panic(fmt.Sprintf("cqrs: handler already registered for %s", t))
+	}
+	b.routes[t] = route{info: info, handle: handle}
+}
+
+func dispatch[R any](ctx context.Context, b *Bus, kind Kind, msg any) (R, error) {
+	var zero R
+	t := reflect.TypeOf(msg)
+
+	b.mu.RLock()
+	r, ok := b.routes[t]
+	b.mu.RUnlock()
+	if !ok || r.info.Kind != kind {
+		return zero, fmt.Errorf("cqrs: no %s handler registered for %s", kind, t)
+	}
+
+	res, err := r.handle(ctx, msg)
+	if res == nil {
+		return zero, err
+	}
+	out, ok := res.(R)
+	if !ok {
+		return zero, fmt.Errorf("cqrs: %s returns %T, not %s", t, res, reflect.TypeFor[R]())
+	}
+	return out, err
+}
+
+func adapt[M, R any](h Handler[M, R]) Next {
+	return func(ctx context.Context, msg any) (any, error) {
+		return h(ctx, msg.(M))
+	}
+}
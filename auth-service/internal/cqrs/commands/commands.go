@@ -0,0 +1,154 @@
+// Package commands contiene los comandos del auth-service: registro, login,
+// OTP, renovación y revocación de tokens y sesiones, restablecimiento de
+// contraseña, 2FA, administración de usuarios e invitaciones.
+// Cada comando corre en la transacción que abre el bus; lo que debe quedar
+// guardado aunque el comando falle (contadores de intentos, auditoría) se
+// escribe fuera de ella.
+package commands
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/users"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTooManyAttempts    = errors.New("too many attempts")
+	ErrCitizenRole        = errors.New("citizens register by OTP")
+	ErrNotAdmin           = errors.New("only an admin can register accounts")
+	ErrAdminRole          = errors.New("registering admin accounts requires users:manage_admins")
+	ErrNoAuthorization    = errors.New("admin session or invitation required")
+	ErrRoleRequired       = errors.New("role is required")
+	ErrRoleMismatch       = errors.New("role does not match the invitation")
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidPhone       = errors.New("phone must be in E.164 format")
+	ErrRoleNotAllowed     = errors.New("authentication method not allowed for this role")
+	ErrSMSFailed          = errors.New("sms delivery failed")
+	ErrInvalidOTP         = errors.New("invalid otp")
+	ErrOTPExhausted       = errors.New("otp attempts exceeded")
+	// ErrUnauthenticated lo devuelve Validate cuando el comando exige un
+	// usuario autenticado y Origin no lo trae
+	ErrUnauthenticated = errors.New("invalid token")
+)
+
+// RetryError rechaza una petición por límite de intentos; Wait es el tiempo
+// que el cliente debe esperar (cabecera Retry-After)
+type RetryError struct {
+	Err  error
+	Wait time.Duration
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Origin identifica quién envía el comando y desde dónde
+type Origin struct {
+	ActorID   string
+	ActorRole string
+	IP        string
+	UserAgent string
+}
+
+// Device es el dispositivo con el que se abren las sesiones
+func (o Origin) Device() auth.Device {
+	return auth.Device{UserAgent: o.UserAgent, IP: o.IP}
+}
+
+// audit registra en repo un evento cuyo actor es el usuario autenticado, si lo hay
+func (o Origin) audit(ctx context.Context, repo repository.AuditRepository, action, target, outcome, reason string) {
+	var actor *uuid.UUID
+	if id, err := uuid.Parse(o.ActorID); err == nil {
+		actor = &id
+	}
+	o.auditAs(ctx, repo, actor, action, target, outcome, reason)
+}
+
+// auditAs registra en repo un evento con un actor explícito (p. ej. el
+// usuario que acaba de iniciar sesión)
+func (o Origin) auditAs(ctx context.Context, repo repository.AuditRepository, actor *uuid.UUID, action, target, outcome, reason string) {
+	audit.Record(ctx, repo, models.AuditEvent{
+		ActorID:   actor,
+		Action:    action,
+		Target:    target,
+		Outcome:   outcome,
+		Reason:    reason,
+		IP:        o.IP,
+		UserAgent: o.UserAgent,
+	})
+}
+
+// validateActor exige un actor autenticado (el administrador)
+func (o Origin) validateActor() error {
+	if _, err := uuid.Parse(o.ActorID); err != nil {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+// actor es el administrador que envía el comando (ver validateActor)
+func (o Origin) actor() users.Actor {
+	id, _ := uuid.Parse(o.ActorID)
+	return users.Actor{ID: id, Role: o.ActorRole}
+}
+
+// auditResult registra el resultado de una acción administrativa: success si
+// err es nil, denied si la regla de negocio la rechazó y failure en otro caso
+func (o Origin) auditResult(ctx context.Context, repo repository.AuditRepository, action, target, reason string, err error) {
+	switch {
+	case err == nil:
+		o.audit(ctx, repo, action, target, audit.OutcomeSuccess, reason)
+	case errors.Is(err, users.ErrSelf), errors.Is(err, users.ErrInsufficient), errors.Is(err, users.ErrInvalidRole):
+		o.audit(ctx, repo, action, target, audit.OutcomeDenied, err.Error())
+	default:
+		o.audit(ctx, repo, action, target, audit.OutcomeFailure, err.Error())
+	}
+}
+
+// TokenPair es el resultado de los comandos que abren o renuevan una sesión
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Register registra los comandos en el bus
+func Register(bus *cqrs.Bus, repos repository.Repositories) {
+	users := &queries.GetByEmailHandler{Users: repos.Users}
+
+	cqrs.HandleCommand(bus, (&RegisterHandler{Users: repos.Users, Invitations: repos.Invitations, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&LoginHandler{
+		QueryHandler: users,
+		Users:        repos.Users,
+		Lockout:      repos.Lockout,
+		MFA:          repos.MFA,
+		Audit:        repos.Audit,
+	}).Handle)
+	cqrs.HandleCommand(bus, refresh)
+	cqrs.HandleCommand(bus, logout)
+	cqrs.HandleCommand(bus, logoutAll)
+	cqrs.HandleCommand(bus, (&SendOTPHandler{Users: repos.Users, OTPs: repos.OTPs, Limits: repos.OTPLimits, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&VerifyOTPHandler{Users: repos.Users, OTPs: repos.OTPs, Limits: repos.OTPLimits, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&RequestPasswordResetHandler{Users: repos.Users, Resets: repos.PasswordReset}).Handle)
+	cqrs.HandleCommand(bus, (&ConfirmPasswordResetHandler{Users: repos.Users, Resets: repos.PasswordReset}).Handle)
+	cqrs.HandleCommand(bus, (&EnrollTOTPHandler{Users: repos.Users, MFA: repos.MFA}).Handle)
+	cqrs.HandleCommand(bus, (&ConfirmTOTPHandler{Users: repos.Users, MFA: repos.MFA}).Handle)
+	cqrs.HandleCommand(bus, (&VerifyMFAHandler{Users: repos.Users, MFA: repos.MFA, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&DisableTOTPHandler{Users: repos.Users, MFA: repos.MFA}).Handle)
+	cqrs.HandleCommand(bus, (&SetMFARoleRequirementHandler{MFA: repos.MFA, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&RevokeSessionHandler{Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&UpdateUserRoleHandler{Users: repos.Users, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&SetUserStatusHandler{Users: repos.Users, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&DeleteUserHandler{Users: repos.Users, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&UnlockUserHandler{Lockout: repos.Lockout, Audit: repos.Audit}).Handle)
+	cqrs.HandleCommand(bus, (&CreateInvitationHandler{Invitations: repos.Invitations, Audit: repos.Audit}).Handle)
+}
@@ -0,0 +1,620 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/lockout"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/sms"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/users"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Los comandos corren sin base de datos: repositorios en memoria, bus sin
+// transacciones, llave de firma efímera y SMS en memoria
+
+const password = "correct-horse"
+
+type fixture struct {
+	bus    *cqrs.Bus
+	repos  repository.Repositories
+	outbox *sms.MemorySender
+}
+
+func setup(t *testing.T) fixture {
+	t.Helper()
+	f := fixture{repos: repository.NewMemory(), outbox: sms.NewMemorySender()}
+
+	auth.InitKeys(config.JWT{AccessHours: 1, RefreshHours: 168}, true)
+	auth.InitRepositories(f.repos, nil)
+	sms.SetSender(f.outbox)
+	ratelimit.OTP = ratelimit.NewOTPLimiter(ratelimit.DefaultOTPPolicy)
+	lockout.SetPolicy(lockout.Policy{Threshold: 3, LockDuration: time.Minute, IPFailuresHourly: 100})
+	t.Cleanup(func() { lockout.SetPolicy(lockout.DefaultPolicy) })
+
+	f.bus = cqrs.New(nil, cqrs.Validation)
+	queries.Register(f.bus, f.repos)
+	commands.Register(f.bus, f.repos)
+	return f
+}
+
+// createUser guarda una cuenta con contraseña; edit ajusta el modelo antes
+func (f fixture) createUser(t *testing.T, email, role string, edit func(*models.User)) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := string(hash)
+	user := &models.User{Email: &email, PasswordHash: &h, Role: role, DisplayName: email}
+	if edit != nil {
+		edit(user)
+	}
+	if err := f.repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (f fixture) login(email, pass string) (commands.LoginResult, error) {
+	return cqrs.Send[commands.LoginResult](context.Background(), f.bus, commands.LoginCommand{
+		Origin:   commands.Origin{IP: "203.0.113.7"},
+		Email:    email,
+		Password: pass,
+	})
+}
+
+func TestLogin(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		role       string
+		edit       func(*models.User)
+		requireMFA bool
+		email      string
+		password   string
+		wantErr    error
+		wantMFA    bool
+	}{
+		{name: "valid credentials", role: "operador", password: password},
+		{name: "wrong password", role: "operador", password: "wrong", wantErr: commands.ErrInvalidCredentials},
+		{name: "unknown email", role: "operador", email: "nobody@example.com", password: password, wantErr: commands.ErrInvalidCredentials},
+		{name: "missing password", role: "operador", wantErr: cqrs.ErrValidation},
+		{
+			name:     "suspended account",
+			role:     "operador",
+			edit:     func(u *models.User) { u.Status = models.UserStatusSuspended; u.SuspendedUntil = &future },
+			password: password,
+			wantErr:  account.ErrSuspended,
+		},
+		{
+			name:     "expired suspension is lifted",
+			role:     "operador",
+			edit:     func(u *models.User) { u.Status = models.UserStatusSuspended; u.SuspendedUntil = &past },
+			password: password,
+		},
+		{
+			name:     "locked account",
+			role:     "operador",
+			edit:     func(u *models.User) { u.LockedUntil = &future },
+			password: password,
+			wantErr:  commands.ErrInvalidCredentials,
+		},
+		{name: "role requires 2FA", role: "admin", requireMFA: true, password: password, wantMFA: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			user := f.createUser(t, "staff@example.com", tt.role, tt.edit)
+			if tt.requireMFA {
+				if err := f.repos.MFA.SaveRolePolicy(context.Background(), &models.MFARolePolicy{Role: tt.role, Required: true}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			email := *user.Email
+			if tt.email != "" {
+				email = tt.email
+			}
+
+			res, err := f.login(email, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if tt.wantMFA {
+				if !res.MFAEnrollmentRequired || res.MFAToken == "" || res.AccessToken != "" {
+					t.Fatalf("want an enrollment challenge instead of tokens, got %+v", res)
+				}
+				return
+			}
+			claims, err := auth.ValidateToken(res.AccessToken)
+			if err != nil {
+				t.Fatalf("access token: %v", err)
+			}
+			if claims.UserID != user.ID.String() {
+				t.Errorf("access token user = %s, want %s", claims.UserID, user.ID)
+			}
+			stored, _ := f.repos.Users.FindByID(context.Background(), user.ID)
+			if stored.Status != models.UserStatusActive {
+				t.Errorf("status = %s, want ACTIVE", stored.Status)
+			}
+		})
+	}
+}
+
+func TestLoginLocksAfterThreshold(t *testing.T) {
+	f := setup(t)
+	user := f.createUser(t, "staff@example.com", "operador", nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.login(*user.Email, "wrong"); !errors.Is(err, commands.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v", i+1, err)
+		}
+	}
+	stored, _ := f.repos.Users.FindByID(context.Background(), user.ID)
+	if stored.LockedUntil == nil || !stored.LockedUntil.After(time.Now()) {
+		t.Fatalf("account not locked after 3 failures: %+v", stored.LockedUntil)
+	}
+
+	// El bloqueo responde igual que una contraseña incorrecta
+	if _, err := f.login(*user.Email, password); !errors.Is(err, commands.ErrInvalidCredentials) {
+		t.Fatalf("locked login err = %v, want ErrInvalidCredentials", err)
+	}
+
+	page, err := audit.List(context.Background(), f.repos.Audit, audit.Filter{Action: audit.ActionLogin, Target: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 4 || page.Data[0].Reason != "account_locked" {
+		t.Errorf("audit = %d events, newest %+v; want 4 ending in account_locked", page.Total, page.Data[0])
+	}
+}
+
+func TestLoginWhileLockedIsNotCounted(t *testing.T) {
+	recent := time.Now()
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		edit       func(*models.User)
+		wantReason string
+	}{
+		{
+			name:       "locked account",
+			edit:       func(u *models.User) { u.LockedUntil = &future },
+			wantReason: "account_locked",
+		},
+		{
+			name:       "progressive delay",
+			edit:       func(u *models.User) { u.FailedLoginAttempts = 2; u.LastFailedLoginAt = &recent },
+			wantReason: "too_soon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			lockout.SetPolicy(lockout.Policy{Threshold: 3, LockDuration: time.Minute, BaseDelay: time.Hour, MaxDelay: time.Hour, IPFailuresHourly: 100})
+			user := f.createUser(t, "staff@example.com", "operador", tt.edit)
+
+			// Contraseña correcta e incorrecta reciben la misma respuesta que
+			// un email inexistente
+			for _, pass := range []string{password, "wrong", password} {
+				if _, err := f.login(*user.Email, pass); !errors.Is(err, commands.ErrInvalidCredentials) {
+					t.Fatalf("login with %q: err = %v, want ErrInvalidCredentials", pass, err)
+				}
+			}
+
+			// Ningún intento cuenta: ni suma fallos ni alarga o provoca un bloqueo
+			stored, _ := f.repos.Users.FindByID(context.Background(), user.ID)
+			if stored.FailedLoginAttempts != user.FailedLoginAttempts {
+				t.Errorf("failed attempts = %d, want %d", stored.FailedLoginAttempts, user.FailedLoginAttempts)
+			}
+			switch {
+			case user.LockedUntil == nil && stored.LockedUntil != nil:
+				t.Errorf("attempts during the delay locked the account until %v", stored.LockedUntil)
+			case user.LockedUntil != nil && (stored.LockedUntil == nil || !stored.LockedUntil.Equal(*user.LockedUntil)):
+				t.Errorf("locked until = %v, want %v", stored.LockedUntil, user.LockedUntil)
+			}
+
+			page, err := audit.List(context.Background(), f.repos.Audit, audit.Filter{Action: audit.ActionLogin, Target: user.ID.String()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range page.Data {
+				if e.Outcome != audit.OutcomeDenied || e.Reason != tt.wantReason {
+					t.Errorf("audit event %s/%s, want %s/%s", e.Outcome, e.Reason, audit.OutcomeDenied, tt.wantReason)
+				}
+			}
+			if page.Total != 3 {
+				t.Errorf("audit = %d events, want 3", page.Total)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	f := setup(t)
+	user := f.createUser(t, "staff@example.com", "operador", nil)
+	res, err := f.login(*user.Email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh := func(token string) (commands.TokenPair, error) {
+		return cqrs.Send[commands.TokenPair](context.Background(), f.bus, commands.RefreshCommand{RefreshToken: token})
+	}
+
+	rotated, err := refresh(res.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if rotated.RefreshToken == res.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		// Presentar el token ya rotado revoca la familia...
+		{name: "reused token", token: res.RefreshToken, wantErr: auth.ErrRefreshTokenReused},
+		// ...así que el token vigente tampoco sirve
+		{name: "token of a revoked family", token: rotated.RefreshToken, wantErr: auth.ErrRefreshTokenInvalid},
+		{name: "access token", token: rotated.AccessToken, wantErr: auth.ErrRefreshTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := refresh(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var otpCode = regexp.MustCompile(`\b\d{6}\b`)
+
+func TestVerifyOTP(t *testing.T) {
+	const phone = "+593987654321"
+
+	tests := []struct {
+		name    string
+		code    func(sent string) string
+		wantErr error
+	}{
+		{name: "code from the SMS", code: func(sent string) string { return sent }},
+		{name: "wrong code", code: func(sent string) string { return wrongCode(sent) }, wantErr: commands.ErrInvalidOTP},
+		{name: "malformed code", code: func(string) string { return "12" }, wantErr: commands.ErrInvalidOTP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			ctx := context.Background()
+			origin := commands.Origin{IP: "198.51.100.4"}
+
+			if _, err := cqrs.Send[struct{}](ctx, f.bus, commands.SendOTPCommand{Origin: origin, Phone: phone}); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			msg, ok := f.outbox.Last(phone)
+			if !ok {
+				t.Fatal("no SMS sent")
+			}
+			sent := otpCode.FindString(msg.Body)
+
+			tokens, err := cqrs.Send[commands.TokenPair](ctx, f.bus, commands.VerifyOTPCommand{Origin: origin, Phone: phone, Code: tt.code(sent)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			user, findErr := f.repos.Users.FindByPhone(ctx, phone)
+			if tt.wantErr != nil {
+				if !errors.Is(findErr, repository.ErrNotFound) {
+					t.Errorf("user created after a failed verification: %v", findErr)
+				}
+				return
+			}
+			if findErr != nil {
+				t.Fatalf("citizen not created: %v", findErr)
+			}
+			if user.Role != "user" {
+				t.Errorf("role = %s, want user", user.Role)
+			}
+			claims, err := auth.ValidateToken(tokens.AccessToken)
+			if err != nil || claims.UserID != user.ID.String() {
+				t.Fatalf("access token for %v: %v", claims, err)
+			}
+
+			// El código es de un solo uso
+			_, err = cqrs.Send[commands.TokenPair](ctx, f.bus, commands.VerifyOTPCommand{Origin: origin, Phone: phone, Code: sent})
+			if !errors.Is(err, commands.ErrInvalidOTP) {
+				t.Errorf("second use err = %v, want ErrInvalidOTP", err)
+			}
+		})
+	}
+}
+
+// wrongCode devuelve un código de 6 dígitos distinto de code
+func wrongCode(code string) string {
+	b := []byte(code)
+	b[0] = '0' + (b[0]-'0'+1)%10
+	return string(b)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		actorRole   string
+		self        bool
+		anonymous   bool
+		role        string
+		wantErr     error
+		wantOutcome string
+	}{
+		{name: "admin promotes to operador", actorRole: "admin", role: "operador", wantOutcome: audit.OutcomeSuccess},
+		{name: "admin cannot grant admin", actorRole: "admin", role: "admin", wantErr: users.ErrInsufficient, wantOutcome: audit.OutcomeDenied},
+		{name: "super_admin grants admin", actorRole: "super_admin", role: "admin", wantOutcome: audit.OutcomeSuccess},
+		{name: "own account", actorRole: "super_admin", self: true, role: "operador", wantErr: users.ErrSelf, wantOutcome: audit.OutcomeDenied},
+		{name: "unknown role", actorRole: "super_admin", role: "root", wantErr: users.ErrInvalidRole, wantOutcome: audit.OutcomeDenied},
+		{name: "no authenticated actor", anonymous: true, role: "operador", wantErr: commands.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			ctx := context.Background()
+			admin := f.createUser(t, "admin@example.com", tt.actorRole, nil)
+			target := f.createUser(t, "staff@example.com", "trabajador", nil)
+			if tt.self {
+				target = admin
+			}
+			origin := commands.Origin{ActorID: admin.ID.String(), ActorRole: tt.actorRole}
+			if tt.anonymous {
+				origin = commands.Origin{}
+			}
+
+			_, err := cqrs.Send[models.User](ctx, f.bus, commands.UpdateUserRoleCommand{Origin: origin, UserID: target.ID, Role: tt.role})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, _ := f.repos.Users.FindByID(ctx, target.ID)
+			if tt.wantErr == nil && stored.Role != tt.role {
+				t.Errorf("role = %s, want %s", stored.Role, tt.role)
+			}
+			if tt.wantErr != nil && stored.Role != target.Role {
+				t.Errorf("role changed to %s after a rejected update", stored.Role)
+			}
+
+			page, err := audit.List(ctx, f.repos.Audit, audit.Filter{Action: audit.ActionUserRoleUpdate})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantOutcome == "" {
+				if page.Total != 0 {
+					t.Errorf("audited %d events for a rejected command", page.Total)
+				}
+				return
+			}
+			if page.Total != 1 || page.Data[0].Outcome != tt.wantOutcome {
+				t.Errorf("audit = %+v, want one %s event", page.Data, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+// Verificaciones simultáneas del mismo código: el código se consume una sola
+// vez, así que solo una obtiene tokens y se crea un único ciudadano
+func TestVerifyOTPConcurrent(t *testing.T) {
+	const phone = "+593987654321"
+	f := setup(t)
+	ctx := context.Background()
+	origin := commands.Origin{IP: "198.51.100.4"}
+
+	if _, err := cqrs.Send[struct{}](ctx, f.bus, commands.SendOTPCommand{Origin: origin, Phone: phone}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg, _ := f.outbox.Last(phone)
+	code := otpCode.FindString(msg.Body)
+
+	const n = 5
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = cqrs.Send[commands.TokenPair](ctx, f.bus, commands.VerifyOTPCommand{Origin: origin, Phone: phone, Code: code})
+		}(i)
+	}
+	wg.Wait()
+
+	ok := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case !errors.Is(err, commands.ErrInvalidOTP):
+			t.Errorf("err = %v, want ErrInvalidOTP", err)
+		}
+	}
+	if ok != 1 {
+		t.Errorf("%d verifications succeeded, want 1", ok)
+	}
+	_, total, err := f.repos.Users.List(ctx, repository.UserFilter{Phone: phone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Errorf("%d citizens created, want 1", total)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	invited := "invited@example.com"
+
+	tests := []struct {
+		name      string
+		actorRole string
+		email     string
+		role      string
+		// invite emite una invitación para inviteRole (y el email si inviteEmail)
+		invite      bool
+		inviteRole  string
+		inviteEmail bool
+		wantErr     error
+		wantRole    string
+	}{
+		{name: "admin registers an operator", actorRole: authz.RoleAdmin, role: authz.RoleOperador, wantRole: authz.RoleOperador},
+		{name: "admin registers a worker", actorRole: authz.RoleAdmin, role: authz.RoleTrabajador, wantRole: authz.RoleTrabajador},
+		{name: "super admin registers an admin", actorRole: authz.RoleSuperAdmin, role: authz.RoleAdmin, wantRole: authz.RoleAdmin},
+		{name: "admin cannot register an admin", actorRole: authz.RoleAdmin, role: authz.RoleAdmin, wantErr: commands.ErrAdminRole},
+		{name: "role is required", actorRole: authz.RoleAdmin, wantErr: commands.ErrRoleRequired},
+		{name: "operator cannot register", actorRole: authz.RoleOperador, role: authz.RoleTrabajador, wantErr: commands.ErrNotAdmin},
+		{name: "citizens use OTP", actorRole: authz.RoleAdmin, role: authz.RoleUser, wantErr: commands.ErrCitizenRole},
+		{name: "no authorization", role: authz.RoleOperador, wantErr: commands.ErrNoAuthorization},
+		{name: "existing email", actorRole: authz.RoleAdmin, email: "admin@example.com", role: authz.RoleOperador, wantErr: commands.ErrUserExists},
+		{name: "invitation sets the role", invite: true, inviteRole: authz.RoleOperador, inviteEmail: true, wantRole: authz.RoleOperador},
+		{name: "invitation with the same role", invite: true, inviteRole: authz.RoleTrabajador, role: authz.RoleTrabajador, wantRole: authz.RoleTrabajador},
+		{name: "invitation with another role", invite: true, inviteRole: authz.RoleTrabajador, role: authz.RoleOperador, wantErr: commands.ErrRoleMismatch},
+		{name: "invitation for another email", invite: true, inviteRole: authz.RoleOperador, inviteEmail: true, email: "other@example.com", wantErr: invitation.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			admin := f.createUser(t, "admin@example.com", authz.RoleSuperAdmin, nil)
+
+			cmd := commands.RegisterCommand{Email: invited, Password: "staff-password", Role: tt.role}
+			if tt.email != "" {
+				cmd.Email = tt.email
+			}
+			if tt.actorRole != "" {
+				cmd.Origin = commands.Origin{ActorID: admin.ID.String(), ActorRole: tt.actorRole}
+			}
+			var inv models.Invitation
+			if tt.invite {
+				var email *string
+				if tt.inviteEmail {
+					email = &invited
+				}
+				var err error
+				cmd.InvitationToken, inv, err = invitation.Create(ctx, f.repos.Invitations, admin.ID, authz.RoleSuperAdmin, tt.inviteRole, email, invitation.DefaultTTL)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := cqrs.Send[commands.RegisterResult](ctx, f.bus, cmd)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, err := f.repos.Users.FindByEmail(ctx, invited); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("a rejected registration created the account: %v", err)
+				}
+				return
+			}
+
+			user, err := f.repos.Users.FindByEmail(ctx, invited)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Role != tt.wantRole || user.CreatedByID == nil || *user.CreatedByID != admin.ID || res.CreatedByID != admin.ID {
+				t.Errorf("user = role %s created by %v, want %s created by %s", user.Role, user.CreatedByID, tt.wantRole, admin.ID)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte("staff-password")) != nil {
+				t.Error("password not stored")
+			}
+			hasProfile, _ := f.repos.Users.HasOperatorProfile(ctx, user.ID)
+			if hasProfile != (tt.wantRole == authz.RoleOperador) {
+				t.Errorf("operator profile = %t for role %s", hasProfile, tt.wantRole)
+			}
+			if !tt.invite {
+				return
+			}
+			// La invitación queda consumida por la cuenta nueva
+			stored, _ := f.repos.Invitations.FindByID(ctx, inv.ID)
+			if stored.UsedByID == nil || *stored.UsedByID != user.ID {
+				t.Errorf("invitation used by %v, want %s", stored.UsedByID, user.ID)
+			}
+			// y no sirve otra vez: se rechaza antes de mirar si el email existe
+			if _, err := cqrs.Send[commands.RegisterResult](ctx, f.bus, cmd); !errors.Is(err, invitation.ErrInvalid) {
+				t.Errorf("reused invitation: err = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	admin := f.createUser(t, "admin@example.com", authz.RoleAdmin, nil)
+	user := f.createUser(t, "staff@example.com", authz.RoleOperador, nil)
+
+	// openSession inicia sesión con user y devuelve el id de la sesión
+	openSession := func() uuid.UUID {
+		res, err := f.login(*user.Email, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := auth.ValidateToken(res.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uuid.MustParse(claims.SessionID)
+	}
+	revoke := func(actor *models.User, sessionID uuid.UUID) error {
+		origin := commands.Origin{}
+		if actor != nil {
+			origin = commands.Origin{ActorID: actor.ID.String(), ActorRole: actor.Role}
+		}
+		_, err := cqrs.Send[struct{}](ctx, f.bus, commands.RevokeSessionCommand{Origin: origin, UserID: user.ID, SessionID: sessionID})
+		return err
+	}
+	revocations := func() int {
+		page, err := audit.List(ctx, f.repos.Audit, audit.Filter{Action: audit.ActionSessionRevoke})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(page.Total)
+	}
+
+	if err := revoke(nil, openSession()); !errors.Is(err, commands.ErrUnauthenticated) {
+		t.Errorf("revoke without actor = %v, want ErrUnauthenticated", err)
+	}
+	if err := revoke(user, uuid.New()); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Errorf("revoke unknown session = %v, want ErrSessionNotFound", err)
+	}
+
+	// Cerrar la sesión propia no se audita; la de otro usuario sí
+	if err := revoke(user, openSession()); err != nil {
+		t.Fatalf("revoke own session: %v", err)
+	}
+	if n := revocations(); n != 0 {
+		t.Errorf("%d audit events after revoking an own session, want 0", n)
+	}
+	if err := revoke(admin, openSession()); err != nil {
+		t.Fatalf("admin revoke: %v", err)
+	}
+	if n := revocations(); n != 1 {
+		t.Errorf("%d audit events after an admin revoke, want 1", n)
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+)
+
+// CreateInvitationCommand emite una invitación de registro para Role,
+// opcionalmente ligada a Email, que vence en TTL (0 = invitation.DefaultTTL)
+type CreateInvitationCommand struct {
+	Origin
+	Role  string
+	Email *string
+	TTL   time.Duration
+}
+
+func (c CreateInvitationCommand) Validate() error { return c.validateActor() }
+
+// CreateInvitationResult es la invitación creada y su token firmado
+type CreateInvitationResult struct {
+	Token      string
+	Invitation models.Invitation
+}
+
+type CreateInvitationHandler struct {
+	Invitations repository.InvitationRepository
+	Audit       repository.AuditRepository
+}
+
+// Handle devuelve invitation.ErrInsufficient si el admin no puede otorgar el rol
+func (h *CreateInvitationHandler) Handle(ctx context.Context, cmd CreateInvitationCommand) (CreateInvitationResult, error) {
+	// Admin-level roles can only be granted by super_admin
+	if !authz.CanGrant(cmd.ActorRole, cmd.Role) {
+		cmd.audit(ctx, h.Audit, audit.ActionInvitationCreate, cmd.Role, audit.OutcomeDenied, "admin_role")
+		return CreateInvitationResult{}, invitation.ErrInsufficient
+	}
+
+	ttl := cmd.TTL
+	if ttl <= 0 {
+		ttl = invitation.DefaultTTL
+	}
+	token, inv, err := invitation.Create(ctx, h.Invitations, cmd.actor().ID, cmd.ActorRole, cmd.Role, cmd.Email, ttl)
+	if err != nil {
+		return CreateInvitationResult{}, err
+	}
+	cmd.audit(ctx, h.Audit, audit.ActionInvitationCreate, inv.ID.String(), audit.OutcomeSuccess, "role="+inv.Role)
+	return CreateInvitationResult{Token: token, Invitation: inv}, nil
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/lockout"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mfa"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginCommand inicia sesión con email y contraseña
+type LoginCommand struct {
+	Origin
+	Email    string
+	Password string
+}
+
+func (c LoginCommand) Validate() error {
+	if c.Email == "" || c.Password == "" {
+		return errors.New("email and password are required")
+	}
+	return nil
+}
+
+// LoginResult trae los tokens o, si la cuenta usa 2FA, el mfa_token con el
+// que se completa el login (MFARequired) o se inscribe el segundo factor
+// (MFAEnrollmentRequired)
+type LoginResult struct {
+	TokenPair
+	MFAToken              string
+	MFARequired           bool
+	MFAEnrollmentRequired bool
+}
+
+type LoginHandler struct {
+	QueryHandler *queries.GetByEmailHandler
+	Users        repository.UserRepository
+	Lockout      repository.LockoutRepository
+	MFA          repository.MFARepository
+	Audit        repository.AuditRepository
+}
+
+func (h *LoginHandler) Handle(ctx context.Context, cmd LoginCommand) (LoginResult, error) {
+	if wait := lockout.CheckIP(cmd.IP); wait > 0 {
+		cmd.audit(ctx, h.Audit, audit.ActionLogin, cmd.Email, audit.OutcomeDenied, "ip_rate_limited")
+		return LoginResult{}, &RetryError{Err: ErrTooManyAttempts, Wait: wait}
+	}
+
+	user, err := h.QueryHandler.Handle(ctx, queries.GetByEmailQuery{Email: cmd.Email})
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if user == nil {
+		lockout.RecordIPFailure(cmd.IP)
+		comparePasswordDummy(cmd.Password)
+		cmd.audit(ctx, h.Audit, audit.ActionLogin, cmd.Email, audit.OutcomeFailure, "unknown_user")
+		return LoginResult{}, ErrInvalidCredentials
+	}
+
+	// Progressive delay and temporary lock per account. The client gets the
+	// same answer as for an unknown email, even with the correct password: a
+	// 429 with Retry-After would tell an attacker which emails have an
+	// account. The password is not checked and the attempt is not counted,
+	// so retrying during the lock neither extends it nor reveals whether the
+	// guess was right; only the audit log records the reason.
+	if _, err := lockout.CheckAccount(user); err != nil {
+		reason := "too_soon"
+		if errors.Is(err, lockout.ErrAccountLocked) {
+			reason = "account_locked"
+		}
+		comparePasswordDummy(cmd.Password)
+		cmd.audit(ctx, h.Audit, audit.ActionLogin, user.ID.String(), audit.OutcomeDenied, reason)
+		return LoginResult{}, ErrInvalidCredentials
+	}
+
+	// Los contadores de lockout se guardan fuera de la transacción del
+	// comando para que el fallo cuente aunque se deshaga
+	if user.PasswordHash == nil || bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(cmd.Password)) != nil {
+		if err := lockout.Failed(database.WithoutTx(ctx), h.Lockout, user, cmd.IP); err != nil {
+			slog.ErrorContext(ctx, "failed to record login failure", "user_id", user.ID, "error", err)
+		}
+		cmd.audit(ctx, h.Audit, audit.ActionLogin, user.ID.String(), audit.OutcomeFailure, "bad_password")
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	if err := lockout.Succeeded(ctx, h.Lockout, user); err != nil {
+		slog.ErrorContext(ctx, "failed to reset login failures", "user_id", user.ID, "error", err)
+	}
+	if err := account.Check(ctx, h.Users, user); err != nil {
+		cmd.audit(ctx, h.Audit, audit.ActionLogin, user.ID.String(), audit.OutcomeDenied, account.Code(err))
+		return LoginResult{}, err
+	}
+
+	// Second factor: return a short-lived challenge instead of tokens
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateMFAToken(user.ID.String(), auth.TokenTypeMFAChallenge)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionLogin, user.ID.String(), audit.OutcomeSuccess, "mfa_challenge")
+		return LoginResult{MFAToken: mfaToken, MFARequired: true}, nil
+	}
+	required, err := mfa.RoleRequiresMFA(ctx, h.MFA, user.Role)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if required {
+		mfaToken, err := auth.GenerateMFAToken(user.ID.String(), auth.TokenTypeMFAEnrollment)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionLogin, user.ID.String(), audit.OutcomeSuccess, "mfa_enrollment")
+		return LoginResult{MFAToken: mfaToken, MFAEnrollmentRequired: true}, nil
+	}
+
+	accessToken, refreshToken, err := auth.IssueTokens(ctx, *user, cmd.Device())
+	if err != nil {
+		return LoginResult{}, err
+	}
+	cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionLogin, user.ID.String(), audit.OutcomeSuccess, "")
+
+	return LoginResult{TokenPair: TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}}, nil
+}
+
+// dummyHash es un hash con el mismo costo que los de las cuentas
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), 12)
+	return hash
+})
+
+// comparePasswordDummy gasta el mismo tiempo que comprobar una contraseña
+// cuando no hay cuenta contra la que comprobarla (email inexistente, cuenta
+// bloqueada), para que el tiempo de respuesta no revele si existe
+func comparePasswordDummy(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+}
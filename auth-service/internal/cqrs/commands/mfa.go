@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mfa"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMFAToken        = errors.New("mfa token invalid or expired")
+	ErrMFAPasswordOnly = errors.New("2fa is only available for password accounts")
+)
+
+// EnrollTOTPCommand inicia el enrolamiento TOTP del usuario autenticado o,
+// en un login que exige 2FA, del dueño del mfa_token de enrolamiento
+type EnrollTOTPCommand struct {
+	Origin
+	MFAToken string
+}
+
+// EnrollTOTPResult es el secreto pendiente y su URI otpauth:// para el QR
+type EnrollTOTPResult struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// ConfirmTOTPCommand activa TOTP con un código de la app autenticadora
+type ConfirmTOTPCommand struct {
+	Origin
+	MFAToken string
+	Code     string
+}
+
+func (c ConfirmTOTPCommand) Validate() error {
+	if c.Code == "" {
+		return errors.New("code is required")
+	}
+	return nil
+}
+
+// ConfirmTOTPResult trae los códigos de recuperación y, si el enrolamiento
+// completa un login (mfa_token sin sesión), los tokens
+type ConfirmTOTPResult struct {
+	TokenPair
+	RecoveryCodes []string
+}
+
+// VerifyMFACommand completa un login en dos pasos con un código TOTP o de recuperación
+type VerifyMFACommand struct {
+	Origin
+	MFAToken string
+	Code     string
+}
+
+func (c VerifyMFACommand) Validate() error {
+	if c.MFAToken == "" || c.Code == "" {
+		return errors.New("mfa_token and code are required")
+	}
+	return nil
+}
+
+// DisableTOTPCommand desactiva TOTP del usuario autenticado
+type DisableTOTPCommand struct {
+	Origin
+	Code string
+}
+
+func (c DisableTOTPCommand) Validate() error {
+	if c.Code == "" {
+		return errors.New("code is required")
+	}
+	return nil
+}
+
+// SetMFARoleRequirementCommand exige (o deja de exigir) 2FA para un rol
+type SetMFARoleRequirementCommand struct {
+	Origin
+	Role     string
+	Required bool
+}
+
+func (c SetMFARoleRequirementCommand) Validate() error {
+	if !authz.Valid(c.Role) || c.Role == authz.RoleUser {
+		return errors.New("2FA only applies to password-based roles")
+	}
+	return c.validateActor()
+}
+
+type EnrollTOTPHandler struct {
+	Users repository.UserRepository
+	MFA   repository.MFARepository
+}
+
+func (h *EnrollTOTPHandler) Handle(ctx context.Context, cmd EnrollTOTPCommand) (EnrollTOTPResult, error) {
+	user, err := mfaUser(ctx, h.Users, cmd.Origin, cmd.MFAToken)
+	if err != nil {
+		return EnrollTOTPResult{}, err
+	}
+	secret, uri, err := mfa.BeginEnrollment(ctx, h.MFA, user)
+	if err != nil {
+		return EnrollTOTPResult{}, err
+	}
+	return EnrollTOTPResult{Secret: secret, ProvisioningURI: uri}, nil
+}
+
+type ConfirmTOTPHandler struct {
+	Users repository.UserRepository
+	MFA   repository.MFARepository
+}
+
+func (h *ConfirmTOTPHandler) Handle(ctx context.Context, cmd ConfirmTOTPCommand) (ConfirmTOTPResult, error) {
+	user, err := mfaUser(ctx, h.Users, cmd.Origin, cmd.MFAToken)
+	if err != nil {
+		return ConfirmTOTPResult{}, err
+	}
+	codes, err := mfa.ConfirmEnrollment(ctx, h.MFA, user, cmd.Code)
+	if err != nil {
+		return ConfirmTOTPResult{}, err
+	}
+
+	res := ConfirmTOTPResult{RecoveryCodes: codes}
+	// Completing an enrollment required at login finishes the login
+	if cmd.MFAToken != "" && cmd.ActorID == "" {
+		res.AccessToken, res.RefreshToken, err = auth.IssueTokens(ctx, *user, cmd.Device())
+		if err != nil {
+			return ConfirmTOTPResult{}, err
+		}
+	}
+	return res, nil
+}
+
+type VerifyMFAHandler struct {
+	Users repository.UserRepository
+	MFA   repository.MFARepository
+	Audit repository.AuditRepository
+}
+
+func (h *VerifyMFAHandler) Handle(ctx context.Context, cmd VerifyMFACommand) (TokenPair, error) {
+	claims, err := auth.ValidateMFAToken(cmd.MFAToken, auth.TokenTypeMFAChallenge)
+	if err != nil {
+		return TokenPair{}, ErrMFAToken
+	}
+	user, err := findUser(ctx, h.Users, claims.UserID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := mfa.Verify(ctx, h.MFA, user, cmd.Code); err != nil {
+		cmd.audit(ctx, h.Audit, audit.ActionMFAVerify, user.ID.String(), audit.OutcomeFailure, mfaReason(err))
+		return TokenPair{}, err
+	}
+
+	accessToken, refreshToken, err := auth.IssueTokens(ctx, *user, cmd.Device())
+	if err != nil {
+		if account.Code(err) != "" {
+			cmd.audit(ctx, h.Audit, audit.ActionMFAVerify, user.ID.String(), audit.OutcomeDenied, account.Code(err))
+		}
+		return TokenPair{}, err
+	}
+	cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionMFAVerify, user.ID.String(), audit.OutcomeSuccess, "")
+
+	return TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+type DisableTOTPHandler struct {
+	Users repository.UserRepository
+	MFA   repository.MFARepository
+}
+
+func (h *DisableTOTPHandler) Handle(ctx context.Context, cmd DisableTOTPCommand) (struct{}, error) {
+	user, err := mfaUser(ctx, h.Users, cmd.Origin, "")
+	if err != nil {
+		return struct{}{}, err
+	}
+	return struct{}{}, mfa.Disable(ctx, h.MFA, user, cmd.Code)
+}
+
+type SetMFARoleRequirementHandler struct {
+	MFA   repository.MFARepository
+	Audit repository.AuditRepository
+}
+
+func (h *SetMFARoleRequirementHandler) Handle(ctx context.Context, cmd SetMFARoleRequirementCommand) (models.MFARolePolicy, error) {
+	adminID := cmd.actor().ID
+	policy, err := mfa.SetRoleRequirement(ctx, h.MFA, cmd.Role, cmd.Required, adminID)
+	if err != nil {
+		return models.MFARolePolicy{}, err
+	}
+	slog.InfoContext(ctx, "2FA requirement updated", "role", cmd.Role, "required", cmd.Required, "admin_id", adminID)
+	cmd.audit(ctx, h.Audit, audit.ActionMFAPolicyUpdate, cmd.Role, audit.OutcomeSuccess, fmt.Sprintf("required=%t", cmd.Required))
+	return policy, nil
+}
+
+// mfaUser resuelve el usuario del access token (Origin) o, durante un login
+// que exige enrolamiento, del mfa_token de enrolamiento. Solo las cuentas con
+// contraseña usan 2FA.
+func mfaUser(ctx context.Context, users repository.UserRepository, o Origin, mfaToken string) (*models.User, error) {
+	userID := o.ActorID
+	if userID == "" && mfaToken != "" {
+		claims, err := auth.ValidateMFAToken(mfaToken, auth.TokenTypeMFAEnrollment)
+		if err == nil {
+			userID = claims.UserID
+		}
+	}
+	user, err := findUser(ctx, users, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash == nil || user.Role == authz.RoleUser {
+		return nil, ErrMFAPasswordOnly
+	}
+	return user, nil
+}
+
+// findUser busca el usuario de un token; ErrMFAToken si no existe
+func findUser(ctx context.Context, users repository.UserRepository, userID string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrMFAToken
+	}
+	user, err := users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFAToken
+	}
+	return user, err
+}
+
+// mfaReason es el motivo de auditoría de un error de mfa
+func mfaReason(err error) string {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		return "invalid_code"
+	case errors.Is(err, mfa.ErrTooManyAttempts):
+		return "rate_limited"
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		return "not_enrolled"
+	}
+	return "error"
+}
@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/sms"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// otpMessage is the SMS text sent with the OTP code
+const otpMessage = "Latacunga Limpia: tu código de verificación es %s. Expira en 5 minutos."
+
+// otpDigits es el largo de los códigos OTP
+const otpDigits = 6
+
+// E.164 format: +[country code][number]
+var phonePattern = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+
+// SendOTPCommand envía un código OTP por SMS (solo ciudadanos)
+type SendOTPCommand struct {
+	Origin
+	Phone string
+}
+
+func (c SendOTPCommand) Validate() error {
+	if !phonePattern.MatchString(c.Phone) {
+		return ErrInvalidPhone
+	}
+	return nil
+}
+
+// VerifyOTPCommand canjea un código OTP por tokens; crea la cuenta del
+// ciudadano la primera vez
+type VerifyOTPCommand struct {
+	Origin
+	Phone string
+	Code  string
+}
+
+func (c VerifyOTPCommand) Validate() error {
+	if c.Phone == "" {
+		return errors.New("phone is required")
+	}
+	if len(c.Code) != otpDigits {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+type SendOTPHandler struct {
+	Users  repository.UserRepository
+	OTPs   repository.OTPRepository
+	Limits repository.OTPLimitRepository
+	Audit  repository.AuditRepository
+}
+
+// Handle guarda el código y lo envía. Si el SMS falla la transacción se
+// deshace y el código no queda utilizable.
+func (h *SendOTPHandler) Handle(ctx context.Context, cmd SendOTPCommand) (struct{}, error) {
+	// Per-phone and per-IP cooldown and caps. The send counts even if the
+	// SMS fails and the transaction is rolled back.
+	wait, err := ratelimit.OTP.AllowSend(database.WithoutTx(ctx), h.Limits, cmd.Phone, cmd.IP)
+	if err != nil {
+		return struct{}{}, err
+	}
+	if wait > 0 {
+		cmd.audit(ctx, h.Audit, audit.ActionOTPSend, cmd.Phone, audit.OutcomeDenied, "rate_limited")
+		return struct{}{}, &RetryError{Err: ErrTooManyAttempts, Wait: wait}
+	}
+
+	// Check if phone belongs to non-user role
+	if user, err := h.Users.FindByPhone(ctx, cmd.Phone); err == nil {
+		if user.Role != authz.RoleUser {
+			cmd.audit(ctx, h.Audit, audit.ActionOTPSend, cmd.Phone, audit.OutcomeDenied, "role_not_allowed")
+			return struct{}{}, ErrRoleNotAllowed
+		}
+	}
+
+	otpCode, err := generateOTP()
+	if err != nil {
+		return struct{}{}, err
+	}
+	hashedCode, err := bcrypt.GenerateFromPassword([]byte(otpCode), 12)
+	if err != nil {
+		return struct{}{}, err
+	}
+
+	otp := models.OTPCode{
+		Phone:       cmd.Phone,
+		CodeHash:    string(hashedCode),
+		ExpiresAt:   time.Now().Add(5 * time.Minute),
+		MaxAttempts: 5,
+	}
+	if err := h.OTPs.Create(ctx, &otp); err != nil {
+		return struct{}{}, err
+	}
+
+	if err := sms.Send(ctx, cmd.Phone, fmt.Sprintf(otpMessage, otpCode)); err != nil {
+		slog.ErrorContext(ctx, "failed to send OTP SMS", "error", err)
+		cmd.audit(ctx, h.Audit, audit.ActionOTPSend, cmd.Phone, audit.OutcomeFailure, "sms_failed")
+		return struct{}{}, ErrSMSFailed
+	}
+
+	cmd.audit(ctx, h.Audit, audit.ActionOTPSend, cmd.Phone, audit.OutcomeSuccess, "")
+	return struct{}{}, nil
+}
+
+type VerifyOTPHandler struct {
+	Users  repository.UserRepository
+	OTPs   repository.OTPRepository
+	Limits repository.OTPLimitRepository
+	Audit  repository.AuditRepository
+}
+
+func (h *VerifyOTPHandler) Handle(ctx context.Context, cmd VerifyOTPCommand) (TokenPair, error) {
+	// Los fallos y los intentos se guardan fuera de la transacción (noTx): un
+	// código incorrecto deshace el comando pero debe seguir contando
+	noTx := database.WithoutTx(ctx)
+	wait, err := ratelimit.OTP.AllowVerify(noTx, h.Limits, cmd.Phone, cmd.IP)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if wait > 0 {
+		cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, cmd.Phone, audit.OutcomeDenied, "rate_limited")
+		return TokenPair{}, &RetryError{Err: ErrTooManyAttempts, Wait: wait}
+	}
+
+	// Find latest OTP for phone
+	otp, err := h.OTPs.FindActive(ctx, cmd.Phone, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		if err := ratelimit.OTP.VerifyFailed(noTx, h.Limits, cmd.Phone, cmd.IP, false); err != nil {
+			return TokenPair{}, err
+		}
+		cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, cmd.Phone, audit.OutcomeFailure, "no_active_code")
+		return TokenPair{}, ErrInvalidOTP
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// El límite de intentos se comprueba al contar, no con otp.Attempts leído antes, para que
+	// intentos simultáneos no lo superen.
+	counted, err := h.OTPs.IncrementAttempts(noTx, otp)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if !counted {
+		cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, cmd.Phone, audit.OutcomeDenied, "attempts_exceeded")
+		return TokenPair{}, ErrOTPExhausted
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(cmd.Code)) != nil {
+		if err := ratelimit.OTP.VerifyFailed(noTx, h.Limits, cmd.Phone, cmd.IP, otp.Attempts >= otp.MaxAttempts); err != nil {
+			return TokenPair{}, err
+		}
+		cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, cmd.Phone, audit.OutcomeFailure, "invalid_code")
+		return TokenPair{}, ErrInvalidOTP
+	}
+
+	// Solo una de dos peticiones simultáneas con el código correcto lo consume
+	consumed, err := h.OTPs.Consume(ctx, otp.ID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if !consumed {
+		cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, cmd.Phone, audit.OutcomeFailure, "already_used")
+		return TokenPair{}, ErrInvalidOTP
+	}
+	if err := ratelimit.OTP.VerifySucceeded(ctx, h.Limits, cmd.Phone); err != nil {
+		return TokenPair{}, err
+	}
+
+	// Find or create user
+	user, err := h.Users.FindByPhone(ctx, cmd.Phone)
+	if errors.Is(err, repository.ErrNotFound) {
+		user = &models.User{
+			Phone:       &cmd.Phone,
+			Role:        authz.RoleUser,
+			DisplayName: cmd.Phone, // Use phone as display name
+		}
+		if err := h.Users.Create(ctx, user); err != nil {
+			return TokenPair{}, err
+		}
+		cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionRegister, user.ID.String(), audit.OutcomeSuccess, "role=user otp")
+	} else if err != nil {
+		return TokenPair{}, err
+	}
+
+	accessToken, refreshToken, err := auth.IssueTokens(ctx, *user, cmd.Device())
+	if err != nil {
+		if account.Code(err) != "" {
+			cmd.audit(ctx, h.Audit, audit.ActionOTPVerify, user.ID.String(), audit.OutcomeDenied, account.Code(err))
+		}
+		return TokenPair{}, err
+	}
+	cmd.auditAs(ctx, h.Audit, &user.ID, audit.ActionOTPVerify, user.ID.String(), audit.OutcomeSuccess, "")
+
+	return TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// generateOTP devuelve un código de otpDigits dígitos uniforme: cada dígito
+// sale de crypto/rand sin sesgo de módulo
+func generateOTP() (string, error) {
+	code := make([]byte, otpDigits)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import "testing"
+
+func TestGenerateOTP(t *testing.T) {
+	const n = 20000
+	var counts [10]int
+	for i := 0; i < n; i++ {
+		code, err := generateOTP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != otpDigits {
+			t.Fatalf("code %q has %d digits, want %d", code, len(code), otpDigits)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("code %q has a non-digit", code)
+			}
+			counts[c-'0']++
+		}
+	}
+
+	// Cada dígito debería salir un 10% de las veces; con n*otpDigits
+	// muestras, ±10% de margen está muy por encima del ruido aleatorio
+	want := n * otpDigits / 10
+	for d, got := range counts {
+		if got < want*9/10 || got > want*11/10 {
+			t.Errorf("digit %d drawn %d times, want about %d", d, got, want)
+		}
+	}
+}
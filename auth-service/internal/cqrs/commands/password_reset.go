@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/passwordreset"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// RequestPasswordResetCommand envía por email un token de restablecimiento
+type RequestPasswordResetCommand struct {
+	Email string
+}
+
+func (c RequestPasswordResetCommand) Validate() error {
+	if c.Email == "" {
+		return errors.New("email is required")
+	}
+	return nil
+}
+
+// ConfirmPasswordResetCommand fija una contraseña nueva con un token de restablecimiento
+type ConfirmPasswordResetCommand struct {
+	Token       string
+	NewPassword string
+}
+
+func (c ConfirmPasswordResetCommand) Validate() error {
+	if c.Token == "" {
+		return errors.New("token is required")
+	}
+	if len(c.NewPassword) < 6 {
+		return errors.New("new_password must be at least 6 characters")
+	}
+	return nil
+}
+
+type RequestPasswordResetHandler struct {
+	Users  repository.UserRepository
+	Resets repository.PasswordResetRepository
+}
+
+func (h *RequestPasswordResetHandler) Handle(ctx context.Context, cmd RequestPasswordResetCommand) (struct{}, error) {
+	return struct{}{}, passwordreset.Request(ctx, h.Users, h.Resets, cmd.Email)
+}
+
+type ConfirmPasswordResetHandler struct {
+	Users  repository.UserRepository
+	Resets repository.PasswordResetRepository
+}
+
+func (h *ConfirmPasswordResetHandler) Handle(ctx context.Context, cmd ConfirmPasswordResetCommand) (struct{}, error) {
+	return struct{}{}, passwordreset.Confirm(ctx, h.Users, h.Resets, cmd.Token, cmd.NewPassword)
+}
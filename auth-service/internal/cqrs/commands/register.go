@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RegisterCommand crea una cuenta de personal (no ciudadanos). Lo autoriza
+// la sesión de un admin (Origin) o una invitación de un solo uso.
+type RegisterCommand struct {
+	Origin
+	Email           string
+	Password        string
+	Role            string
+	InvitationToken string
+}
+
+func (c RegisterCommand) Validate() error {
+	if c.Email == "" || c.Password == "" {
+		return errors.New("email and password are required")
+	}
+	if c.Role == authz.RoleUser {
+		return ErrCitizenRole
+	}
+	if c.Role != "" && !authz.Valid(c.Role) {
+		return errors.New("invalid role")
+	}
+	return nil
+}
+
+// RegisterResult es la cuenta creada
+type RegisterResult struct {
+	User        models.User
+	CreatedByID uuid.UUID
+}
+
+type RegisterHandler struct {
+	Users       repository.UserRepository
+	Invitations repository.InvitationRepository
+	Audit       repository.AuditRepository
+}
+
+func (h *RegisterHandler) Handle(ctx context.Context, cmd RegisterCommand) (RegisterResult, error) {
+	// Authorization: admin session or invitation
+	var createdBy uuid.UUID
+	var inv *models.Invitation
+	switch {
+	case cmd.ActorID != "":
+		if !authz.Has(cmd.ActorRole, authz.UsersManage) {
+			cmd.audit(ctx, h.Audit, audit.ActionRegister, cmd.Email, audit.OutcomeDenied, "not_admin")
+			return RegisterResult{}, ErrNotAdmin
+		}
+		adminID, err := uuid.Parse(cmd.ActorID)
+		if err != nil {
+			return RegisterResult{}, ErrNoAuthorization
+		}
+		if cmd.Role == "" {
+			return RegisterResult{}, ErrRoleRequired
+		}
+		if !authz.CanGrant(cmd.ActorRole, cmd.Role) {
+			cmd.audit(ctx, h.Audit, audit.ActionRegister, cmd.Email, audit.OutcomeDenied, "admin_role")
+			return RegisterResult{}, ErrAdminRole
+		}
+		createdBy = adminID
+	case cmd.InvitationToken != "":
+		var err error
+		inv, err = invitation.Validate(ctx, h.Invitations, cmd.InvitationToken, cmd.Email)
+		if err != nil {
+			cmd.audit(ctx, h.Audit, audit.ActionRegister, cmd.Email, audit.OutcomeDenied, "invalid_invitation")
+			return RegisterResult{}, invitation.ErrInvalid
+		}
+		if cmd.Role != "" && cmd.Role != inv.Role {
+			return RegisterResult{}, ErrRoleMismatch
+		}
+		cmd.Role = inv.Role
+		createdBy = inv.CreatedByID
+	default:
+		cmd.audit(ctx, h.Audit, audit.ActionRegister, cmd.Email, audit.OutcomeDenied, "no_authorization")
+		return RegisterResult{}, ErrNoAuthorization
+	}
+
+	// Check if user already exists
+	if _, err := h.Users.FindByEmail(ctx, cmd.Email); err == nil {
+		cmd.audit(ctx, h.Audit, audit.ActionRegister, cmd.Email, audit.OutcomeFailure, "already_exists")
+		return RegisterResult{}, ErrUserExists
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(cmd.Password), 12)
+	if err != nil {
+		return RegisterResult{}, err
+	}
+	hash := string(hashed)
+
+	user := models.User{
+		Email:        &cmd.Email,
+		PasswordHash: &hash,
+		Role:         cmd.Role,
+		DisplayName:  cmd.Email, // Use email as display name for now
+		CreatedByID:  &createdBy,
+	}
+	if err := h.Users.Create(ctx, &user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return RegisterResult{}, ErrUserExists
+		}
+		return RegisterResult{}, err
+	}
+
+	// If role is operador, create operator profile
+	if cmd.Role == authz.RoleOperador {
+		if err := h.Users.CreateOperatorProfile(ctx, &models.OperatorProfile{UserID: user.ID}); err != nil {
+			return RegisterResult{}, err
+		}
+	}
+	if inv != nil {
+		if err := invitation.Consume(ctx, h.Invitations, inv, user.ID); err != nil {
+			return RegisterResult{}, err
+		}
+	}
+
+	reason := "role=" + user.Role
+	if inv != nil {
+		reason += " invitation=" + inv.ID.String()
+	}
+	cmd.audit(ctx, h.Audit, audit.ActionRegister, user.ID.String(), audit.OutcomeSuccess, reason)
+
+	return RegisterResult{User: user, CreatedByID: createdBy}, nil
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// RevokeSessionCommand cierra una sesión de UserID: la propia o, para un
+// admin, la de otro usuario
+type RevokeSessionCommand struct {
+	Origin
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
+
+func (c RevokeSessionCommand) Validate() error { return c.validateActor() }
+
+type RevokeSessionHandler struct {
+	Audit repository.AuditRepository
+}
+
+// Handle devuelve auth.ErrSessionNotFound si la sesión no está activa o es
+// de otro usuario. Cerrar la sesión de otro usuario queda auditado.
+func (h *RevokeSessionHandler) Handle(ctx context.Context, cmd RevokeSessionCommand) (struct{}, error) {
+	if err := auth.RevokeSession(ctx, cmd.UserID, cmd.SessionID); err != nil {
+		return struct{}{}, err
+	}
+	if cmd.ActorID != cmd.UserID.String() {
+		cmd.audit(ctx, h.Audit, audit.ActionSessionRevoke, cmd.UserID.String(), audit.OutcomeSuccess, "session="+cmd.SessionID.String())
+	}
+	return struct{}{}, nil
+}
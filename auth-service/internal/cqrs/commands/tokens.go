@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/google/uuid"
+)
+
+// RefreshCommand canjea un refresh token por un par nuevo (rotación)
+type RefreshCommand struct {
+	Origin
+	RefreshToken string
+}
+
+func (c RefreshCommand) Validate() error {
+	if c.RefreshToken == "" {
+		return errors.New("refresh_token is required")
+	}
+	return nil
+}
+
+// LogoutCommand revoca la sesión del refresh token presentado
+type LogoutCommand struct {
+	Origin
+	RefreshToken string
+}
+
+func (c LogoutCommand) Validate() error {
+	if c.RefreshToken == "" {
+		return errors.New("refresh_token is required")
+	}
+	return nil
+}
+
+// LogoutAllCommand revoca todas las sesiones del usuario autenticado
+type LogoutAllCommand struct {
+	Origin
+}
+
+func (c LogoutAllCommand) Validate() error { return c.validateActor() }
+
+func refresh(ctx context.Context, cmd RefreshCommand) (TokenPair, error) {
+	accessToken, refreshToken, err := auth.RotateRefreshToken(ctx, cmd.RefreshToken, cmd.Device())
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func logout(ctx context.Context, cmd LogoutCommand) (struct{}, error) {
+	return struct{}{}, auth.RevokeRefreshToken(ctx, cmd.RefreshToken, cmd.ActorID)
+}
+
+func logoutAll(ctx context.Context, cmd LogoutAllCommand) (struct{}, error) {
+	return struct{}{}, auth.RevokeUserTokens(ctx, uuid.MustParse(cmd.ActorID))
+}
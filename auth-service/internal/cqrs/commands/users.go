@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/lockout"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/users"
+	"github.com/google/uuid"
+)
+
+// UpdateUserRoleCommand cambia el rol de un usuario y cierra sus sesiones
+type UpdateUserRoleCommand struct {
+	Origin
+	UserID uuid.UUID
+	Role   string
+}
+
+func (c UpdateUserRoleCommand) Validate() error { return c.validateActor() }
+
+// SetUserStatusCommand suspende (hasta Until, o indefinidamente), bloquea o
+// reactiva una cuenta y cierra sus sesiones
+type SetUserStatusCommand struct {
+	Origin
+	UserID uuid.UUID
+	Status string
+	Until  *time.Time
+}
+
+func (c SetUserStatusCommand) Validate() error {
+	if err := c.validateActor(); err != nil {
+		return err
+	}
+	if c.Status == models.UserStatusSuspended && c.Until != nil && !c.Until.After(time.Now()) {
+		return errors.New("until must be in the future")
+	}
+	return nil
+}
+
+// DeleteUserCommand elimina una cuenta y los datos que dependen de ella
+type DeleteUserCommand struct {
+	Origin
+	UserID uuid.UUID
+}
+
+func (c DeleteUserCommand) Validate() error { return c.validateActor() }
+
+// UnlockUserCommand levanta el bloqueo por logins fallidos de una cuenta
+type UnlockUserCommand struct {
+	Origin
+	UserID uuid.UUID
+}
+
+func (c UnlockUserCommand) Validate() error { return c.validateActor() }
+
+type UpdateUserRoleHandler struct {
+	Users repository.UserRepository
+	Audit repository.AuditRepository
+}
+
+func (h *UpdateUserRoleHandler) Handle(ctx context.Context, cmd UpdateUserRoleCommand) (models.User, error) {
+	user, err := users.UpdateRole(ctx, h.Users, cmd.actor(), cmd.UserID, cmd.Role)
+	cmd.auditResult(ctx, h.Audit, audit.ActionUserRoleUpdate, cmd.UserID.String(), "role="+cmd.Role, err)
+	return user, err
+}
+
+type SetUserStatusHandler struct {
+	Users repository.UserRepository
+	Audit repository.AuditRepository
+}
+
+func (h *SetUserStatusHandler) Handle(ctx context.Context, cmd SetUserStatusCommand) (models.User, error) {
+	action := audit.ActionUserReactivate
+	switch cmd.Status {
+	case models.UserStatusSuspended:
+		action = audit.ActionUserSuspend
+	case models.UserStatusBanned:
+		action = audit.ActionUserBan
+	}
+	reason := ""
+	if cmd.Until != nil {
+		reason = "until=" + cmd.Until.UTC().Format(time.RFC3339)
+	}
+
+	user, err := users.SetStatus(ctx, h.Users, cmd.actor(), cmd.UserID, cmd.Status, cmd.Until)
+	cmd.auditResult(ctx, h.Audit, action, cmd.UserID.String(), reason, err)
+	return user, err
+}
+
+type DeleteUserHandler struct {
+	Users repository.UserRepository
+	Audit repository.AuditRepository
+}
+
+func (h *DeleteUserHandler) Handle(ctx context.Context, cmd DeleteUserCommand) (struct{}, error) {
+	err := users.Delete(ctx, h.Users, cmd.actor(), cmd.UserID)
+	cmd.auditResult(ctx, h.Audit, audit.ActionUserDelete, cmd.UserID.String(), "", err)
+	return struct{}{}, err
+}
+
+type UnlockUserHandler struct {
+	Lockout repository.LockoutRepository
+	Audit   repository.AuditRepository
+}
+
+// Handle devuelve users.ErrNotFound si la cuenta no existe
+func (h *UnlockUserHandler) Handle(ctx context.Context, cmd UnlockUserCommand) (struct{}, error) {
+	err := lockout.Unlock(ctx, h.Lockout, cmd.UserID, cmd.actor().ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = users.ErrNotFound
+	}
+	cmd.auditResult(ctx, h.Audit, audit.ActionUserUnlock, cmd.UserID.String(), "", err)
+	return struct{}{}, err
+}
@@ -0,0 +1,53 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrValidation envuelve los errores de Validate
+var ErrValidation = errors.New("validation failed")
+
+// Validator lo implementan los mensajes que comprueban sus propios campos
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError es el error devuelto cuando un mensaje no pasa Validate.
+// Cumple errors.Is con ErrValidation y con el error original.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+
+func (e *ValidationError) Unwrap() []error { return []error{ErrValidation, e.Err} }
+
+// Validation rechaza los mensajes cuyo Validate devuelve error antes de llegar
+// al handler (y antes de abrir la transacción)
+func Validation(info Info, next Next) Next {
+	return func(ctx context.Context, msg any) (any, error) {
+		if v, ok := msg.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, &ValidationError{Err: err}
+			}
+		}
+		return next(ctx, msg)
+	}
+}
+
+// Logging registra cada mensaje despachado con su duración y el error, si lo hubo
+func Logging(info Info, next Next) Next {
+	return func(ctx context.Context, msg any) (any, error) {
+		start := time.Now()
+		res, err := next(ctx, msg)
+		if err != nil {
+			slog.WarnContext(ctx, "cqrs message failed", "kind", info.Kind, "name", info.Name, "duration", time.Since(start), "error", err)
+		} else {
+			slog.DebugContext(ctx, "cqrs message handled", "kind", info.Kind, "name", info.Name, "duration", time.Since(start))
+		}
+		return res, err
+	}
+}
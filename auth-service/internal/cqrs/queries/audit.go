@@ -0,0 +1,37 @@
+package queries
+
+import (
+	"context"
+	"io"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/audit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// ListAuditEventsQuery pagina los eventos de auditoría que cumplen el filtro
+type ListAuditEventsQuery struct {
+	audit.Filter
+}
+
+type ListAuditEventsHandler struct {
+	Audit repository.AuditRepository
+}
+
+func (h *ListAuditEventsHandler) Handle(ctx context.Context, q ListAuditEventsQuery) (audit.Page, error) {
+	return audit.List(ctx, h.Audit, q.Filter)
+}
+
+// ExportAuditEventsQuery escribe en W, como CSV, todos los eventos que
+// cumplen el filtro (sin paginar)
+type ExportAuditEventsQuery struct {
+	audit.Filter
+	W io.Writer
+}
+
+type ExportAuditEventsHandler struct {
+	Audit repository.AuditRepository
+}
+
+func (h *ExportAuditEventsHandler) Handle(ctx context.Context, q ExportAuditEventsQuery) (struct{}, error) {
+	return struct{}{}, audit.Export(ctx, h.Audit, q.Filter, q.W)
+}
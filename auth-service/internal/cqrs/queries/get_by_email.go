@@ -1,29 +1,34 @@
 package queries
 
 import (
-	"github.com/Andres09xZ/latacunga_clean_app/internal/models"
-	"gorm.io/gorm"
-)
+	"context"
+	"errors"
 
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
 
 type GetByEmailQuery struct {
 	Email string
 }
 
-type GetByEmailHandler struct {
-	DB *gorm.DB
+func (q GetByEmailQuery) Validate() error {
+	if q.Email == "" {
+		return errors.New("email is required")
+	}
+	return nil
 }
 
-func (h *GetByEmailHandler) Handle(q GetByEmailQuery) (*models.User, error) {
-	var user models.User
+type GetByEmailHandler struct {
+	Users repository.UserRepository
+}
 
-	//Busca por email y solo usuarios activos 
-	err := h.DB.Where("email = ? AND is_active = ?", q.Email, true).First(&user).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+// Handle busca el usuario por email sin filtrar por estado: quien lo use
+// decide con account.Check. Devuelve nil, nil si no existe.
+func (h *GetByEmailHandler) Handle(ctx context.Context, q GetByEmailQuery) (*models.User, error) {
+	user, err := h.Users.FindByEmail(ctx, q.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
 	}
-	return &user, nil
-}
\ No newline at end of file
+	return user, err
+}
@@ -0,0 +1,20 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mfa"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// ListMFARolePoliciesQuery lista los roles que exigen 2FA
+type ListMFARolePoliciesQuery struct{}
+
+type ListMFARolePoliciesHandler struct {
+	MFA repository.MFARepository
+}
+
+func (h *ListMFARolePoliciesHandler) Handle(ctx context.Context, _ ListMFARolePoliciesQuery) ([]models.MFARolePolicy, error) {
+	return mfa.ListRolePolicies(ctx, h.MFA)
+}
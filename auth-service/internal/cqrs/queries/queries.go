@@ -0,0 +1,19 @@
+// Package queries contiene las consultas del auth-service (solo lectura)
+package queries
+
+import (
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// Register registra las consultas en el bus
+func Register(bus *cqrs.Bus, repos repository.Repositories) {
+	cqrs.HandleQuery(bus, (&GetByEmailHandler{Users: repos.Users}).Handle)
+	cqrs.HandleQuery(bus, (&ValidateTokenHandler{Users: repos.Users}).Handle)
+	cqrs.HandleQuery(bus, (&ListUsersHandler{Users: repos.Users}).Handle)
+	cqrs.HandleQuery(bus, (&GetUserHandler{Users: repos.Users}).Handle)
+	cqrs.HandleQuery(bus, listSessions)
+	cqrs.HandleQuery(bus, (&ListMFARolePoliciesHandler{MFA: repos.MFA}).Handle)
+	cqrs.HandleQuery(bus, (&ListAuditEventsHandler{Audit: repos.Audit}).Handle)
+	cqrs.HandleQuery(bus, (&ExportAuditEventsHandler{Audit: repos.Audit}).Handle)
+}
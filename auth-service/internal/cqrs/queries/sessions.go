@@ -0,0 +1,19 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/google/uuid"
+)
+
+// ListSessionsQuery lista las sesiones activas de UserID; la de
+// CurrentSessionID se marca como actual
+type ListSessionsQuery struct {
+	UserID           uuid.UUID
+	CurrentSessionID string
+}
+
+func listSessions(ctx context.Context, q ListSessionsQuery) ([]auth.Session, error) {
+	return auth.ListSessions(ctx, q.UserID, q.CurrentSessionID)
+}
@@ -0,0 +1,37 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/users"
+	"github.com/google/uuid"
+)
+
+// ListUsersQuery pagina los usuarios que cumplen el filtro
+type ListUsersQuery struct {
+	users.Filter
+}
+
+type ListUsersHandler struct {
+	Users repository.UserRepository
+}
+
+func (h *ListUsersHandler) Handle(ctx context.Context, q ListUsersQuery) (users.Page, error) {
+	return users.List(ctx, h.Users, q.Filter)
+}
+
+// GetUserQuery busca un usuario por ID
+type GetUserQuery struct {
+	ID uuid.UUID
+}
+
+type GetUserHandler struct {
+	Users repository.UserRepository
+}
+
+// Handle devuelve users.ErrNotFound si no existe
+func (h *GetUserHandler) Handle(ctx context.Context, q GetUserQuery) (models.User, error) {
+	return users.Get(ctx, h.Users, q.ID)
+}
@@ -0,0 +1,43 @@
+package queries
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrUserNotFound indica que el usuario del token ya no existe
+var ErrUserNotFound = errors.New("user not found")
+
+// ValidateTokenQuery comprueba que el dueño de un access token todavía puede
+// usarlo (existe y su cuenta está activa)
+type ValidateTokenQuery struct {
+	UserID string
+}
+
+type ValidateTokenHandler struct {
+	Users repository.UserRepository
+}
+
+// Handle devuelve el usuario, ErrUserNotFound o el error de account
+func (h *ValidateTokenHandler) Handle(ctx context.Context, q ValidateTokenQuery) (models.User, error) {
+	id, err := uuid.Parse(q.UserID)
+	if err != nil {
+		return models.User{}, ErrUserNotFound
+	}
+	user, err := h.Users.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.User{}, ErrUserNotFound
+		}
+		return models.User{}, err
+	}
+	if err := account.Check(ctx, h.Users, user); err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
+}
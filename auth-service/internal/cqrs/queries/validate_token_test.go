@@ -0,0 +1,51 @@
+package queries_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestValidateToken(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	ids := map[string]string{}
+	for _, status := range []string{models.UserStatusActive, models.UserStatusSuspended, models.UserStatusBanned} {
+		email := status + "@example.com"
+		user := &models.User{Email: &email, Role: "operador", DisplayName: email, Status: status}
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		ids[status] = user.ID.String()
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr error
+	}{
+		{name: "active", userID: ids[models.UserStatusActive]},
+		{name: "suspended", userID: ids[models.UserStatusSuspended], wantErr: account.ErrSuspended},
+		{name: "banned", userID: ids[models.UserStatusBanned], wantErr: account.ErrBanned},
+		{name: "deleted user", userID: uuid.NewString(), wantErr: queries.ErrUserNotFound},
+		{name: "malformed subject", userID: "not-a-uuid", wantErr: queries.ErrUserNotFound},
+	}
+	h := &queries.ValidateTokenHandler{Users: users}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := h.Handle(ctx, queries.ValidateTokenQuery{UserID: tt.userID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.ID.String() != tt.userID {
+				t.Errorf("user = %s, want %s", user.ID, tt.userID)
+			}
+		})
+	}
+}
@@ -1,40 +1,71 @@
 package database
 
 import (
+	"context"
 	"log"
-	"os"
+	"log/slog"
 
-	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/migrations"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
 
-// InitDB initializes the database connection and migrates models
-func InitDB() {
-	dsn := os.Getenv("DB_URL")
-	if dsn == "" {
-		log.Fatal("DB_URL environment variable is not set")
+// InitDB initializes the database connection and applies pending migrations.
+// Set DB_MIGRATE=off to skip them (e.g. when cmd/migrate runs before deploying).
+func InitDB(c config.Database) {
+	Connect(c.URL)
+
+	if !c.Migrate {
+		slog.Info("DB_MIGRATE=off, skipping migrations")
+		return
+	}
+	runner, err := NewMigrator()
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	applied, err := runner.Up(context.Background())
+	if err != nil {
+		log.Fatal("Failed to migrate database:", err)
 	}
+	slog.Info("database schema up to date", "applied", len(applied))
+}
 
+// Connect opens the database connection without migrating
+func Connect(dsn string) {
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError maps unique violations to gorm.ErrDuplicatedKey (see repository)
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("Error conectando a Neon PostgreSQL:", err)
 	}
+	// One span per query, without bound values (they may hold personal data or token hashes)
+	if err := DB.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		log.Fatal("Failed to enable database tracing:", err)
+	}
 
-	log.Println("Conectado a Neon PostgreSQL")
+	slog.Info("connected to PostgreSQL")
+}
 
-	// Drop tables in reverse order to avoid foreign key constraints
-	err = DB.Migrator().DropTable(&models.OTPCode{}, &models.OperatorProfile{}, &models.User{})
+// NewMigrator returns the migration runner for the embedded auth-service migrations
+func NewMigrator() (*migrate.Runner, error) {
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Printf("Warning: Could not drop tables: %v", err)
+		return nil, err
 	}
+	return migrate.New(sqlDB, migrations.FS, "auth-service")
+}
 
-	// Auto-migrate models
-	err = DB.AutoMigrate(&models.User{}, &models.OperatorProfile{}, &models.OTPCode{})
+// Close cierra la conexión; se llama al apagar el servicio, después de drenar
+// las peticiones en curso
+func Close(ctx context.Context) error {
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return err
 	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithTx devuelve un contexto que lleva la transacción tx. Los repositorios
+// GORM la usan en lugar de su conexión.
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// WithoutTx devuelve un contexto sin transacción, para escrituras que deben
+// quedar guardadas aunque la transacción en curso se deshaga
+func WithoutTx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, (*gorm.DB)(nil))
+}
+
+// Conn devuelve la transacción de ctx o, si no hay, db
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok && tx != nil {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
+
+// InTx indica si ctx lleva una transacción
+func InTx(ctx context.Context) bool {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok && tx != nil
+}
+
+// Transaction ejecuta fn en una transacción de db. Si ctx ya lleva una, fn
+// corre dentro de ella y la confirma quien la abrió; con db nil (repositorios
+// en memoria) corre sin transacción.
+func Transaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if db == nil || InTx(ctx) {
+		return fn(ctx)
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	})
+}
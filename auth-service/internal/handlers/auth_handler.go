@@ -1,23 +1,32 @@
 ﻿package handlers
 
 import (
-	"crypto/rand"
-	"fmt"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
-	"regexp"
+	"strconv"
 	"time"
 
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
-	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/passwordreset"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=user operador admin"`
+	Email           string `json:"email" binding:"required,email"`
+	Password        string `json:"password" binding:"required,min=6"`
+	Role            string `json:"role" binding:"omitempty,oneof=user trabajador operador admin"`
+	InvitationToken string `json:"invitation_token,omitempty"`
 }
 
 type LoginRequest struct {
@@ -25,6 +34,30 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ValidateTokenResponse is the shape report-service's JWTAuth decodes
+type ValidateTokenResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
 type OTPRequest struct {
 	Phone string `json:"phone" binding:"required"`
 }
@@ -37,24 +70,18 @@ type OTPVerifyRequest struct {
 // Register creates a new user account (only for operador/admin)
 //
 //	@Summary	Register a new user (operador/admin only)
-//	@Description	Create a new user account with email, password and role (only operador/admin allowed)
+//	@Description	Create an operador/admin account. Requires an admin/super_admin access token (super_admin for admin-level roles) or a single-use invitation token; with an invitation the role comes from the invitation
 //	@Tags		auth
 //	@Accept		json
 //	@Produce	json
+//	@Security	BearerAuth
 //	@Param		request	body		RegisterRequest	true	"Register request"
 //	@Success	201		{object}	map[string]interface{}
 //	@Failure	400		{object}	map[string]string
+//	@Failure	401		{object}	map[string]string
+//	@Failure	403		{object}	map[string]string
 //	@Failure	500		{object}	map[string]string
 //	@Router		/auth/register [post]
-//
-// @Tagsauth
-// @Acceptjson
-// @Producejson
-// @ParamrequestbodyRegisterRequesttrue"Register request""
-// @Success201{object}map[string]interface{}
-// @Failure400{object}map[string]string
-// @Failure500{object}map[string]string
-// @Router/auth/register [post]
 func Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -62,100 +89,289 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Reject registration for "user" role - must use OTP
-	if req.Role == "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Registro de ciudadanos solo por OTP (teléfono)"})
+	res, err := cqrs.Send[commands.RegisterResult](c.Request.Context(), Bus, commands.RegisterCommand{
+		Origin:          origin(c),
+		Email:           req.Email,
+		Password:        req.Password,
+		Role:            req.Role,
+		InvitationToken: req.InvitationToken,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, commands.ErrCitizenRole):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Registro de ciudadanos solo por OTP (teléfono)"})
+		case errors.Is(err, commands.ErrNotAdmin):
+			c.JSON(http.StatusForbidden, gin.H{"message": "Solo un administrador puede registrar cuentas"})
+		case errors.Is(err, commands.ErrAdminRole):
+			c.JSON(http.StatusForbidden, gin.H{"message": "Solo un super_admin puede registrar cuentas de administrador"})
+		case errors.Is(err, invitation.ErrInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invitación inválida o expirada"})
+		case errors.Is(err, commands.ErrRoleMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "El rol no coincide con la invitación"})
+		case errors.Is(err, commands.ErrNoAuthorization):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Se requiere sesión de administrador o invitación"})
+		case errors.Is(err, commands.ErrRoleRequired), errors.Is(err, cqrs.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, commands.ErrUserExists):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
 		return
 	}
 
-	// Check if user already exists
-	var existing models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
+	c.JSON(http.StatusCreated, gin.H{
+		"id":            res.User.ID,
+		"email":         *res.User.Email,
+		"role":          res.User.Role,
+		"created_by_id": res.CreatedByID,
+	})
+}
+
+// Login authenticates a user and returns tokens
+//
+// @Summary Login user
+// @Description Authenticate user with email and password, return access and refresh tokens. Accounts with 2FA get an mfa_token to complete the login at /auth/mfa/verify instead. A temporarily locked account answers 401 like a wrong password; 429 only limits failures per client IP
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Login request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/login [post]
+func Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Hash password
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), 12)
+	res, err := cqrs.Send[commands.LoginResult](c.Request.Context(), Bus, commands.LoginCommand{
+		Origin:   origin(c),
+		Email:    req.Email,
+		Password: req.Password,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		var retry *commands.RetryError
+		switch {
+		case errors.As(err, &retry):
+			tooManyRequests(c, retry.Wait, "Demasiados intentos fallidos, intente más tarde")
+		case errors.Is(err, commands.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		case account.Code(err) != "":
+			accountError(c, err)
+		case errors.Is(err, cqrs.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		}
 		return
 	}
 
-	// Create user
-	user := models.User{
-		Email:        &req.Email,
-		PasswordHash: stringPtr(string(hashed)),
-		Role:         req.Role,
-		DisplayName:  req.Email, // Use email as display name for now
+	switch {
+	case res.MFARequired:
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": res.MFAToken})
+	case res.MFAEnrollmentRequired:
+		c.JSON(http.StatusOK, gin.H{"mfa_enrollment_required": true, "mfa_token": res.MFAToken})
+	default:
+		tokenResponse(c, res.TokenPair)
 	}
+}
 
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+// Refresh exchanges a refresh token for a new token pair
+//
+// @Summary Refresh tokens
+// @Description Exchange a valid refresh token for a new access and refresh token. The presented refresh token is rotated; replaying an already rotated token revokes the whole session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/refresh [post]
+func Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// If role is operador, create operator profile
-	if req.Role == "operador" {
-		operatorProfile := models.OperatorProfile{
-			UserID: user.ID,
+	tokens, err := cqrs.Send[commands.TokenPair](c.Request.Context(), Bus, commands.RefreshCommand{
+		Origin:       origin(c),
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenInvalid) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token inválido o expirado"})
+			return
 		}
-		if err := database.DB.Create(&operatorProfile).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create operator profile"})
+		if account.Code(err) != "" {
+			accountError(c, err)
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh tokens"})
+		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":    user.ID,
-		"email": *user.Email,
-		"role":  user.Role,
-	})
+	tokenResponse(c, tokens)
 }
 
-// Login authenticates a user and returns tokens
+// Logout revokes the session of the given refresh token
 //
-// @Summary Login user
-// @Description Authenticate user with email and password, return access and refresh tokens
+// @Summary Logout
+// @Description Revoke the presented refresh token (and the session it belongs to)
 // @Tags auth
 // @Accept json
-// @Produce json
-// @Param request body LoginRequest true "Login request"
-// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Param request body LogoutRequest true "Logout request"
+// @Success 204
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
-// @Router /auth/login [post]
-func Login(c *gin.Context) {
-	var req LoginRequest
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	var req LogoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Find user
-	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.LogoutCommand{
+		Origin:       origin(c),
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token inválido o expirado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes every refresh token of the authenticated user
+//
+// @Summary Logout from all devices
+// @Description Revoke all refresh tokens of the authenticated user
+// @Tags auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Router /auth/logout/all [post]
+func LogoutAll(c *gin.Context) {
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.LogoutAllCommand{Origin: origin(c)})
+	if err != nil {
+		if errors.Is(err, cqrs.ErrValidation) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke tokens"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ValidateToken checks an access token on behalf of other services
+//
+// @Summary Validate access token
+// @Description Validate the bearer access token and check the user still exists and is active. Used by report-service
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} ValidateTokenResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/validate-token [post]
+func ValidateToken(c *gin.Context) {
+	user, err := cqrs.Ask[models.User](c.Request.Context(), Bus, queries.ValidateTokenQuery{UserID: authz.UserID(c)})
+	if err != nil {
+		if account.Code(err) != "" {
+			accountError(c, err)
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
 	}
 
-	// Check password
-	if user.PasswordHash == nil || bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(req.Password)) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	c.JSON(http.StatusOK, ValidateTokenResponse{
+		UserID: user.ID.String(),
+		Email:  authz.Email(c),
+		Role:   user.Role,
+	})
+}
+
+// RequestPasswordReset emails a password reset token
+//
+// @Summary Request password reset
+// @Description Send a single-use password reset token to the email of an admin/operador account. The response is the same whether the email exists or not
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetRequest true "Password reset request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/password/reset-request [post]
+func RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Generate tokens
-	accessToken, refreshToken, err := auth.GenerateTokens(user.ID.String(), *user.Email, user.Role)
+	// Errors are only logged: a different answer would tell whether the email has an account
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.RequestPasswordResetCommand{Email: req.Email})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		slog.ErrorContext(c.Request.Context(), "failed to process password reset request", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Si el email está registrado recibirá las instrucciones para restablecer la contraseña"})
+}
+
+// ConfirmPasswordReset sets a new password using a reset token
+//
+// @Summary Confirm password reset
+// @Description Set a new password with a reset token. The token is single-use and all sessions of the user are revoked
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetConfirmRequest true "Password reset confirmation"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/reset [post]
+func ConfirmPasswordReset(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.ConfirmPasswordResetCommand{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
 	})
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrInvalidToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Token inválido o expirado"})
+			return
+		}
+		if errors.Is(err, cqrs.ErrValidation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contraseña actualizada"})
 }
 
 // RequestOTP sends OTP to phone number
@@ -169,6 +385,8 @@ func Login(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 502 {object} map[string]string
 // @Router /auth/otp/send [post]
 func RequestOTP(c *gin.Context) {
 	var req OTPRequest
@@ -177,47 +395,27 @@ func RequestOTP(c *gin.Context) {
 		return
 	}
 
-	// Validate phone format (E.164)
-	if !isValidPhone(req.Phone) {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Formato de teléfono inválido. Use E.164"})
-		return
-	}
-
-	// Check if phone belongs to non-user role
-	var user models.User
-	if err := database.DB.Where("phone = ?", req.Phone).First(&user).Error; err == nil {
-		if user.Role != "user" {
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.SendOTPCommand{
+		Origin: origin(c),
+		Phone:  req.Phone,
+	})
+	if err != nil {
+		var retry *commands.RetryError
+		switch {
+		case errors.Is(err, commands.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Formato de teléfono inválido. Use E.164"})
+		case errors.As(err, &retry):
+			tooManyRequests(c, retry.Wait, "Demasiadas solicitudes de OTP, intente más tarde")
+		case errors.Is(err, commands.ErrRoleNotAllowed):
 			c.JSON(http.StatusForbidden, gin.H{"message": "Método de autenticación no permitido para este rol"})
-			return
+		case errors.Is(err, commands.ErrSMSFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo enviar el OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
 		}
-	}
-
-	// Generate 6-digit OTP
-	otpCode := generateOTP()
-
-	// Hash the code
-	hashedCode, err := bcrypt.GenerateFromPassword([]byte(otpCode), 12)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
 		return
 	}
 
-	// Save OTP code
-	otp := models.OTPCode{
-		Phone:       req.Phone,
-		CodeHash:    string(hashedCode),
-		ExpiresAt:   time.Now().Add(5 * time.Minute),
-		MaxAttempts: 5,
-	}
-
-	if err := database.DB.Create(&otp).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save OTP"})
-		return
-	}
-
-	// TODO: Send OTP via SMS (placeholder)
-	fmt.Printf("OTP for %s: %s\n", req.Phone, otpCode)
-
 	c.JSON(http.StatusOK, gin.H{"message": "OTP enviado"})
 }
 
@@ -231,6 +429,7 @@ func RequestOTP(c *gin.Context) {
 // @Param request body OTPVerifyRequest true "OTP verify request"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 429 {object} map[string]string
 // @Router /auth/otp/verify [post]
 func VerifyOTP(c *gin.Context) {
@@ -240,79 +439,80 @@ func VerifyOTP(c *gin.Context) {
 		return
 	}
 
-	// Find latest OTP for phone
-	var otp models.OTPCode
-	if err := database.DB.Where("phone = ? AND consumed = false AND expires_at > ?", req.Phone, time.Now()).
-		Order("issued_at DESC").First(&otp).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "OTP inválido"})
+	tokens, err := cqrs.Send[commands.TokenPair](c.Request.Context(), Bus, commands.VerifyOTPCommand{
+		Origin: origin(c),
+		Phone:  req.Phone,
+		Code:   req.Code,
+	})
+	if err != nil {
+		var retry *commands.RetryError
+		switch {
+		case errors.As(err, &retry):
+			tooManyRequests(c, retry.Wait, "Demasiados intentos fallidos, intente más tarde")
+		case errors.Is(err, commands.ErrOTPExhausted):
+			c.JSON(http.StatusTooManyRequests, gin.H{"message": "Límite de intentos excedido, solicite un nuevo OTP"})
+		case errors.Is(err, commands.ErrInvalidOTP), errors.Is(err, cqrs.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "OTP inválido"})
+		case account.Code(err) != "":
+			accountError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		}
 		return
 	}
 
-	// Check attempts
-	if otp.Attempts >= otp.MaxAttempts {
-		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Límite de intentos excedido, solicite un nuevo OTP"})
-		return
-	}
+	tokenResponse(c, tokens)
+}
 
-	// Increment attempts
-	otp.Attempts++
-	database.DB.Save(&otp)
+// Helper functions
 
-	// Verify code
-	if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(req.Code)) != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "OTP inválido"})
-		return
-	}
+// Bus despacha los comandos y consultas de los handlers de auth
+var Bus *cqrs.Bus
 
-	// Mark as consumed
-	otp.Consumed = true
-	database.DB.Save(&otp)
-
-	// Find or create user
-	var user models.User
-	if err := database.DB.Where("phone = ?", req.Phone).First(&user).Error; err != nil {
-		// Create new user
-		user = models.User{
-			Phone:       &req.Phone,
-			Role:        "user",
-			DisplayName: req.Phone, // Use phone as display name
-		}
-		if err := database.DB.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-			return
-		}
-	}
+// InitBus crea el bus y registra los comandos y consultas sobre r. db abre
+// las transacciones de los comandos; nil con repositorios en memoria.
+func InitBus(db *gorm.DB, r repository.Repositories) {
+	Bus = cqrs.New(db, cqrs.Logging, cqrs.Validation)
+	queries.Register(Bus, r)
+	commands.Register(Bus, r)
+}
 
-	// Generate tokens
-	accessToken, refreshToken, err := auth.GenerateTokens(user.ID.String(), *user.Phone, user.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
-		return
+// origin identifica al actor y al dispositivo de la petición
+func origin(c *gin.Context) commands.Origin {
+	return commands.Origin{
+		ActorID:   authz.UserID(c),
+		ActorRole: authz.Role(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
 	}
+}
 
+func tokenResponse(c *gin.Context, tokens commands.TokenPair) {
 	c.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 	})
 }
 
-// Helper functions
-func stringPtr(s string) *string {
-	return &s
-}
-
-func isValidPhone(phone string) bool {
-	// E.164 format: +[country code][number]
-	match, _ := regexp.MatchString(`^\+[1-9]\d{7,14}$`, phone)
-	return match
+// accountError responde 403 con el código del estado de la cuenta
+// (ACCOUNT_SUSPENDED, ACCOUNT_BANNED o ACCOUNT_INACTIVE)
+func accountError(c *gin.Context, err error) {
+	resp := gin.H{"error": account.Code(err)}
+	switch {
+	case errors.Is(err, account.ErrSuspended):
+		resp["message"] = "Cuenta suspendida"
+		if until := account.SuspendedUntil(err); until != nil {
+			resp["suspended_until"] = until
+		}
+	case errors.Is(err, account.ErrBanned):
+		resp["message"] = "Cuenta bloqueada permanentemente"
+	default:
+		resp["message"] = "Cuenta inactiva"
+	}
+	c.JSON(http.StatusForbidden, resp)
 }
 
-func generateOTP() string {
-	const digits = "0123456789"
-	code := make([]byte, 6)
-	rand.Read(code)
-	for i := range code {
-		code[i] = digits[int(code[i])%10]
-	}
-	return string(code)
+func tooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"message": message})
 }
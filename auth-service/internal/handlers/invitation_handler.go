@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateInvitationRequest struct {
+	Role           string `json:"role" binding:"required,oneof=trabajador operador admin"`
+	Email          string `json:"email,omitempty" binding:"omitempty,email"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" binding:"omitempty,min=1,max=720"`
+}
+
+// CreateInvitation emite una invitación de registro de un solo uso.
+// @Summary Create registration invitation
+// @Description Issue a signed single-use invitation to register an operador/admin account. Optionally bound to an email. Requires invitations:create permission, and users:manage_admins to invite an admin.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateInvitationRequest true "Invitation request"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/invitations [post]
+func CreateInvitation(c *gin.Context) {
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var email *string
+	if req.Email != "" {
+		email = &req.Email
+	}
+
+	res, err := cqrs.Send[commands.CreateInvitationResult](c.Request.Context(), Bus, commands.CreateInvitationCommand{
+		Origin: origin(c),
+		Role:   req.Role,
+		Email:  email,
+		TTL:    time.Duration(req.ExpiresInHours) * time.Hour,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, commands.ErrUnauthenticated):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		case errors.Is(err, invitation.ErrInsufficient):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		}
+		return
+	}
+
+	inv := res.Invitation
+	c.JSON(http.StatusCreated, gin.H{
+		"id":               inv.ID,
+		"role":             inv.Role,
+		"email":            inv.Email,
+		"expires_at":       inv.ExpiresAt,
+		"invitation_token": res.Token,
+	})
+}
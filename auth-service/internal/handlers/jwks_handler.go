@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publica las llaves públicas para que otros servicios verifiquen los tokens.
+// @Summary JSON Web Key Set
+// @Description Public keys (RS256/EdDSA) used to verify access tokens, identified by kid
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKS
+// @Failure 500 {object} map[string]string
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	set, err := auth.PublicJWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "keys not available"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
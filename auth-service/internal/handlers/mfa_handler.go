@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mfa"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAEnrollRequest struct {
+	// MFAToken is the enrollment token returned by login when the role requires 2FA.
+	// Not needed when calling with an access token.
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+type MFAConfirmRequest struct {
+	Code     string `json:"code" binding:"required,len=6"`
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFADisableRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFARoleRequirementRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// EnrollTOTP starts TOTP enrollment
+//
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret and otpauth:// provisioning URI (render it as a QR code). Authenticate with an access token, or with the mfa_token returned by login when the role requires 2FA
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MFAEnrollRequest false "Enrollment request"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/mfa/totp/enroll [post]
+func EnrollTOTP(c *gin.Context) {
+	var req MFAEnrollRequest
+	// The body is optional when authenticating with an access token
+	_ = c.ShouldBindJSON(&req)
+
+	res, err := cqrs.Send[commands.EnrollTOTPResult](c.Request.Context(), Bus, commands.EnrollTOTPCommand{
+		Origin:   origin(c),
+		MFAToken: req.MFAToken,
+	})
+	if err != nil {
+		if mfaUserError(c, err) {
+			return
+		}
+		if errors.Is(err, mfa.ErrAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"message": "2FA ya está activo"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           res.Secret,
+		"provisioning_uri": res.ProvisioningURI,
+	})
+}
+
+// ConfirmTOTP finishes TOTP enrollment
+//
+// @Summary Confirm TOTP enrollment
+// @Description Activate TOTP with a code from the authenticator app and return one-time recovery codes. When authenticated with the login mfa_token it also returns access and refresh tokens
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MFAConfirmRequest true "Confirmation request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/totp/confirm [post]
+func ConfirmTOTP(c *gin.Context) {
+	var req MFAConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := cqrs.Send[commands.ConfirmTOTPResult](c.Request.Context(), Bus, commands.ConfirmTOTPCommand{
+		Origin:   origin(c),
+		MFAToken: req.MFAToken,
+		Code:     req.Code,
+	})
+	if err != nil {
+		if mfaUserError(c, err) {
+			return
+		}
+		mfaError(c, err)
+		return
+	}
+
+	resp := gin.H{"recovery_codes": res.RecoveryCodes}
+	// Completing an enrollment required at login finishes the login
+	if res.AccessToken != "" {
+		resp["access_token"] = res.AccessToken
+		resp["refresh_token"] = res.RefreshToken
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// VerifyMFA completes a two-step login
+//
+// @Summary Verify second factor
+// @Description Exchange the mfa_token returned by login plus a TOTP or recovery code for access and refresh tokens
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body MFAVerifyRequest true "Verification request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/verify [post]
+func VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := cqrs.Send[commands.TokenPair](c.Request.Context(), Bus, commands.VerifyMFACommand{
+		Origin:   origin(c),
+		MFAToken: req.MFAToken,
+		Code:     req.Code,
+	})
+	if err != nil {
+		if mfaUserError(c, err) {
+			return
+		}
+		mfaError(c, err)
+		return
+	}
+
+	tokenResponse(c, tokens)
+}
+
+// DisableTOTP turns off TOTP for the authenticated user
+//
+// @Summary Disable TOTP
+// @Description Disable TOTP after checking a TOTP or recovery code. Not allowed when the user's role requires 2FA
+// @Tags mfa
+// @Accept json
+// @Security BearerAuth
+// @Param request body MFADisableRequest true "Disable request"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/mfa/totp/disable [post]
+func DisableTOTP(c *gin.Context) {
+	var req MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := cqrs.Send[struct{}](c.Request.Context(), Bus, commands.DisableTOTPCommand{
+		Origin: origin(c),
+		Code:   req.Code,
+	})
+	if err != nil {
+		if mfaUserError(c, err) {
+			return
+		}
+		if errors.Is(err, mfa.ErrRequiredByRole) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "2FA es obligatorio para este rol"})
+			return
+		}
+		mfaError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListMFARolePolicies lista los roles que exigen 2FA.
+// @Summary List 2FA role requirements
+// @Description Roles whose accounts must use two-factor authentication. Requires security:manage permission.
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.MFARolePolicy
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/mfa/roles [get]
+func ListMFARolePolicies(c *gin.Context) {
+	policies, err := cqrs.Ask[[]models.MFARolePolicy](c.Request.Context(), Bus, queries.ListMFARolePoliciesQuery{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// SetMFARoleRequirement exige o deja de exigir 2FA para un rol.
+// @Summary Require 2FA for a role
+// @Description Require (or stop requiring) two-factor authentication for every account of a role. Requires security:manage permission.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role" Enums(operador, admin, super_admin, trabajador)
+// @Param request body MFARoleRequirementRequest true "Requirement"
+// @Success 200 {object} models.MFARolePolicy
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/mfa/roles/{role} [put]
+func SetMFARoleRequirement(c *gin.Context) {
+	var req MFARoleRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := cqrs.Send[models.MFARolePolicy](c.Request.Context(), Bus, commands.SetMFARoleRequirementCommand{
+		Origin:   origin(c),
+		Role:     c.Param("role"),
+		Required: *req.Required,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, commands.ErrUnauthenticated):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		case errors.Is(err, cqrs.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// mfaUserError writes the response when the 2FA command could not resolve its
+// user, or its request failed validation or the account check
+func mfaUserError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, commands.ErrMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Token inválido o expirado"})
+	case errors.Is(err, commands.ErrMFAPasswordOnly):
+		c.JSON(http.StatusForbidden, gin.H{"message": "2FA solo está disponible para cuentas con contraseña"})
+	case errors.Is(err, cqrs.ErrValidation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case account.Code(err) != "":
+		accountError(c, err)
+	default:
+		return false
+	}
+	return true
+}
+
+func mfaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Código inválido"})
+	case errors.Is(err, mfa.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Demasiados intentos fallidos, intente más tarde"})
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA no está configurado"})
+	case errors.Is(err, mfa.ErrAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"message": "2FA ya está activo"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+	}
+}
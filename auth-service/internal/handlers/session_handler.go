@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions lists the active sessions of the authenticated user
+//
+// @Summary List my sessions
+// @Description List the active sessions (devices) of the authenticated user with user agent, IP, creation time and last use. The session of the current access token is flagged with current=true
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} auth.Session
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	userID, err := uuid.Parse(authz.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	listSessions(c, userID, authz.SessionID(c))
+}
+
+// RevokeSession closes one of the authenticated user's sessions
+//
+// @Summary Revoke one of my sessions
+// @Description Revoke the refresh tokens of a session (e.g. a lost device). Access tokens already issued remain valid until they expire
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	userID, err := uuid.Parse(authz.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	revokeSession(c, userID, c.Param("id"))
+}
+
+// ListUserSessions lista las sesiones activas de cualquier usuario.
+// @Summary List user sessions
+// @Description List the active sessions (devices) of a user. Requires users:read permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {array} auth.Session
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/sessions [get]
+func ListUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	listSessions(c, userID, "")
+}
+
+// RevokeUserSession cierra una sesión de cualquier usuario.
+// @Summary Revoke user session
+// @Description Revoke the refresh tokens of one session of a user. Requires users:manage permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param sid path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/sessions/{sid} [delete]
+func RevokeUserSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	revokeSession(c, userID, c.Param("sid"))
+}
+
+func listSessions(c *gin.Context, userID uuid.UUID, currentSessionID string) {
+	sessions, err := cqrs.Ask[[]auth.Session](c.Request.Context(), Bus, queries.ListSessionsQuery{
+		UserID:           userID,
+		CurrentSessionID: currentSessionID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func revokeSession(c *gin.Context, userID uuid.UUID, sessionParam string) {
+	sessionID, err := uuid.Parse(sessionParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	_, err = cqrs.Send[struct{}](c.Request.Context(), Bus, commands.RevokeSessionCommand{
+		Origin:    origin(c),
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrSessionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		case errors.Is(err, commands.ErrUnauthenticated):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,28 +1,278 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/commands"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/users"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// ListUsers obtiene una lista de todos los usuarios.
-// @Summary List all users
-// @Description Retrieve a list of all users in the system. Requires admin role.
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user trabajador operador admin super_admin"`
+}
+
+type SuspendUserRequest struct {
+	// Until is when the suspension ends (RFC 3339); omit for an indefinite suspension
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// ListUsers obtiene una lista paginada de usuarios.
+// @Summary List users
+// @Description Retrieve a paginated list of users, newest first, filtered by role, status, email or phone (partial match). Requires users:read permission.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.User
+// @Param role query string false "Role"
+// @Param status query string false "Status (ACTIVE, SUSPENDED, BANNED)"
+// @Param email query string false "Email contains"
+// @Param phone query string false "Phone contains"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} users.Page
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/users [get]
 func ListUsers(c *gin.Context) {
-	var users []models.User
-	if err := database.DB.Find(&users).Error; err != nil {
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	result, err := cqrs.Ask[users.Page](c.Request.Context(), Bus, queries.ListUsersQuery{Filter: users.Filter{
+		Role:     c.Query("role"),
+		Status:   c.Query("status"),
+		Email:    c.Query("email"),
+		Phone:    c.Query("phone"),
+		Page:     page,
+		PageSize: pageSize,
+	}})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUser obtiene un usuario por ID.
+// @Summary Get user
+// @Description Retrieve a user by ID. Requires users:read permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id} [get]
+func GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := cqrs.Ask[models.User](c.Request.Context(), Bus, queries.GetUserQuery{ID: userID})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserRole cambia el rol de un usuario y cierra sus sesiones.
+// @Summary Update user role
+// @Description Change the role of a user and revoke their sessions. Granting or changing admin roles requires users:manage_admins (super_admin). Requires users:manage permission.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body UpdateUserRoleRequest true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/role [put]
+func UpdateUserRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := cqrs.Send[models.User](c.Request.Context(), Bus, commands.UpdateUserRoleCommand{
+		Origin: origin(c),
+		UserID: userID,
+		Role:   req.Role,
+	})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser suspende una cuenta y cierra sus sesiones.
+// @Summary Suspend user
+// @Description Set the user status to SUSPENDED, optionally until a given time, and revoke their sessions. Suspended users get 403 ACCOUNT_SUSPENDED on login, OTP, refresh and token validation. Requires users:manage permission.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body SuspendUserRequest false "Suspension"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func SuspendUser(c *gin.Context) {
+	var req SuspendUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	setUserStatus(c, models.UserStatusSuspended, req.Until)
+}
+
+// BanUser bloquea una cuenta de forma permanente y cierra sus sesiones.
+// @Summary Ban user
+// @Description Set the user status to BANNED and revoke their sessions. Banned users get 403 ACCOUNT_BANNED until reactivated. Requires users:manage permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/ban [post]
+func BanUser(c *gin.Context) {
+	setUserStatus(c, models.UserStatusBanned, nil)
+}
+
+// ReactivateUser reactiva una cuenta suspendida.
+// @Summary Reactivate user
+// @Description Set a suspended or banned user back to ACTIVE and revoke their sessions. Requires users:manage permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+func ReactivateUser(c *gin.Context) {
+	setUserStatus(c, models.UserStatusActive, nil)
+}
+
+// DeleteUser elimina una cuenta.
+// @Summary Delete user
+// @Description Delete a user together with their sessions, reset tokens, recovery codes and operator profile. Requires users:manage permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	_, err = cqrs.Send[struct{}](c.Request.Context(), Bus, commands.DeleteUserCommand{Origin: origin(c), UserID: userID})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockUser desbloquea una cuenta bloqueada por intentos fallidos de login.
+// @Summary Unlock user account
+// @Description Clear the failed login counter and temporary lock of a user. Requires users:manage permission.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	_, err = cqrs.Send[struct{}](c.Request.Context(), Bus, commands.UnlockUserCommand{Origin: origin(c), UserID: userID})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func setUserStatus(c *gin.Context, status string, until *time.Time) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := cqrs.Send[models.User](c.Request.Context(), Bus, commands.SetUserStatusCommand{
+		Origin: origin(c),
+		UserID: userID,
+		Status: status,
+		Until:  until,
+	})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func userError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, commands.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+	case errors.Is(err, cqrs.ErrValidation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, users.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case errors.Is(err, users.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, users.ErrSelf), errors.Is(err, users.ErrInsufficient):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+	}
 }
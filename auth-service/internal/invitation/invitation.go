@@ -0,0 +1,94 @@
+// Package invitation emite y consume invitaciones de registro para cuentas
+// operador/admin. Cada invitación fija el rol, vence y se puede usar una sola vez.
+package invitation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+)
+
+// DefaultTTL es la vigencia de una invitación si no se indica otra
+const DefaultTTL = 72 * time.Hour
+
+// ErrInvalid se devuelve si la invitación no existe, ya se usó, expiró o no
+// corresponde al email con el que se registra
+var ErrInvalid = errors.New("invitation invalid or expired")
+
+// ErrInsufficient se devuelve si quien invita no puede otorgar el rol
+var ErrInsufficient = errors.New("inviting admin accounts requires users:manage_admins")
+
+// Create guarda una invitación emitida por adminID (con rol adminRole) y
+// devuelve su token firmado
+func Create(ctx context.Context, repo repository.InvitationRepository, adminID uuid.UUID, adminRole, role string, email *string, ttl time.Duration) (string, models.Invitation, error) {
+	if !authz.CanGrant(adminRole, role) {
+		return "", models.Invitation{}, ErrInsufficient
+	}
+
+	inv := models.Invitation{
+		ID:          uuid.New(),
+		Email:       email,
+		Role:        role,
+		CreatedByID: adminID,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+
+	invitedEmail := ""
+	if email != nil {
+		invitedEmail = *email
+	}
+	token, err := auth.GenerateInvitationToken(inv.ID.String(), invitedEmail, role, inv.ExpiresAt)
+	if err != nil {
+		return "", inv, err
+	}
+	if err := repo.Create(ctx, &inv); err != nil {
+		return "", inv, err
+	}
+	return token, inv, nil
+}
+
+// Validate comprueba el token y que la invitación siga pendiente para email
+func Validate(ctx context.Context, repo repository.InvitationRepository, token, email string) (*models.Invitation, error) {
+	claims, err := auth.ValidateInvitationToken(token)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	inv, err := repo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inv.UsedAt != nil || time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvalid
+	}
+	if inv.Email != nil && *inv.Email != email {
+		return nil, ErrInvalid
+	}
+	return inv, nil
+}
+
+// Consume marca la invitación como usada por userID, en la transacción que
+// lleve ctx. Falla si otra petición la consumió antes.
+func Consume(ctx context.Context, repo repository.InvitationRepository, inv *models.Invitation, userID uuid.UUID) error {
+	consumed, err := repo.Consume(ctx, inv.ID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalid
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package invitation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/invitation"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+)
+
+func setup(t *testing.T) repository.InvitationRepository {
+	t.Helper()
+	auth.InitKeys(config.JWT{AccessHours: 1, RefreshHours: 168}, true)
+	return repository.NewMemoryInvitationRepository()
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		actor, role string
+		wantErr     error
+	}{
+		{authz.RoleAdmin, authz.RoleOperador, nil},
+		{authz.RoleAdmin, authz.RoleTrabajador, nil},
+		{authz.RoleAdmin, authz.RoleAdmin, invitation.ErrInsufficient},
+		{authz.RoleSuperAdmin, authz.RoleAdmin, nil},
+		{authz.RoleOperador, authz.RoleAdmin, invitation.ErrInsufficient},
+	}
+	for _, tt := range tests {
+		repo := setup(t)
+		adminID := uuid.New()
+		token, inv, err := invitation.Create(context.Background(), repo, adminID, tt.actor, tt.role, nil, invitation.DefaultTTL)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s inviting %s: err = %v, want %v", tt.actor, tt.role, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if token == "" || inv.Role != tt.role || inv.CreatedByID != adminID {
+			t.Errorf("%s inviting %s: token %q, invitation %+v", tt.actor, tt.role, token, inv)
+		}
+		if _, err := repo.FindByID(context.Background(), inv.ID); err != nil {
+			t.Errorf("invitation not stored: %v", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	invited := "new.operator@example.com"
+
+	tests := []struct {
+		name    string
+		email   *string
+		ttl     time.Duration
+		prepare func(t *testing.T, repo repository.InvitationRepository, id uuid.UUID)
+		// token reemplaza el token emitido si no es vacío
+		token string
+		// forget valida contra una base que no tiene la invitación
+		forget  bool
+		as      string
+		wantErr error
+	}{
+		{name: "invited email", email: &invited, as: invited},
+		{name: "open invitation", as: "anyone@example.com"},
+		{name: "different email", email: &invited, as: "other@example.com", wantErr: invitation.ErrInvalid},
+		{name: "expired", ttl: -time.Minute, as: invited, wantErr: invitation.ErrInvalid},
+		{name: "malformed token", token: "not-a-token", as: invited, wantErr: invitation.ErrInvalid},
+		{
+			name: "already used",
+			as:   invited,
+			prepare: func(t *testing.T, repo repository.InvitationRepository, id uuid.UUID) {
+				if ok, err := repo.Consume(ctx, id, uuid.New(), time.Now()); !ok || err != nil {
+					t.Fatalf("Consume = %t, %v", ok, err)
+				}
+			},
+			wantErr: invitation.ErrInvalid,
+		},
+		{
+			// Token válido de una invitación que ya no está en la base
+			name:    "unknown invitation",
+			as:      invited,
+			forget:  true,
+			wantErr: invitation.ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setup(t)
+			ttl := tt.ttl
+			if ttl == 0 {
+				ttl = invitation.DefaultTTL
+			}
+			token, inv, err := invitation.Create(ctx, repo, uuid.New(), authz.RoleAdmin, authz.RoleOperador, tt.email, ttl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.forget {
+				repo = repository.NewMemoryInvitationRepository()
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, repo, inv.ID)
+			}
+			if tt.token != "" {
+				token = tt.token
+			}
+
+			got, err := invitation.Validate(ctx, repo, token, tt.as)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.ID != inv.ID || got.Role != authz.RoleOperador) {
+				t.Errorf("Validate = %+v, want invitation %s", got, inv.ID)
+			}
+		})
+	}
+}
+
+func TestConsume(t *testing.T) {
+	ctx := context.Background()
+	repo := setup(t)
+	_, inv, err := invitation.Create(ctx, repo, uuid.New(), authz.RoleAdmin, authz.RoleOperador, nil, invitation.DefaultTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.New()
+	if err := invitation.Consume(ctx, repo, &inv, userID); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	stored, _ := repo.FindByID(ctx, inv.ID)
+	if stored.UsedAt == nil || stored.UsedByID == nil || *stored.UsedByID != userID {
+		t.Errorf("stored invitation = %+v, want used by %s", stored, userID)
+	}
+	// Un solo uso
+	if err := invitation.Consume(ctx, repo, &inv, uuid.New()); !errors.Is(err, invitation.ErrInvalid) {
+		t.Errorf("second Consume = %v, want ErrInvalid", err)
+	}
+}
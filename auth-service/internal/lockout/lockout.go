@@ -0,0 +1,154 @@
+// Package lockout protege el login por contraseña contra fuerza bruta:
+// retardos progresivos y bloqueo temporal por cuenta, y límite de fallos por IP.
+package lockout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Policy define los umbrales de bloqueo
+type Policy struct {
+	// Threshold es el número de fallos consecutivos que bloquea la cuenta
+	Threshold    int
+	LockDuration time.Duration
+
+	// Tras cada fallo el siguiente intento debe esperar BaseDelay * 2^(fallos-1), hasta MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// IPFailuresHourly limita los logins fallidos por IP (cualquier cuenta)
+	IPFailuresHourly int
+}
+
+// DefaultPolicy son los valores por defecto
+var DefaultPolicy = Policy{
+	Threshold:        5,
+	LockDuration:     15 * time.Minute,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	IPFailuresHourly: 30,
+}
+
+var (
+	ErrAccountLocked = errors.New("account temporarily locked")
+	ErrTooSoon       = errors.New("retry after delay")
+)
+
+var (
+	policy     = DefaultPolicy
+	ipFailures = ratelimit.New(time.Hour)
+)
+
+// Init fija la política a partir de la configuración (LOGIN_LOCK_THRESHOLD,
+// LOGIN_LOCK_DURATION, LOGIN_DELAY_BASE, LOGIN_DELAY_MAX y LOGIN_IP_FAILURES_HOURLY)
+func Init(c config.Lockout) {
+	SetPolicy(Policy{
+		Threshold:        c.Threshold,
+		LockDuration:     c.LockDuration,
+		BaseDelay:        c.BaseDelay,
+		MaxDelay:         c.MaxDelay,
+		IPFailuresHourly: c.IPFailuresHourly,
+	})
+}
+
+// SetPolicy reemplaza la política en uso
+func SetPolicy(p Policy) {
+	policy = p
+}
+
+// CheckIP devuelve cuánto debe esperar ip antes de volver a intentar (0 si puede)
+func CheckIP(ip string) time.Duration {
+	return ipFailures.Check(ip, ratelimit.Rule{Limit: policy.IPFailuresHourly, Window: time.Hour})
+}
+
+// RecordIPFailure cuenta un login fallido desde ip (p. ej. email inexistente)
+func RecordIPFailure(ip string) {
+	ipFailures.Record(ip)
+}
+
+// CheckAccount indica si la cuenta puede intentar el login ahora. Devuelve
+// ErrAccountLocked o ErrTooSoon junto con el tiempo de espera.
+func CheckAccount(user *models.User) (time.Duration, error) {
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		return user.LockedUntil.Sub(now), ErrAccountLocked
+	}
+	if user.FailedLoginAttempts > 0 && user.LastFailedLoginAt != nil {
+		next := user.LastFailedLoginAt.Add(delayFor(user.FailedLoginAttempts))
+		if next.After(now) {
+			return next.Sub(now), ErrTooSoon
+		}
+	}
+	return 0, nil
+}
+
+// Failed registra una contraseña incorrecta. Al alcanzar el umbral bloquea la
+// cuenta durante LockDuration y guarda el evento.
+func Failed(ctx context.Context, repo repository.LockoutRepository, user *models.User, ip string) error {
+	RecordIPFailure(ip)
+
+	// El contador se incrementa en la base de datos y no a partir de
+	// user.FailedLoginAttempts: con la lectura previa, logins fallidos
+	// simultáneos se pisarían y nunca alcanzarían el umbral
+	now := time.Now()
+	attempts, err := repo.RecordFailure(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	user.FailedLoginAttempts = attempts
+	user.LastFailedLoginAt = &now
+	if attempts < policy.Threshold {
+		return nil
+	}
+
+	// Tras el bloqueo se empieza de nuevo a contar
+	until := now.Add(policy.LockDuration)
+	slog.WarnContext(ctx, "account locked", "user_id", user.ID, "until", until.Format(time.RFC3339), "failed_logins", attempts)
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = &until
+	return repo.Lock(ctx, &models.AccountLockEvent{
+		UserID:         user.ID,
+		Event:          models.LockEventLocked,
+		IP:             ip,
+		FailedAttempts: attempts,
+		LockedUntil:    &until,
+	})
+}
+
+// Succeeded limpia el contador de fallos tras un login correcto
+func Succeeded(ctx context.Context, repo repository.LockoutRepository, user *models.User) error {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+	return repo.Reset(ctx, user.ID)
+}
+
+// Unlock desbloquea la cuenta a pedido de un admin y registra el evento.
+// Devuelve repository.ErrNotFound si el usuario no existe.
+func Unlock(ctx context.Context, repo repository.LockoutRepository, userID, adminID uuid.UUID) error {
+	return repo.Unlock(ctx, &models.AccountLockEvent{
+		UserID:  userID,
+		Event:   models.LockEventUnlocked,
+		ActorID: &adminID,
+	})
+}
+
+func delayFor(failures int) time.Duration {
+	d := policy.BaseDelay
+	for i := 1; i < failures && d < policy.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > policy.MaxDelay {
+		d = policy.MaxDelay
+	}
+	return d
+}
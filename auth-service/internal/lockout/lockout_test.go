@@ -0,0 +1,210 @@
+package lockout_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/lockout"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var testPolicy = lockout.Policy{
+	Threshold:        3,
+	LockDuration:     15 * time.Minute,
+	BaseDelay:        time.Second,
+	MaxDelay:         8 * time.Second,
+	IPFailuresHourly: 5,
+}
+
+func setup(t *testing.T) (repository.Repositories, *models.User) {
+	t.Helper()
+	lockout.SetPolicy(testPolicy)
+	t.Cleanup(func() { lockout.SetPolicy(lockout.DefaultPolicy) })
+
+	repos := repository.NewMemory()
+	email := "staff@example.com"
+	user := &models.User{Email: &email, Role: "operador"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return repos, user
+}
+
+func TestCheckAccount(t *testing.T) {
+	ago := func(d time.Duration) *time.Time { at := time.Now().Add(-d); return &at }
+	in := func(d time.Duration) *time.Time { at := time.Now().Add(d); return &at }
+
+	tests := []struct {
+		name        string
+		failures    int
+		lastFailure *time.Time
+		lockedUntil *time.Time
+		wantErr     error
+		wantWait    time.Duration
+	}{
+		{name: "no failures"},
+		{name: "first failure waits the base delay", failures: 1, lastFailure: ago(0), wantErr: lockout.ErrTooSoon, wantWait: time.Second},
+		{name: "delay doubles per failure", failures: 3, lastFailure: ago(0), wantErr: lockout.ErrTooSoon, wantWait: 4 * time.Second},
+		{name: "delay is capped", failures: 10, lastFailure: ago(0), wantErr: lockout.ErrTooSoon, wantWait: 8 * time.Second},
+		{name: "delay already elapsed", failures: 3, lastFailure: ago(5 * time.Second)},
+		{name: "partially elapsed delay", failures: 3, lastFailure: ago(3 * time.Second), wantErr: lockout.ErrTooSoon, wantWait: time.Second},
+		{name: "locked", lockedUntil: in(10 * time.Minute), wantErr: lockout.ErrAccountLocked, wantWait: 10 * time.Minute},
+		{name: "expired lock", lockedUntil: ago(time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lockout.SetPolicy(testPolicy)
+			t.Cleanup(func() { lockout.SetPolicy(lockout.DefaultPolicy) })
+
+			wait, err := lockout.CheckAccount(&models.User{
+				FailedLoginAttempts: tt.failures,
+				LastFailedLoginAt:   tt.lastFailure,
+				LockedUntil:         tt.lockedUntil,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			// Margen por el tiempo transcurrido desde que se armó el caso
+			if wait > tt.wantWait || wait < tt.wantWait-time.Second/2 {
+				t.Errorf("wait = %s, want about %s", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantAttempts int
+		wantLocked   bool
+	}{
+		{name: "below threshold", failures: 2, wantAttempts: 2},
+		{name: "threshold locks and restarts the count", failures: 3, wantAttempts: 0, wantLocked: true},
+		{name: "counting resumes after the lock", failures: 4, wantAttempts: 1, wantLocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user := setup(t)
+
+			for i := 0; i < tt.failures; i++ {
+				if err := lockout.Failed(ctx, repos.Lockout, user, "192.0.2.1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stored, _ := repos.Users.FindByID(ctx, user.ID)
+			if stored.FailedLoginAttempts != tt.wantAttempts {
+				t.Errorf("failed attempts = %d, want %d", stored.FailedLoginAttempts, tt.wantAttempts)
+			}
+			locked := stored.LockedUntil != nil && stored.LockedUntil.After(time.Now())
+			if locked != tt.wantLocked {
+				t.Fatalf("locked = %t, want %t", locked, tt.wantLocked)
+			}
+			if locked && stored.LockedUntil.After(time.Now().Add(testPolicy.LockDuration)) {
+				t.Errorf("locked until %s, longer than the lock duration", stored.LockedUntil)
+			}
+		})
+	}
+}
+
+// Los fallos simultáneos se cuentan en el repositorio, así que no se pisan y
+// alcanzan el umbral
+func TestFailedConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repos, user := setup(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < testPolicy.Threshold; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Cada petición trae su propia copia del usuario, leída antes de fallar
+			stale := *user
+			if err := lockout.Failed(ctx, repos.Lockout, &stale, "192.0.2.2"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, _ := repos.Users.FindByID(ctx, user.ID)
+	if stored.LockedUntil == nil {
+		t.Fatalf("%d simultaneous failures did not lock the account", testPolicy.Threshold)
+	}
+}
+
+func TestSucceededAndUnlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		reset   func(ctx context.Context, repos repository.Repositories, user *models.User) error
+		wantErr error
+	}{
+		{
+			name: "successful login",
+			reset: func(ctx context.Context, repos repository.Repositories, user *models.User) error {
+				return lockout.Succeeded(ctx, repos.Lockout, user)
+			},
+		},
+		{
+			name: "admin unlock",
+			reset: func(ctx context.Context, repos repository.Repositories, user *models.User) error {
+				return lockout.Unlock(ctx, repos.Lockout, user.ID, uuid.New())
+			},
+		},
+		{
+			name: "admin unlock of an unknown user",
+			reset: func(ctx context.Context, repos repository.Repositories, _ *models.User) error {
+				return lockout.Unlock(ctx, repos.Lockout, uuid.New(), uuid.New())
+			},
+			wantErr: repository.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user := setup(t)
+			for i := 0; i < testPolicy.Threshold; i++ {
+				if err := lockout.Failed(ctx, repos.Lockout, user, "192.0.2.3"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.reset(ctx, repos, user); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			stored, _ := repos.Users.FindByID(ctx, user.ID)
+			if stored.LockedUntil != nil || stored.FailedLoginAttempts != 0 || stored.LastFailedLoginAt != nil {
+				t.Errorf("lockout state not cleared: %+v", stored)
+			}
+		})
+	}
+}
+
+func TestCheckIP(t *testing.T) {
+	_, _ = setup(t)
+	// El contador por IP es global: una IP propia por ejecución
+	ip := uuid.NewString()
+
+	for i := 0; i < testPolicy.IPFailuresHourly; i++ {
+		if wait := lockout.CheckIP(ip); wait != 0 {
+			t.Fatalf("blocked after %d failures, limit is %d", i, testPolicy.IPFailuresHourly)
+		}
+		lockout.RecordIPFailure(ip)
+	}
+	if wait := lockout.CheckIP(ip); wait <= 0 || wait > time.Hour {
+		t.Errorf("wait = %s after reaching the hourly limit, want (0, 1h]", wait)
+	}
+	if wait := lockout.CheckIP(uuid.NewString()); wait != 0 {
+		t.Errorf("another IP waits %s", wait)
+	}
+}
@@ -0,0 +1,59 @@
+// Package mail envía los correos del servicio (recuperación de contraseña).
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+)
+
+// Mailer entrega un correo de texto plano
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+var (
+	mu     sync.RWMutex
+	mailer Mailer
+)
+
+// InitMailer configura el proveedor según c.Provider (MAIL_PROVIDER):
+//   - "smtp":   servidor SMTP (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM)
+//   - "memory": guarda los correos en memoria, solo APP_ENV=development
+//
+// config.Config.Validate ya comprobó los valores.
+func InitMailer(c config.Mail) {
+	switch c.Provider {
+	case "smtp":
+		SetMailer(NewSMTPMailer(c.Host, strconv.Itoa(c.Port), c.Username, c.Password, c.From))
+	case "memory":
+		slog.Warn("MAIL_PROVIDER not set to smtp, emails are kept in memory and not delivered")
+		SetMailer(NewMemoryMailer())
+	default:
+		log.Fatalf("unknown MAIL_PROVIDER %q", c.Provider)
+	}
+	slog.Info("mail provider configured", "provider", c.Provider)
+}
+
+// SetMailer reemplaza el proveedor en uso
+func SetMailer(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	mailer = m
+}
+
+// Send entrega el correo con el proveedor configurado
+func Send(ctx context.Context, to, subject, body string) error {
+	mu.RLock()
+	m := mailer
+	mu.RUnlock()
+	if m == nil {
+		return fmt.Errorf("mail: no mailer configured")
+	}
+	return m.Send(ctx, to, subject, body)
+}
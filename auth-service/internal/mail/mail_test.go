@@ -0,0 +1,167 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mail"
+)
+
+// fakeSMTP atiende una sesión SMTP sin autenticación y devuelve por el canal
+// los datos del mensaje recibido
+func fakeSMTP(t *testing.T) (host, port string, data <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 end with .")
+				var msg strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					msg.WriteString(l)
+				}
+				out <- msg.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, out
+}
+
+func TestSMTPMailer(t *testing.T) {
+	host, port, data := fakeSMTP(t)
+	m := mail.NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+
+	body := "Hola,\r\n\r\nsu código es abc123"
+	if err := m.Send(context.Background(), "admin@example.com", "Recuperación de contraseña", body); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msg := <-data
+	for _, want := range []string{
+		"From: no-reply@example.com\r\n",
+		"To: admin@example.com\r\n",
+		"Subject: Recuperación de contraseña\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\n" + body,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	m := mail.NewSMTPMailer("127.0.0.1", "1", "", "", "no-reply@example.com")
+	tests := []struct{ to, subject string }{
+		{"admin@example.com\r\nBcc: attacker@example.com", "Hola"},
+		{"admin@example.com", "Hola\nBcc: attacker@example.com"},
+	}
+	for _, tt := range tests {
+		err := m.Send(context.Background(), tt.to, tt.subject, "body")
+		if err == nil || !strings.Contains(err.Error(), "invalid header") {
+			t.Errorf("Send(%q, %q) = %v, want an invalid header error", tt.to, tt.subject, err)
+		}
+	}
+}
+
+func TestSMTPMailerContext(t *testing.T) {
+	// Un servidor que acepta la conexión y no responde
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = mail.NewSMTPMailer(host, port, "", "", "no-reply@example.com").Send(ctx, "admin@example.com", "Hola", "body")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send to a hung server = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	ctx := context.Background()
+	m := mail.NewMemoryMailer()
+	for _, to := range []string{"a@example.com", "b@example.com", "a@example.com"} {
+		if err := m.Send(ctx, to, "asunto", "para "+to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last, ok := m.Last("b@example.com"); !ok || last.Body != "para b@example.com" || last.SentAt.IsZero() {
+		t.Errorf("Last = %+v, %t", last, ok)
+	}
+	if _, ok := m.Last("c@example.com"); ok {
+		t.Error("Last found a message for an unknown address")
+	}
+
+	m.Err = errors.New("provider down")
+	if err := m.Send(ctx, "a@example.com", "asunto", "x"); !errors.Is(err, m.Err) {
+		t.Errorf("Send with Err set = %v, want %v", err, m.Err)
+	}
+	if n := len(m.Messages()); n != 3 {
+		t.Errorf("%d messages stored, want 3", n)
+	}
+	m.Reset()
+	if n := len(m.Messages()); n != 0 {
+		t.Errorf("%d messages after Reset", n)
+	}
+}
+
+func TestSend(t *testing.T) {
+	mail.SetMailer(nil)
+	if err := mail.Send(context.Background(), "a@example.com", "asunto", "body"); err == nil {
+		t.Fatal("Send without a mailer returned nil")
+	}
+
+	m := mail.NewMemoryMailer()
+	mail.SetMailer(m)
+	t.Cleanup(func() { mail.SetMailer(nil) })
+	if err := mail.Send(context.Background(), "a@example.com", "asunto", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Last("a@example.com"); !ok {
+		t.Error("message not delivered through the configured mailer")
+	}
+}
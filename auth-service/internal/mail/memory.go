@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message es un correo guardado por MemoryMailer
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	SentAt  time.Time
+}
+
+// MemoryMailer guarda los correos en memoria; Err permite simular fallas del proveedor
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+// NewMemoryMailer crea un MemoryMailer vacío
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, Message{To: to, Subject: subject, Body: body, SentAt: time.Now()})
+	return nil
+}
+
+// Messages devuelve una copia de los correos enviados
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Last devuelve el último correo enviado a to
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset borra los correos guardados
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
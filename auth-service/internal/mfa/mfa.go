@@ -0,0 +1,218 @@
+// Package mfa implementa el segundo factor TOTP para cuentas con contraseña
+// (operador, admin): enrolamiento, códigos de recuperación y política por rol.
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrNotEnrolled     = errors.New("totp not enrolled")
+	ErrAlreadyEnabled  = errors.New("totp already enabled")
+	ErrInvalidCode     = errors.New("invalid verification code")
+	ErrTooManyAttempts = errors.New("too many failed verification attempts")
+	ErrRequiredByRole  = errors.New("two-factor authentication is required for this role")
+)
+
+// Como mucho 5 códigos incorrectos por cuenta cada 5 minutos
+var failures = ratelimit.New(5 * time.Minute)
+
+// issuer es el nombre que muestran las apps autenticadoras (MFA_ISSUER)
+var issuer = "Latacunga Limpia"
+
+// SetIssuer reemplaza el nombre del emisor de los códigos TOTP
+func SetIssuer(name string) {
+	issuer = name
+}
+
+// BeginEnrollment genera un secreto pendiente de confirmación y devuelve el
+// secreto y la URI de aprovisionamiento para el código QR
+func BeginEnrollment(ctx context.Context, repo repository.MFARepository, user *models.User) (string, string, error) {
+	if user.TOTPEnabled {
+		return "", "", ErrAlreadyEnabled
+	}
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := repo.SetSecret(ctx, user.ID, secret); err != nil {
+		return "", "", err
+	}
+	user.TOTPSecret = &secret
+	user.TOTPLastStep = 0
+
+	account := user.ID.String()
+	if user.Email != nil {
+		account = *user.Email
+	}
+	return secret, ProvisioningURI(issuer, account, secret), nil
+}
+
+// ConfirmEnrollment activa TOTP si code corresponde al secreto pendiente y
+// devuelve los códigos de recuperación (solo se muestran esta vez)
+func ConfirmEnrollment(ctx context.Context, repo repository.MFARepository, user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrNotEnrolled
+	}
+	step, err := checkTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = recoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashCode(codes[i])
+	}
+	if err := repo.Enable(ctx, user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	return codes, nil
+}
+
+// Verify acepta un código TOTP o un código de recuperación (que queda usado).
+// Cada paso TOTP sirve una sola vez, también entre peticiones simultáneas.
+func Verify(ctx context.Context, repo repository.MFARepository, user *models.User, code string) error {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return ErrNotEnrolled
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		step, err := checkTOTP(user, code)
+		if err != nil {
+			return err
+		}
+		advanced, err := repo.AdvanceStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			failures.Record(user.ID.String())
+			return ErrInvalidCode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+	return useRecoveryCode(ctx, repo, user, code)
+}
+
+// Disable desactiva TOTP tras verificar un código. No se permite si el rol del usuario lo exige.
+func Disable(ctx context.Context, repo repository.MFARepository, user *models.User, code string) error {
+	required, err := RoleRequiresMFA(ctx, repo, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequiredByRole
+	}
+	if err := Verify(ctx, repo, user, code); err != nil {
+		return err
+	}
+	if err := repo.Disable(ctx, user.ID); err != nil {
+		return err
+	}
+	user.TOTPEnabled = false
+	user.TOTPSecret = nil
+	user.TOTPLastStep = 0
+	return nil
+}
+
+// RoleRequiresMFA indica si los admins exigieron 2FA para el rol
+func RoleRequiresMFA(ctx context.Context, repo repository.MFARepository, role string) (bool, error) {
+	policy, err := repo.RolePolicy(ctx, strings.ToLower(role))
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return policy.Required, nil
+}
+
+// SetRoleRequirement exige (o deja de exigir) 2FA para las cuentas de role
+func SetRoleRequirement(ctx context.Context, repo repository.MFARepository, role string, required bool, adminID uuid.UUID) (models.MFARolePolicy, error) {
+	policy := models.MFARolePolicy{Role: strings.ToLower(role), Required: required, UpdatedByID: &adminID}
+	err := repo.SaveRolePolicy(ctx, &policy)
+	return policy, err
+}
+
+// ListRolePolicies devuelve la política de 2FA configurada por rol
+func ListRolePolicies(ctx context.Context, repo repository.MFARepository) ([]models.MFARolePolicy, error) {
+	return repo.ListRolePolicies(ctx)
+}
+
+func checkTOTP(user *models.User, code string) (int64, error) {
+	key := user.ID.String()
+	if failures.Check(key, ratelimit.Rule{Limit: 5, Window: 5 * time.Minute}) > 0 {
+		return 0, ErrTooManyAttempts
+	}
+	step, ok := ValidateTOTP(*user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		failures.Record(key)
+		return 0, ErrInvalidCode
+	}
+	return step, nil
+}
+
+func useRecoveryCode(ctx context.Context, repo repository.MFARepository, user *models.User, code string) error {
+	key := user.ID.String()
+	if failures.Check(key, ratelimit.Rule{Limit: 5, Window: 5 * time.Minute}) > 0 {
+		return ErrTooManyAttempts
+	}
+	used, err := repo.UseRecoveryCode(ctx, user.ID, hashCode(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		failures.Record(key)
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// recoveryCode genera un código como "k7q2m-9xw4p" (alfabeto sin caracteres ambiguos)
+func recoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, " ", ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
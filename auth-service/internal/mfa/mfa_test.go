@@ -0,0 +1,248 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// newUser guarda un operador en repositorios en memoria
+func newUser(t *testing.T) (repository.Repositories, *models.User) {
+	t.Helper()
+	repos := repository.NewMemory()
+	email := "staff@example.com"
+	hash := "hash"
+	user := &models.User{Email: &email, PasswordHash: &hash, Role: "operador"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return repos, user
+}
+
+// codeAt es el código TOTP del secreto offset pasos después del vigente
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	key, err := b32.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, time.Now().Unix()/totpPeriod+offset)
+}
+
+func currentCode(t *testing.T, secret string) string { return codeAt(t, secret, 0) }
+
+// enroll activa TOTP para el usuario y devuelve sus códigos de recuperación.
+// Confirma con el código del paso anterior (aceptado por el desfase) para
+// que el vigente quede libre, como en un login posterior.
+func enroll(t *testing.T, repo repository.MFARepository, user *models.User) []string {
+	t.Helper()
+	ctx := context.Background()
+	secret, _, err := BeginEnrollment(ctx, repo, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := ConfirmEnrollment(ctx, repo, user, codeAt(t, secret, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codes
+}
+
+func TestEnrollment(t *testing.T) {
+	ctx := context.Background()
+	repos, user := newUser(t)
+
+	secret, uri, err := BeginEnrollment(ctx, repos.MFA, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("provisioning uri %s does not carry the secret", uri)
+	}
+	if _, err := ConfirmEnrollment(ctx, repos.MFA, user, wrong(currentCode(t, secret))); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("confirm with a wrong code: err = %v, want ErrInvalidCode", err)
+	}
+
+	codes, err := ConfirmEnrollment(ctx, repos.MFA, user, currentCode(t, secret))
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("%d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' || seen[c] {
+			t.Errorf("recovery code %q is malformed or repeated", c)
+		}
+		seen[c] = true
+	}
+
+	stored, _ := repos.Users.FindByID(ctx, user.ID)
+	if !stored.TOTPEnabled || stored.TOTPLastStep == 0 {
+		t.Errorf("stored user = enabled %t, last step %d; want enabled with the confirmed step", stored.TOTPEnabled, stored.TOTPLastStep)
+	}
+	if _, _, err := BeginEnrollment(ctx, repos.MFA, stored); !errors.Is(err, ErrAlreadyEnabled) {
+		t.Errorf("second enrollment err = %v, want ErrAlreadyEnabled", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// code devuelve el código a presentar a partir del secreto y los
+		// códigos de recuperación
+		code    func(t *testing.T, secret string, recovery []string) string
+		wantErr error
+	}{
+		{name: "current TOTP code", code: func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) }},
+		{name: "TOTP code with spaces", code: func(t *testing.T, secret string, _ []string) string { return " " + currentCode(t, secret) + " " }},
+		{name: "wrong TOTP code", code: func(t *testing.T, secret string, _ []string) string { return wrong(currentCode(t, secret)) }, wantErr: ErrInvalidCode},
+		{name: "recovery code", code: func(_ *testing.T, _ string, recovery []string) string { return recovery[0] }},
+		{name: "recovery code without dash, uppercase", code: func(_ *testing.T, _ string, recovery []string) string {
+			return strings.ToUpper(strings.ReplaceAll(recovery[1], "-", ""))
+		}},
+		{name: "unknown recovery code", code: func(*testing.T, string, []string) string { return "aaaaa-bbbbb" }, wantErr: ErrInvalidCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user := newUser(t)
+			recovery := enroll(t, repos.MFA, user)
+
+			err := Verify(ctx, repos.MFA, user, tt.code(t, *user.TOTPSecret, recovery))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	tests := []struct {
+		name string
+		// first y second devuelven los dos códigos presentados
+		first, second func(t *testing.T, secret string, recovery []string) string
+		// stale indica que la segunda verificación usa una copia del usuario
+		// leída antes de la primera (peticiones simultáneas)
+		stale bool
+	}{
+		{
+			name:   "same TOTP code",
+			first:  func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) },
+			second: func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) },
+		},
+		{
+			name:   "same TOTP code from a stale request",
+			first:  func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) },
+			second: func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) },
+			stale:  true,
+		},
+		{
+			name:   "earlier step after a later one",
+			first:  func(t *testing.T, secret string, _ []string) string { return codeAt(t, secret, 1) },
+			second: func(t *testing.T, secret string, _ []string) string { return currentCode(t, secret) },
+			stale:  true,
+		},
+		{
+			name:   "same recovery code",
+			first:  func(_ *testing.T, _ string, recovery []string) string { return recovery[0] },
+			second: func(_ *testing.T, _ string, recovery []string) string { return recovery[0] },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user := newUser(t)
+			recovery := enroll(t, repos.MFA, user)
+			secret := *user.TOTPSecret
+			stale := *user
+
+			if err := Verify(ctx, repos.MFA, user, tt.first(t, secret, recovery)); err != nil {
+				t.Fatalf("first use: %v", err)
+			}
+			second := user
+			if tt.stale {
+				second = &stale
+			}
+			if err := Verify(ctx, repos.MFA, second, tt.second(t, secret, recovery)); !errors.Is(err, ErrInvalidCode) {
+				t.Errorf("second use err = %v, want ErrInvalidCode", err)
+			}
+		})
+	}
+}
+
+func TestVerifyTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	repos, user := newUser(t)
+	enroll(t, repos.MFA, user)
+	secret := *user.TOTPSecret
+
+	for i := 0; i < 5; i++ {
+		if err := Verify(ctx, repos.MFA, user, wrong(currentCode(t, secret))); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	// Ni el código correcto ni uno de recuperación pasan mientras dura el límite
+	if err := Verify(ctx, repos.MFA, user, currentCode(t, secret)); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("err = %v, want ErrTooManyAttempts", err)
+	}
+	if err := Verify(ctx, repos.MFA, user, "aaaaa-bbbbb"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("recovery err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		code     func(t *testing.T, secret string) string
+		wantErr  error
+	}{
+		{name: "valid code", code: func(t *testing.T, secret string) string { return currentCode(t, secret) }},
+		{name: "wrong code", code: func(t *testing.T, secret string) string { return wrong(currentCode(t, secret)) }, wantErr: ErrInvalidCode},
+		{name: "required by role", required: true, code: func(t *testing.T, secret string) string { return currentCode(t, secret) }, wantErr: ErrRequiredByRole},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos, user := newUser(t)
+			enroll(t, repos.MFA, user)
+			if _, err := SetRoleRequirement(ctx, repos.MFA, "Operador", tt.required, uuid.New()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Disable(ctx, repos.MFA, user, tt.code(t, *user.TOTPSecret))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := repos.Users.FindByID(ctx, user.ID)
+			if stored.TOTPEnabled != (tt.wantErr != nil) {
+				t.Errorf("enabled = %t after disable err %v", stored.TOTPEnabled, err)
+			}
+			if tt.wantErr == nil && stored.TOTPSecret != nil {
+				t.Error("secret kept after disabling")
+			}
+		})
+	}
+}
+
+func TestVerifyNotEnrolled(t *testing.T) {
+	repos, user := newUser(t)
+	if err := Verify(context.Background(), repos.MFA, user, "123456"); !errors.Is(err, ErrNotEnrolled) {
+		t.Errorf("err = %v, want ErrNotEnrolled", err)
+	}
+}
+
+// wrong devuelve un código de 6 dígitos distinto de code
+func wrong(code string) string {
+	b := []byte(code)
+	b[0] = '0' + (b[0]-'0'+1)%10
+	return string(b)
+}
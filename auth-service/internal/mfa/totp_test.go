@@ -0,0 +1,64 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secreto ASCII "12345678901234567890" de los vectores SHA1 del RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	at := func(unix int64) time.Time { return time.Unix(unix, 0) }
+	// 1111111109 cae en el paso 37037036
+	const step = 37037036
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// Los vectores del RFC son de 8 dígitos; los 6 últimos son el código de 6
+		{name: "RFC vector T=59", secret: rfcSecret, code: "287082", now: at(59), wantStep: 1, wantOK: true},
+		{name: "RFC vector T=1111111109", secret: rfcSecret, code: "081804", now: at(1111111109), wantStep: step, wantOK: true},
+		{name: "RFC vector T=1234567890", secret: rfcSecret, code: "005924", now: at(1234567890), wantStep: 41152263, wantOK: true},
+		{name: "RFC vector T=2000000000", secret: rfcSecret, code: "279037", now: at(2000000000), wantStep: 66666666, wantOK: true},
+		{name: "lowercase secret", secret: strings.ToLower(rfcSecret), code: "081804", now: at(1111111109), wantStep: step, wantOK: true},
+		{name: "previous step within skew", secret: rfcSecret, code: "081804", now: at(1111111109 + totpPeriod), wantStep: step, wantOK: true},
+		{name: "next step within skew", secret: rfcSecret, code: "081804", now: at(1111111109 - totpPeriod), wantStep: step, wantOK: true},
+		{name: "two steps late", secret: rfcSecret, code: "081804", now: at(1111111109 + 2*totpPeriod)},
+		{name: "replayed step", secret: rfcSecret, code: "081804", now: at(1111111109), lastStep: step},
+		{name: "later step already used", secret: rfcSecret, code: "081804", now: at(1111111109), lastStep: step + 1},
+		{name: "step after the last used", secret: rfcSecret, code: "081804", now: at(1111111109), lastStep: step - 1, wantStep: step, wantOK: true},
+		{name: "wrong code", secret: rfcSecret, code: "081805", now: at(1111111109)},
+		{name: "8 digits", secret: rfcSecret, code: "07081804", now: at(1111111109)},
+		{name: "invalid secret", secret: "not base32!", code: "081804", now: at(1111111109)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, tt.now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %t), want (%d, %t)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Latacunga Limpia", "staff@example.com", rfcSecret)
+
+	want := "otpauth://totp/Latacunga%20Limpia:staff@example.com?"
+	if !strings.HasPrefix(uri, want) {
+		t.Errorf("uri = %s, want prefix %s", uri, want)
+	}
+	for _, param := range []string{"secret=" + rfcSecret, "issuer=Latacunga+Limpia", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("uri %s lacks %s", uri, param)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent is an append-only record of a security-relevant action.
+// Target is whatever the action was about: a user ID, or the email/phone
+// used when no account matched.
+type AuditEvent struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid;index"`
+	Action    string     `json:"action" gorm:"not null;index"`
+	Target    string     `json:"target,omitempty" gorm:"index"`
+	Outcome   string     `json:"outcome" gorm:"not null"`
+	Reason    string     `json:"reason,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
@@ -6,6 +6,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// User account statuses; only ACTIVE accounts may authenticate. A suspension
+// may carry an expiry (SuspendedUntil), a ban is permanent until lifted by an admin.
+const (
+	UserStatusActive    = "ACTIVE"
+	UserStatusSuspended = "SUSPENDED"
+	UserStatusBanned    = "BANNED"
+)
+
 // User represents a user in the system
 type User struct {
 	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -14,9 +22,25 @@ type User struct {
 	PasswordHash *string   `json:"-" gorm:"size:128"`
 	Role         string    `json:"role" gorm:"not null"`
 	DisplayName  string    `json:"display_name"`
-	Status       string    `json:"status" gorm:"default:ACTIVE"`
+	Status       string    `json:"status" gorm:"default:ACTIVE;index"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// End of a temporary suspension; nil means indefinite
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+
+	// Admin who registered the account or issued its invitation
+	CreatedByID *uuid.UUID `json:"created_by_id,omitempty" gorm:"type:uuid"`
+
+	// TOTP second factor; the secret is set at enrollment and enabled once confirmed
+	TOTPSecret   *string `json:"-"`
+	TOTPEnabled  bool    `json:"totp_enabled" gorm:"default:false"`
+	TOTPLastStep int64   `json:"-" gorm:"default:0"`
+
+	// Password login lockout
+	FailedLoginAttempts int        `json:"-" gorm:"default:0"`
+	LastFailedLoginAt   *time.Time `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
 }
 
 // OTPCode represents OTP codes for phone authentication
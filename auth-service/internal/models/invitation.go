@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation allows registering an operador/admin account without an admin
+// session. The invitation token is a signed JWT whose jti is the invitation ID.
+type Invitation struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	Email       *string    `json:"email,omitempty"`
+	Role        string     `json:"role" gorm:"not null"`
+	CreatedByID uuid.UUID  `json:"created_by_id" gorm:"type:uuid;not null;index"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	UsedByID    *uuid.UUID `json:"used_by_id,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Account lock event types
+const (
+	LockEventLocked   = "LOCKED"
+	LockEventUnlocked = "UNLOCKED"
+)
+
+// AccountLockEvent records every time an account is locked after failed
+// password logins or unlocked by an admin
+type AccountLockEvent struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Event          string     `json:"event" gorm:"not null"`
+	IP             string     `json:"ip,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	ActorID        *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFARecoveryCode is a one-time code to pass the second factor without the
+// authenticator app. Only the SHA-256 hash is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// MFARolePolicy marks roles whose accounts must use two-factor authentication
+type MFARolePolicy struct {
+	Role        string     `json:"role" gorm:"primary_key"`
+	Required    bool       `json:"required" gorm:"not null;default:false"`
+	UpdatedByID *uuid.UUID `json:"updated_by_id,omitempty" gorm:"type:uuid"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// OTP rate-limit event kinds
+const (
+	OTPLimitSend         = "send"
+	OTPLimitVerifyFailed = "verify_failed"
+	OTPLimitFailedRound  = "failed_round"
+)
+
+// OTPLimitEvent is one OTP send or failed verification counted against a
+// phone or IP. Key is "phone:<E.164>" or "ip:<address>".
+type OTPLimitEvent struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"not null"`
+	Key       string    `json:"key" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+// OTPLock blocks sending and verifying OTP codes for a phone until LockedUntil
+type OTPLock struct {
+	Key         string    `json:"key" gorm:"primaryKey"`
+	LockedUntil time.Time `json:"locked_until" gorm:"not null"`
+}
@@ -2,18 +2,43 @@ package models
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
-// RefreshToken represents a refresh token stored in the database
+// RefreshToken represents a refresh token stored in the database.
+// The ID is the token's jti claim; every token belongs to a family that
+// starts at login and is carried over each time the token is rotated.
 type RefreshToken struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	UserID    uint      `gorm:"not null" json:"user_id"`
-	Token     string    `gorm:"uniqueIndex;size:512;not null" json:"token"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
-	Revoked   bool      `gorm:"default:false" json:"revoked"`
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Revoked      bool       `gorm:"default:false" json:"revoked"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid" json:"replaced_by_id,omitempty"`
+
+	// Device metadata; SessionStartedAt is carried over on rotation
+	SessionStartedAt time.Time  `gorm:"not null;default:now()" json:"session_started_at"`
+	UserAgent        string     `gorm:"size:512" json:"user_agent,omitempty"`
+	IP               string     `gorm:"size:64" json:"ip,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// PasswordResetToken is a single-use password reset token. Only the SHA-256
+// hash of the token sent by email is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
 
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 // OTPRequest represents an OTP request for phone verification
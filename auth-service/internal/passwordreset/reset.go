@@ -0,0 +1,142 @@
+// Package passwordreset implementa la recuperación de contraseña para cuentas
+// con email (admin, operador): token de un solo uso enviado por correo.
+package passwordreset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mail"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenTTL es la vigencia de un token de recuperación
+const TokenTTL = 30 * time.Minute
+
+// ErrInvalidToken se devuelve si el token no existe, ya se usó o expiró
+var ErrInvalidToken = errors.New("reset token invalid or expired")
+
+// Como mucho 3 correos de recuperación por cuenta y hora
+var requests = ratelimit.New(time.Hour)
+
+// Request genera un token y lo envía al email si pertenece a una cuenta con
+// contraseña. Para no revelar qué emails existen, no informa si la cuenta no
+// existe ni si falló el envío del correo (solo lo registra en el log).
+func Request(ctx context.Context, users repository.UserRepository, resets repository.PasswordResetRepository, email string) error {
+	user, err := users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.PasswordHash == nil {
+		return nil
+	}
+	if wait := requests.Allow(user.ID.String(), ratelimit.Rule{Limit: 3, Window: time.Hour}); wait > 0 {
+		slog.WarnContext(ctx, "password reset rate limited", "user_id", user.ID)
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	reset := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(TokenTTL),
+	}
+	if err := resets.Create(ctx, &reset); err != nil {
+		return err
+	}
+
+	if err := mail.Send(ctx, email, "Recuperación de contraseña", resetBody(token)); err != nil {
+		slog.ErrorContext(ctx, "failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// Confirm cambia la contraseña usando el token, lo marca como usado e
+// invalida las sesiones abiertas (refresh tokens) del usuario. Los cambios
+// van en la transacción que lleve ctx.
+func Confirm(ctx context.Context, users repository.UserRepository, resets repository.PasswordResetRepository, token, newPassword string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	reset, err := resets.FindByHash(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	// Use es condicional: garantiza un solo uso aunque lleguen dos peticiones a la vez
+	now := time.Now()
+	used, err := resets.Use(ctx, reset.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidToken
+	}
+
+	// Los demás tokens pendientes del usuario dejan de servir
+	if err := resets.UseAll(ctx, reset.UserID, now); err != nil {
+		return err
+	}
+	if err := users.SetPassword(ctx, reset.UserID, string(hashed)); err != nil {
+		return err
+	}
+	return auth.RevokeUserTokens(ctx, reset.UserID)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// resetURL es la página del frontend que recibe el token (PASSWORD_RESET_URL)
+var resetURL string
+
+// SetResetURL fija la página a la que enlazan los correos; vacía envía solo el código
+func SetResetURL(url string) {
+	resetURL = url
+}
+
+// resetBody arma el correo; si hay resetURL incluye el enlace con el token
+func resetBody(token string) string {
+	instructions := fmt.Sprintf("Use el siguiente código para restablecer su contraseña: %s", token)
+	if base := resetURL; base != "" {
+		sep := "?"
+		if strings.Contains(base, "?") {
+			sep = "&"
+		}
+		instructions = fmt.Sprintf("Abra el siguiente enlace para restablecer su contraseña: %s%stoken=%s", base, sep, token)
+	}
+	return fmt.Sprintf("Hola,\n\nRecibimos una solicitud para restablecer su contraseña.\n\n%s\n\nEl enlace expira en %d minutos. Si no la solicitó, ignore este correo.\n",
+		instructions, int(TokenTTL.Minutes()))
+}
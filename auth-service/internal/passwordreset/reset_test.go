@@ -0,0 +1,187 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mail"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fixture struct {
+	repos  repository.Repositories
+	outbox *mail.MemoryMailer
+}
+
+func setup(t *testing.T) fixture {
+	t.Helper()
+	f := fixture{repos: repository.NewMemory(), outbox: mail.NewMemoryMailer()}
+	auth.InitKeys(config.JWT{AccessHours: 1, RefreshHours: 168}, true)
+	auth.InitRepositories(f.repos, nil)
+	mail.SetMailer(f.outbox)
+	SetResetURL("https://app.example.com/reset")
+	t.Cleanup(func() {
+		mail.SetMailer(nil)
+		SetResetURL("")
+	})
+	return f
+}
+
+// createUser guarda una cuenta con email y, si password no es vacía, contraseña
+func (f fixture) createUser(t *testing.T, email, password string) *models.User {
+	t.Helper()
+	user := &models.User{Email: &email, Role: "operador", DisplayName: email}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := string(hash)
+		user.PasswordHash = &h
+	}
+	if err := f.repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+var tokenRe = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// request pide la recuperación y devuelve el token del correo enviado
+func (f fixture) request(t *testing.T, email string) string {
+	t.Helper()
+	f.outbox.Reset()
+	if err := Request(context.Background(), f.repos.Users, f.repos.PasswordReset, email); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	msg, ok := f.outbox.Last(email)
+	if !ok {
+		return ""
+	}
+	m := tokenRe.FindStringSubmatch(msg.Body)
+	if m == nil {
+		t.Fatalf("no token in email body %q", msg.Body)
+	}
+	return m[1]
+}
+
+func TestRequest(t *testing.T) {
+	f := setup(t)
+	f.createUser(t, "staff@example.com", "old-password")
+	f.createUser(t, "citizen@example.com", "")
+
+	if token := f.request(t, "staff@example.com"); len(token) != 43 {
+		t.Errorf("token = %q, want 32 random bytes in base64url", token)
+	}
+	// Sin cuenta o sin contraseña no hay correo, pero tampoco error
+	for _, email := range []string{"nobody@example.com", "citizen@example.com"} {
+		if token := f.request(t, email); token != "" {
+			t.Errorf("Request(%s) sent an email", email)
+		}
+	}
+
+	// Una falla del proveedor no se informa al cliente
+	f.outbox.Err = errors.New("smtp down")
+	if err := Request(context.Background(), f.repos.Users, f.repos.PasswordReset, "staff@example.com"); err != nil {
+		t.Errorf("Request with a failing mailer = %v, want nil", err)
+	}
+}
+
+func TestRequestRateLimit(t *testing.T) {
+	f := setup(t)
+	f.createUser(t, "limited@example.com", "old-password")
+
+	for i := 0; i < 3; i++ {
+		if f.request(t, "limited@example.com") == "" {
+			t.Fatalf("request %d: no email sent", i+1)
+		}
+	}
+	if f.request(t, "limited@example.com") != "" {
+		t.Error("fourth request within the hour sent an email")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	user := f.createUser(t, "reset@example.com", "old-password")
+	if _, _, err := auth.IssueTokens(ctx, *user, auth.Device{}); err != nil {
+		t.Fatal(err)
+	}
+
+	older := f.request(t, "reset@example.com")
+	token := f.request(t, "reset@example.com")
+	if err := Confirm(ctx, f.repos.Users, f.repos.PasswordReset, token, "new-password"); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	stored, _ := f.repos.Users.FindByID(ctx, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(*stored.PasswordHash), []byte("new-password")) != nil {
+		t.Error("password not changed")
+	}
+	// Las sesiones abiertas se cierran
+	if active, _ := f.repos.RefreshTokens.ListActive(ctx, user.ID, time.Now()); len(active) != 0 {
+		t.Errorf("%d sessions still active after the reset", len(active))
+	}
+	// El token es de un solo uso y los anteriores pendientes dejan de servir
+	for name, tok := range map[string]string{"reused token": token, "older token": older} {
+		if err := Confirm(ctx, f.repos.Users, f.repos.PasswordReset, tok, "another-password"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Confirm = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestConfirmInvalidToken(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	user := f.createUser(t, "expired@example.com", "old-password")
+
+	expired := "expired-token"
+	if err := f.repos.PasswordReset.Create(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(expired),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{expired, "unknown-token", ""} {
+		if err := Confirm(ctx, f.repos.Users, f.repos.PasswordReset, token, "new-password"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Confirm(%q) = %v, want ErrInvalidToken", token, err)
+		}
+	}
+	stored, _ := f.repos.Users.FindByID(ctx, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(*stored.PasswordHash), []byte("old-password")) != nil {
+		t.Error("an invalid token changed the password")
+	}
+}
+
+func TestResetBody(t *testing.T) {
+	t.Cleanup(func() { SetResetURL("") })
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "", want: "restablecer su contraseña: abc123"},
+		{url: "https://app.example.com/reset", want: "https://app.example.com/reset?token=abc123"},
+		{url: "https://app.example.com/#/auth?step=reset", want: "https://app.example.com/#/auth?step=reset&token=abc123"},
+	}
+	for _, tt := range tests {
+		SetResetURL(tt.url)
+		body := resetBody("abc123")
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("resetBody with url %q = %q, want it to contain %q", tt.url, body, tt.want)
+		}
+		if !strings.Contains(body, "30 minutos") {
+			t.Errorf("resetBody does not state the expiry: %q", body)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package ratelimit implementa límites por ventana deslizante y bloqueos
+// temporales. El estado de Limiter está en memoria y es local a cada
+// instancia del servicio; OTPLimiter guarda el suyo en la base de datos.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Rule permite como máximo Limit eventos dentro de Window
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Limiter registra eventos por clave (teléfono, IP, cuenta) y bloqueos temporales
+type Limiter struct {
+	mu     sync.Mutex
+	events map[string][]time.Time
+	locks  map[string]time.Time
+	now    func() time.Time
+
+	// retention es la ventana más larga que se consulta; eventos más viejos se descartan
+	retention time.Duration
+}
+
+// New crea un Limiter que conserva eventos durante retention. Las claves
+// vencidas se limpian periódicamente en segundo plano.
+func New(retention time.Duration) *Limiter {
+	l := &Limiter{
+		events:    map[string][]time.Time{},
+		locks:     map[string]time.Time{},
+		now:       time.Now,
+		retention: retention,
+	}
+	go func() {
+		for range time.Tick(10 * time.Minute) {
+			l.Cleanup()
+		}
+	}()
+	return l
+}
+
+// Check devuelve cuánto hay que esperar para que key cumpla todas las reglas
+// (0 si el evento está permitido). No registra el evento.
+func (l *Limiter) Check(key string, rules ...Rule) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.check(key, rules)
+}
+
+// Allow registra el evento si key cumple todas las reglas. Devuelve el tiempo
+// de espera cuando no se permite.
+func (l *Limiter) Allow(key string, rules ...Rule) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if wait := l.check(key, rules); wait > 0 {
+		return wait
+	}
+	l.events[key] = append(l.events[key], l.now())
+	return 0
+}
+
+// Record registra un evento para key sin verificar reglas
+func (l *Limiter) Record(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(key)
+	l.events[key] = append(l.events[key], l.now())
+}
+
+// Count devuelve los eventos de key dentro de window
+func (l *Limiter) Count(key string, window time.Duration) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(key)
+	since := l.now().Add(-window)
+	n := 0
+	for _, t := range l.events[key] {
+		if t.After(since) {
+			n++
+		}
+	}
+	return n
+}
+
+// Last devuelve el momento del último evento de key
+func (l *Limiter) Last(key string) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(key)
+	ev := l.events[key]
+	if len(ev) == 0 {
+		return time.Time{}, false
+	}
+	return ev[len(ev)-1], true
+}
+
+// Reset borra los eventos y el bloqueo de key
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.events, key)
+	delete(l.locks, key)
+}
+
+// Lock bloquea key durante d
+func (l *Limiter) Lock(key string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locks[key] = l.now().Add(d)
+}
+
+// LockedFor devuelve cuánto falta para que expire el bloqueo de key (0 si no está bloqueada)
+func (l *Limiter) LockedFor(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lockedFor(key)
+}
+
+func (l *Limiter) lockedFor(key string) time.Duration {
+	until, ok := l.locks[key]
+	if !ok {
+		return 0
+	}
+	if wait := until.Sub(l.now()); wait > 0 {
+		return wait
+	}
+	delete(l.locks, key)
+	return 0
+}
+
+func (l *Limiter) check(key string, rules []Rule) time.Duration {
+	if wait := l.lockedFor(key); wait > 0 {
+		return wait
+	}
+	l.prune(key)
+	return waitFor(l.events[key], l.now(), rules...)
+}
+
+// waitFor devuelve cuánto hay que esperar para que events, ordenados del más
+// antiguo al más reciente, cumplan todas las reglas (0 si se permite otro)
+func waitFor(events []time.Time, now time.Time, rules ...Rule) time.Duration {
+	var wait time.Duration
+	for _, r := range rules {
+		since := now.Add(-r.Window)
+		var inWindow []time.Time
+		for _, t := range events {
+			if t.After(since) {
+				inWindow = append(inWindow, t)
+			}
+		}
+		if len(inWindow) < r.Limit {
+			continue
+		}
+		// Se libera un cupo cuando el evento más antiguo que cuenta sale de la ventana
+		oldest := inWindow[len(inWindow)-r.Limit]
+		if w := oldest.Add(r.Window).Sub(now); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+func (l *Limiter) prune(key string) {
+	ev := l.events[key]
+	cutoff := l.now().Add(-l.retention)
+	i := 0
+	for i < len(ev) && !ev[i].After(cutoff) {
+		i++
+	}
+	if i == len(ev) {
+		delete(l.events, key)
+		return
+	}
+	l.events[key] = ev[i:]
+}
+
+// Cleanup descarta eventos y bloqueos vencidos de todas las claves
+func (l *Limiter) Cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.events {
+		l.prune(key)
+	}
+	for key := range l.locks {
+		l.lockedFor(key)
+	}
+}
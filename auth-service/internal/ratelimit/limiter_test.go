@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock es un reloj manual para los limitadores de las pruebas
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newClock() *clock { return &clock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)} }
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(c *clock, retention time.Duration) *Limiter {
+	l := New(retention)
+	l.now = c.now
+	return l
+}
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	rule := Rule{Limit: 3, Window: time.Minute}
+
+	tests := []struct {
+		name string
+		// events son los momentos de los eventos y checkAt el de la consulta,
+		// contados desde el inicio del reloj
+		events   []time.Duration
+		checkAt  time.Duration
+		wantWait time.Duration
+	}{
+		{name: "no events"},
+		{name: "below the limit", events: []time.Duration{0, 20 * time.Second}, checkAt: 30 * time.Second},
+		{name: "at the limit waits for the oldest to leave", events: []time.Duration{0, 20 * time.Second, 30 * time.Second}, checkAt: 40 * time.Second, wantWait: 20 * time.Second},
+		{name: "events outside the window do not count", events: []time.Duration{0, 10 * time.Second, 60 * time.Second, 70 * time.Second}, checkAt: 71 * time.Second},
+		{name: "an event exactly one window old does not count", events: []time.Duration{0, 30 * time.Second, 40 * time.Second}, checkAt: time.Minute},
+		{name: "over the limit waits for enough to leave", events: []time.Duration{0, 10 * time.Second, 30 * time.Second, 40 * time.Second}, checkAt: 50 * time.Second, wantWait: 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClock()
+			l := newTestLimiter(c, time.Hour)
+			var at time.Duration
+			for _, e := range tt.events {
+				c.advance(e - at)
+				at = e
+				l.Record("k")
+			}
+			c.advance(tt.checkAt - at)
+
+			if wait := l.Check("k", rule); wait != tt.wantWait {
+				t.Errorf("wait = %s, want %s", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestLimiterRules(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c, 24*time.Hour)
+	hourly := Rule{Limit: 2, Window: time.Hour}
+	daily := Rule{Limit: 3, Window: 24 * time.Hour}
+
+	for i := 0; i < 2; i++ {
+		if wait := l.Allow("k", hourly, daily); wait != 0 {
+			t.Fatalf("event %d rejected, wait %s", i+1, wait)
+		}
+	}
+	// La regla más restrictiva decide la espera
+	if wait := l.Allow("k", hourly, daily); wait != time.Hour {
+		t.Fatalf("hourly cap: wait = %s, want 1h", wait)
+	}
+
+	c.advance(time.Hour)
+	if wait := l.Allow("k", hourly, daily); wait != 0 {
+		t.Fatalf("rejected after the hourly window, wait %s", wait)
+	}
+	if wait := l.Allow("k", hourly, daily); wait != 23*time.Hour {
+		t.Fatalf("daily cap: wait = %s, want 23h", wait)
+	}
+	if n := l.Count("k", 24*time.Hour); n != 3 {
+		t.Errorf("count = %d, want 3 (rejected events are not recorded)", n)
+	}
+	if wait := l.Check("other", hourly); wait != 0 {
+		t.Errorf("keys are independent, other waits %s", wait)
+	}
+}
+
+func TestLimiterLock(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c, time.Hour)
+
+	l.Lock("k", 10*time.Minute)
+	if wait := l.Check("k"); wait != 10*time.Minute {
+		t.Fatalf("wait = %s, want 10m", wait)
+	}
+	c.advance(10 * time.Minute)
+	if wait := l.LockedFor("k"); wait != 0 {
+		t.Fatalf("lock did not expire, %s left", wait)
+	}
+
+	l.Record("k")
+	l.Lock("k", time.Minute)
+	l.Reset("k")
+	if l.LockedFor("k") != 0 || l.Count("k", time.Hour) != 0 {
+		t.Error("Reset kept the lock or the events")
+	}
+}
+
+// Allow comprueba y registra bajo el mismo lock: con peticiones simultáneas
+// no se admiten más eventos que el límite
+func TestLimiterAllowConcurrent(t *testing.T) {
+	l := newTestLimiter(newClock(), time.Hour)
+	rule := Rule{Limit: 10, Window: time.Hour}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow("k", rule) == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != rule.Limit {
+		t.Errorf("allowed %d events, want %d", allowed, rule.Limit)
+	}
+}
+
+func TestLimiterCleanup(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c, time.Hour)
+	l.Record("old")
+	l.Lock("locked", time.Minute)
+	c.advance(2 * time.Hour)
+	l.Record("new")
+
+	l.Cleanup()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.events["old"]; ok {
+		t.Error("expired events kept")
+	}
+	if _, ok := l.locks["locked"]; ok {
+		t.Error("expired lock kept")
+	}
+	if len(l.events["new"]) != 1 {
+		t.Error("current events dropped")
+	}
+}
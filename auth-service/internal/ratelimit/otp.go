@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+// OTPPolicy define los límites para enviar y verificar códigos OTP
+type OTPPolicy struct {
+	// ResendCooldown es el tiempo mínimo entre dos envíos al mismo teléfono
+	ResendCooldown time.Duration
+	PhoneHourly    int
+	PhoneDaily     int
+	IPHourly       int
+	IPDaily        int
+
+	// IPFailedVerifyHourly limita los códigos incorrectos por IP
+	IPFailedVerifyHourly int
+
+	// MaxFailedRounds es cuántos códigos agotados (todos los intentos fallidos)
+	// se permiten dentro de FailedRoundsWindow antes de bloquear el teléfono
+	MaxFailedRounds    int
+	FailedRoundsWindow time.Duration
+	Lockout            time.Duration
+}
+
+// DefaultOTPPolicy son los límites por defecto
+var DefaultOTPPolicy = OTPPolicy{
+	ResendCooldown:       60 * time.Second,
+	PhoneHourly:          5,
+	PhoneDaily:           10,
+	IPHourly:             20,
+	IPDaily:              100,
+	IPFailedVerifyHourly: 30,
+	MaxFailedRounds:      3,
+	FailedRoundsWindow:   24 * time.Hour,
+	Lockout:              time.Hour,
+}
+
+// purgeInterval es cada cuánto AllowSend borra los eventos que ya no cuentan
+const purgeInterval = 10 * time.Minute
+
+// OTPLimiter aplica OTPPolicy por teléfono y por IP. Los eventos y bloqueos
+// se guardan en el repository.OTPLimitRepository que recibe cada llamada,
+// así que los límites se comparten entre instancias del servicio.
+type OTPLimiter struct {
+	policy OTPPolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewOTPLimiter crea un OTPLimiter con la política dada
+func NewOTPLimiter(policy OTPPolicy) *OTPLimiter {
+	return &OTPLimiter{policy: policy, now: time.Now}
+}
+
+// OTP es el limitador usado por los handlers de OTP
+var OTP = NewOTPLimiter(DefaultOTPPolicy)
+
+// InitOTP fija la política a partir de la configuración (OTP_RESEND_COOLDOWN,
+// OTP_PHONE_HOURLY, OTP_PHONE_DAILY, OTP_IP_HOURLY, OTP_IP_DAILY,
+// OTP_IP_FAILED_VERIFY_HOURLY, OTP_MAX_FAILED_ROUNDS, OTP_FAILED_ROUNDS_WINDOW
+// y OTP_LOCKOUT)
+func InitOTP(c config.OTP) {
+	OTP = NewOTPLimiter(OTPPolicy{
+		ResendCooldown:       c.ResendCooldown,
+		PhoneHourly:          c.PhoneHourly,
+		PhoneDaily:           c.PhoneDaily,
+		IPHourly:             c.IPHourly,
+		IPDaily:              c.IPDaily,
+		IPFailedVerifyHourly: c.IPFailedVerifyHourly,
+		MaxFailedRounds:      c.MaxFailedRounds,
+		FailedRoundsWindow:   c.FailedRoundsWindow,
+		Lockout:              c.Lockout,
+	})
+}
+
+// AllowSend decide si se puede enviar un código a phone desde ip y lo registra.
+// Devuelve el tiempo de espera (Retry-After) cuando se rechaza.
+func (o *OTPLimiter) AllowSend(ctx context.Context, repo repository.OTPLimitRepository, phone, ip string) (time.Duration, error) {
+	p := o.policy
+	now := o.now()
+	o.purge(ctx, repo, now)
+
+	if wait, err := o.lockedFor(ctx, repo, phone, now); wait > 0 || err != nil {
+		return wait, err
+	}
+
+	phoneSends, err := repo.Since(ctx, models.OTPLimitSend, "phone:"+phone, now.Add(-24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	if n := len(phoneSends); n > 0 {
+		if wait := phoneSends[n-1].Add(p.ResendCooldown).Sub(now); wait > 0 {
+			return wait, nil
+		}
+	}
+	ipSends, err := repo.Since(ctx, models.OTPLimitSend, "ip:"+ip, now.Add(-24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	wait := maxDuration(
+		waitFor(phoneSends, now, Rule{p.PhoneHourly, time.Hour}, Rule{p.PhoneDaily, 24 * time.Hour}),
+		waitFor(ipSends, now, Rule{p.IPHourly, time.Hour}, Rule{p.IPDaily, 24 * time.Hour}),
+	)
+	if wait > 0 {
+		return wait, nil
+	}
+
+	if err := repo.Record(ctx, models.OTPLimitSend, "phone:"+phone, now); err != nil {
+		return 0, err
+	}
+	return 0, repo.Record(ctx, models.OTPLimitSend, "ip:"+ip, now)
+}
+
+// AllowVerify decide si se acepta un intento de verificación
+func (o *OTPLimiter) AllowVerify(ctx context.Context, repo repository.OTPLimitRepository, phone, ip string) (time.Duration, error) {
+	now := o.now()
+	if wait, err := o.lockedFor(ctx, repo, phone, now); wait > 0 || err != nil {
+		return wait, err
+	}
+	fails, err := repo.Since(ctx, models.OTPLimitVerifyFailed, "ip:"+ip, now.Add(-time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	return waitFor(fails, now, Rule{o.policy.IPFailedVerifyHourly, time.Hour}), nil
+}
+
+// VerifyFailed registra un código incorrecto. roundExhausted indica que el
+// código agotó sus intentos; tras MaxFailedRounds el teléfono queda bloqueado.
+func (o *OTPLimiter) VerifyFailed(ctx context.Context, repo repository.OTPLimitRepository, phone, ip string, roundExhausted bool) error {
+	now := o.now()
+	if err := repo.Record(ctx, models.OTPLimitVerifyFailed, "ip:"+ip, now); err != nil {
+		return err
+	}
+	if !roundExhausted {
+		return nil
+	}
+
+	key := "phone:" + phone
+	if err := repo.Record(ctx, models.OTPLimitFailedRound, key, now); err != nil {
+		return err
+	}
+	rounds, err := repo.Since(ctx, models.OTPLimitFailedRound, key, now.Add(-o.policy.FailedRoundsWindow))
+	if err != nil {
+		return err
+	}
+	if len(rounds) < o.policy.MaxFailedRounds {
+		return nil
+	}
+	if err := repo.Lock(ctx, key, now.Add(o.policy.Lockout)); err != nil {
+		return err
+	}
+	slog.WarnContext(ctx, "OTP verification locked for a phone", "failed_rounds", len(rounds))
+	return repo.Clear(ctx, models.OTPLimitFailedRound, key)
+}
+
+// VerifySucceeded olvida las rondas fallidas del teléfono
+func (o *OTPLimiter) VerifySucceeded(ctx context.Context, repo repository.OTPLimitRepository, phone string) error {
+	return repo.Clear(ctx, models.OTPLimitFailedRound, "phone:"+phone)
+}
+
+func (o *OTPLimiter) lockedFor(ctx context.Context, repo repository.OTPLimitRepository, phone string, now time.Time) (time.Duration, error) {
+	until, err := repo.LockedUntil(ctx, "phone:"+phone)
+	if err != nil {
+		return 0, err
+	}
+	return maxDuration(until.Sub(now), 0), nil
+}
+
+// purge borra, como mucho cada purgeInterval, los eventos más viejos que la
+// ventana más larga de la política. Un error solo se registra: los eventos
+// viejos no cambian ninguna decisión.
+func (o *OTPLimiter) purge(ctx context.Context, repo repository.OTPLimitRepository, now time.Time) {
+	o.mu.Lock()
+	if now.Sub(o.lastPurge) < purgeInterval {
+		o.mu.Unlock()
+		return
+	}
+	o.lastPurge = now
+	o.mu.Unlock()
+
+	retention := maxDuration(24*time.Hour, o.policy.FailedRoundsWindow)
+	if err := repo.Purge(ctx, now.Add(-retention)); err != nil {
+		slog.WarnContext(ctx, "failed to purge OTP rate limit events", "error", err)
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+)
+
+var testOTPPolicy = OTPPolicy{
+	ResendCooldown:       time.Minute,
+	PhoneHourly:          3,
+	PhoneDaily:           4,
+	IPHourly:             5,
+	IPDaily:              100,
+	IPFailedVerifyHourly: 4,
+	MaxFailedRounds:      2,
+	FailedRoundsWindow:   24 * time.Hour,
+	Lockout:              time.Hour,
+}
+
+// otpFixture es un OTPLimiter con reloj manual sobre un repositorio en memoria
+type otpFixture struct {
+	t     *testing.T
+	o     *OTPLimiter
+	store repository.OTPLimitRepository
+}
+
+func newTestOTPLimiter(t *testing.T, c *clock) otpFixture {
+	o := NewOTPLimiter(testOTPPolicy)
+	o.now = c.now
+	return otpFixture{t: t, o: o, store: repository.NewMemoryOTPLimitRepository()}
+}
+
+func (f otpFixture) AllowSend(phone, ip string) time.Duration {
+	f.t.Helper()
+	wait, err := f.o.AllowSend(context.Background(), f.store, phone, ip)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return wait
+}
+
+func (f otpFixture) AllowVerify(phone, ip string) time.Duration {
+	f.t.Helper()
+	wait, err := f.o.AllowVerify(context.Background(), f.store, phone, ip)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return wait
+}
+
+func (f otpFixture) VerifyFailed(phone, ip string, roundExhausted bool) {
+	f.t.Helper()
+	if err := f.o.VerifyFailed(context.Background(), f.store, phone, ip, roundExhausted); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f otpFixture) VerifySucceeded(phone string) {
+	f.t.Helper()
+	if err := f.o.VerifySucceeded(context.Background(), f.store, phone); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func TestOTPAllowSend(t *testing.T) {
+	const phone, ip = "+593987654321", "203.0.113.7"
+
+	tests := []struct {
+		name string
+		// sends son los envíos previos desde ip, cada uno seguido de su espera
+		sends    []time.Duration
+		phone    string
+		ip       string
+		wantWait time.Duration
+	}{
+		{name: "first code", phone: phone, ip: ip},
+		{name: "resend cooldown", sends: []time.Duration{20 * time.Second}, phone: phone, ip: ip, wantWait: 40 * time.Second},
+		{name: "after the cooldown", sends: []time.Duration{time.Minute}, phone: phone, ip: ip},
+		{
+			name:     "phone hourly cap",
+			sends:    []time.Duration{10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+			phone:    phone,
+			ip:       ip,
+			wantWait: 30 * time.Minute,
+		},
+		{
+			name:     "phone daily cap",
+			sends:    []time.Duration{time.Hour, time.Hour, time.Hour, time.Hour},
+			phone:    phone,
+			ip:       ip,
+			wantWait: 20 * time.Hour,
+		},
+		{
+			name:  "the cap is per phone",
+			sends: []time.Duration{10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+			phone: "+593900000000",
+			ip:    ip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClock()
+			o := newTestOTPLimiter(t, c)
+			for i, d := range tt.sends {
+				if wait := o.AllowSend(phone, ip); wait != 0 {
+					t.Fatalf("send %d rejected, wait %s", i+1, wait)
+				}
+				c.advance(d)
+			}
+
+			if wait := o.AllowSend(tt.phone, tt.ip); wait != tt.wantWait {
+				t.Errorf("wait = %s, want %s", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestOTPAllowSendIPCap(t *testing.T) {
+	c := newClock()
+	o := newTestOTPLimiter(t, c)
+	const ip = "203.0.113.7"
+
+	// Cada código a un teléfono distinto: solo cuenta el límite por IP
+	phones := []string{"+593900000001", "+593900000002", "+593900000003", "+593900000004", "+593900000005"}
+	for _, phone := range phones {
+		if wait := o.AllowSend(phone, ip); wait != 0 {
+			t.Fatalf("send to %s rejected, wait %s", phone, wait)
+		}
+		c.advance(time.Minute)
+	}
+	if wait := o.AllowSend("+593900000006", ip); wait != 55*time.Minute {
+		t.Errorf("wait = %s, want 55m", wait)
+	}
+	if wait := o.AllowSend("+593900000006", "198.51.100.1"); wait != 0 {
+		t.Errorf("another IP waits %s", wait)
+	}
+}
+
+func TestOTPVerify(t *testing.T) {
+	const phone, ip = "+593987654321", "203.0.113.7"
+
+	tests := []struct {
+		name string
+		// failures son los códigos incorrectos previos; true agota el código
+		failures  []bool
+		succeeded bool
+		wantWait  time.Duration
+		// wantSendLocked indica si el bloqueo alcanza también al envío
+		wantSendLocked bool
+	}{
+		{name: "no failures"},
+		{name: "failures below the IP limit", failures: []bool{false, false, false}},
+		{name: "IP failure limit", failures: []bool{false, false, false, false}, wantWait: time.Hour},
+		{name: "one exhausted round", failures: []bool{true}},
+		{name: "repeated exhausted rounds lock the phone", failures: []bool{true, true}, wantWait: time.Hour, wantSendLocked: true},
+		{name: "a success forgets the failed rounds", failures: []bool{true}, succeeded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClock()
+			o := newTestOTPLimiter(t, c)
+			for _, exhausted := range tt.failures {
+				o.VerifyFailed(phone, ip, exhausted)
+			}
+			if tt.succeeded {
+				o.VerifySucceeded(phone)
+				// Una ronda más tras el éxito no alcanza para bloquear
+				o.VerifyFailed(phone, "198.51.100.1", true)
+			}
+
+			if wait := o.AllowVerify(phone, ip); wait != tt.wantWait {
+				t.Errorf("verify wait = %s, want %s", wait, tt.wantWait)
+			}
+			if locked := o.AllowSend(phone, "198.51.100.2") > 0; locked != tt.wantSendLocked {
+				t.Errorf("send locked = %t, want %t", locked, tt.wantSendLocked)
+			}
+		})
+	}
+}
+
+func TestOTPLimitsAreShared(t *testing.T) {
+	const phone, ip = "+593987654321", "203.0.113.7"
+	c := newClock()
+	a := newTestOTPLimiter(t, c)
+	// Otra instancia del servicio sobre el mismo repositorio
+	b := newTestOTPLimiter(t, c)
+	b.store = a.store
+
+	if wait := a.AllowSend(phone, ip); wait != 0 {
+		t.Fatalf("first send rejected, wait %s", wait)
+	}
+	if wait := b.AllowSend(phone, ip); wait != time.Minute {
+		t.Errorf("the other instance waits %s, want the 1m cooldown", wait)
+	}
+
+	a.VerifyFailed(phone, ip, true)
+	b.VerifyFailed(phone, ip, true)
+	if wait := a.AllowVerify(phone, "198.51.100.1"); wait != time.Hour {
+		t.Errorf("rounds failed on both instances: verify wait = %s, want 1h", wait)
+	}
+}
+
+func TestOTPPurge(t *testing.T) {
+	const phone, ip = "+593987654321", "203.0.113.7"
+	c := newClock()
+	f := newTestOTPLimiter(t, c)
+	ctx := context.Background()
+
+	f.AllowSend(phone, ip)
+	c.advance(25 * time.Hour)
+	// El siguiente envío purga los eventos de más de 24h
+	f.AllowSend(phone, "198.51.100.1")
+	sends, err := f.store.Since(ctx, models.OTPLimitSend, "ip:"+ip, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sends) != 0 {
+		t.Errorf("%d old sends left after the purge", len(sends))
+	}
+}
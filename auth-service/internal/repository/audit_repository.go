@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository devuelve el AuditRepository de Postgres
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return database.Conn(ctx, r.db).Create(event).Error
+}
+
+func (r *auditRepository) List(ctx context.Context, f AuditFilter) ([]models.AuditEvent, int64, error) {
+	q := r.filtered(ctx, f)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	events := []models.AuditEvent{}
+	err := q.Order("created_at DESC, id DESC").
+		Offset((f.Page - 1) * f.PageSize).
+		Limit(f.PageSize).
+		Find(&events).Error
+	return events, total, err
+}
+
+func (r *auditRepository) ListBefore(ctx context.Context, f AuditFilter, before *models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	q := r.filtered(ctx, f)
+	if before != nil {
+		q = q.Where("(created_at, id) < (?, ?)", before.CreatedAt, before.ID)
+	}
+	var events []models.AuditEvent
+	err := q.Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *auditRepository) filtered(ctx context.Context, f AuditFilter) *gorm.DB {
+	q := database.Conn(ctx, r.db).Model(&models.AuditEvent{})
+	if f.ActorID != nil {
+		q = q.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Action != "" {
+		if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+			q = q.Where("action LIKE ?", escapeLike(prefix)+"%")
+		} else {
+			q = q.Where("action = ?", f.Action)
+		}
+	}
+	if f.Target != "" {
+		q = q.Where("target = ?", f.Target)
+	}
+	if f.Outcome != "" {
+		q = q.Where("outcome = ?", f.Outcome)
+	}
+	if f.IP != "" {
+		q = q.Where("ip = ?", f.IP)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	return q
+}
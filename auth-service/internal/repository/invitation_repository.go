@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository devuelve el InvitationRepository de Postgres
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, inv *models.Invitation) error {
+	return database.Conn(ctx, r.db).Create(inv).Error
+}
+
+func (r *invitationRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	var inv models.Invitation
+	if err := database.Conn(ctx, r.db).First(&inv, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &inv, nil
+}
+
+func (r *invitationRepository) Consume(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(&models.Invitation{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{"used_at": at, "used_by_id": userID})
+	return res.RowsAffected > 0, res.Error
+}
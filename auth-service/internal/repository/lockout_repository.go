@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type lockoutRepository struct {
+	db *gorm.DB
+}
+
+// NewLockoutRepository devuelve el LockoutRepository de Postgres
+func NewLockoutRepository(db *gorm.DB) LockoutRepository {
+	return &lockoutRepository{db: db}
+}
+
+func (r *lockoutRepository) RecordFailure(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	var attempts int
+	err := database.Conn(ctx, r.db).Raw(`UPDATE users
+		SET failed_login_attempts = failed_login_attempts + 1, last_failed_login_at = ?
+		WHERE id = ? RETURNING failed_login_attempts`, at, userID).Scan(&attempts).Error
+	return attempts, err
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, event *models.AccountLockEvent) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", event.UserID).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          event.LockedUntil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+func (r *lockoutRepository) Reset(ctx context.Context, userID uuid.UUID) error {
+	_, err := reset(database.Conn(ctx, r.db), userID)
+	return err
+}
+
+func (r *lockoutRepository) Unlock(ctx context.Context, event *models.AccountLockEvent) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		found, err := reset(tx, event.UserID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrNotFound
+		}
+		return tx.Create(event).Error
+	})
+}
+
+func reset(tx *gorm.DB, userID uuid.UUID) (bool, error) {
+	res := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	})
+	return res.RowsAffected > 0, res.Error
+}
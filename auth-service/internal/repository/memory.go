@@ -0,0 +1,870 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// Los repositorios en memoria guardan copias de los modelos: lo que devuelven
+// se puede modificar sin afectar lo guardado. Aplican los mismos valores por
+// defecto y restricciones únicas que el esquema de Postgres.
+
+type memoryUserRepository struct {
+	mu       sync.RWMutex
+	users    map[uuid.UUID]models.User
+	profiles []models.OperatorProfile
+}
+
+// NewMemoryUserRepository devuelve un UserRepository en memoria
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: make(map[uuid.UUID]models.User)}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if sameString(u.Email, user.Email) || sameString(u.Phone, user.Phone) {
+			return ErrDuplicate
+		}
+	}
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	} else if _, ok := r.users[user.ID]; ok {
+		return ErrDuplicate
+	}
+	if user.Status == "" {
+		user.Status = models.UserStatusActive
+	}
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email != nil && *u.Email == email })
+}
+
+func (r *memoryUserRepository) FindByPhone(ctx context.Context, phone string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Phone != nil && *u.Phone == phone })
+}
+
+func (r *memoryUserRepository) CreateOperatorProfile(ctx context.Context, profile *models.OperatorProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[profile.UserID]; !ok {
+		return ErrNotFound
+	}
+	if profile.ID == uuid.Nil {
+		profile.ID = uuid.New()
+	}
+	if profile.Status == "" {
+		profile.Status = models.UserStatusActive
+	}
+	now := time.Now()
+	profile.CreatedAt, profile.UpdatedAt = now, now
+	r.profiles = append(r.profiles, *profile)
+	return nil
+}
+
+func (r *memoryUserRepository) HasOperatorProfile(ctx context.Context, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.profiles {
+		if p.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, f UserFilter) ([]models.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := []models.User{}
+	for _, u := range r.users {
+		if (f.Role == "" || u.Role == f.Role) &&
+			(f.Status == "" || u.Status == strings.ToUpper(f.Status)) &&
+			(f.Email == "" || u.Email != nil && strings.Contains(strings.ToLower(*u.Email), strings.ToLower(f.Email))) &&
+			(f.Phone == "" || u.Phone != nil && strings.Contains(*u.Phone, f.Phone)) {
+			matches = append(matches, u)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].CreatedAt.After(matches[j].CreatedAt) })
+	return page(matches, f.Page, f.PageSize), int64(len(matches)), nil
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	return r.update(id, func(u *models.User) bool {
+		u.Role = role
+		return true
+	})
+}
+
+func (r *memoryUserRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, until *time.Time) error {
+	return r.update(id, func(u *models.User) bool {
+		u.Status = status
+		u.SuspendedUntil = until
+		return true
+	})
+}
+
+func (r *memoryUserRepository) LiftSuspension(ctx context.Context, id uuid.UUID, now time.Time) error {
+	err := r.update(id, func(u *models.User) bool {
+		if u.Status != models.UserStatusSuspended || u.SuspendedUntil == nil || u.SuspendedUntil.After(now) {
+			return false
+		}
+		u.Status = models.UserStatusActive
+		u.SuspendedUntil = nil
+		return true
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *memoryUserRepository) SetPassword(ctx context.Context, id uuid.UUID, hash string) error {
+	return r.update(id, func(u *models.User) bool {
+		u.PasswordHash = &hash
+		u.FailedLoginAttempts = 0
+		u.LockedUntil = nil
+		return true
+	})
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	profiles := r.profiles[:0]
+	for _, p := range r.profiles {
+		if p.UserID != id {
+			profiles = append(profiles, p)
+		}
+	}
+	r.profiles = profiles
+	return nil
+}
+
+// update aplica fn al usuario id; fn devuelve false si no hay cambios
+func (r *memoryUserRepository) update(id uuid.UUID, fn func(u *models.User) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if fn(&u) {
+		u.UpdatedAt = time.Now()
+		r.users[id] = u
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) find(match func(models.User) bool) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if match(u) {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type memoryOTPRepository struct {
+	mu    sync.Mutex
+	codes map[uuid.UUID]models.OTPCode
+}
+
+// NewMemoryOTPRepository devuelve un OTPRepository en memoria
+func NewMemoryOTPRepository() OTPRepository {
+	return &memoryOTPRepository{codes: make(map[uuid.UUID]models.OTPCode)}
+}
+
+func (r *memoryOTPRepository) Create(ctx context.Context, otp *models.OTPCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if otp.ID == uuid.Nil {
+		otp.ID = uuid.New()
+	}
+	if otp.MaxAttempts == 0 {
+		otp.MaxAttempts = 5
+	}
+	if otp.IssuedAt.IsZero() {
+		otp.IssuedAt = time.Now()
+	}
+	if otp.Purpose == "" {
+		otp.Purpose = "LOGIN"
+	}
+	r.codes[otp.ID] = *otp
+	return nil
+}
+
+func (r *memoryOTPRepository) FindActive(ctx context.Context, phone string, now time.Time) (*models.OTPCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *models.OTPCode
+	for _, otp := range r.codes {
+		if otp.Phone != phone || otp.Consumed || !otp.ExpiresAt.After(now) {
+			continue
+		}
+		if latest == nil || otp.IssuedAt.After(latest.IssuedAt) {
+			otp := otp
+			latest = &otp
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *memoryOTPRepository) IncrementAttempts(ctx context.Context, otp *models.OTPCode) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.codes[otp.ID]
+	if !ok || stored.Attempts >= stored.MaxAttempts {
+		return false, nil
+	}
+	stored.Attempts++
+	r.codes[otp.ID] = stored
+	otp.Attempts = stored.Attempts
+	return true, nil
+}
+
+func (r *memoryOTPRepository) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	otp, ok := r.codes[id]
+	if !ok || otp.Consumed {
+		return false, nil
+	}
+	otp.Consumed = true
+	r.codes[id] = otp
+	return true, nil
+}
+
+type memoryOTPLimitRepository struct {
+	mu     sync.Mutex
+	events map[[2]string][]time.Time
+	locks  map[string]time.Time
+}
+
+// NewMemoryOTPLimitRepository devuelve un OTPLimitRepository en memoria
+func NewMemoryOTPLimitRepository() OTPLimitRepository {
+	return &memoryOTPLimitRepository{events: make(map[[2]string][]time.Time), locks: make(map[string]time.Time)}
+}
+
+func (r *memoryOTPLimitRepository) Record(ctx context.Context, kind, key string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := [2]string{kind, key}
+	events := append(r.events[k], at)
+	sort.Slice(events, func(i, j int) bool { return events[i].Before(events[j]) })
+	r.events[k] = events
+	return nil
+}
+
+func (r *memoryOTPLimitRepository) Since(ctx context.Context, kind, key string, since time.Time) ([]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var times []time.Time
+	for _, t := range r.events[[2]string{kind, key}] {
+		if t.After(since) {
+			times = append(times, t)
+		}
+	}
+	return times, nil
+}
+
+func (r *memoryOTPLimitRepository) Clear(ctx context.Context, kind, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.events, [2]string{kind, key})
+	return nil
+}
+
+func (r *memoryOTPLimitRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locks[key] = until
+	return nil
+}
+
+func (r *memoryOTPLimitRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.locks[key], nil
+}
+
+func (r *memoryOTPLimitRepository) Purge(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, events := range r.events {
+		i := 0
+		for i < len(events) && !events[i].After(before) {
+			i++
+		}
+		if i == len(events) {
+			delete(r.events, k)
+		} else {
+			r.events[k] = events[i:]
+		}
+	}
+	for key, until := range r.locks {
+		if !until.After(before) {
+			delete(r.locks, key)
+		}
+	}
+	return nil
+}
+
+type memoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]models.RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository devuelve un RefreshTokenRepository en memoria
+func NewMemoryRefreshTokenRepository() RefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: make(map[uuid.UUID]models.RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.ID]; ok {
+		return ErrDuplicate
+	}
+	now := time.Now()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = now
+	}
+	if token.SessionStartedAt.IsZero() {
+		token.SessionStartedAt = now
+	}
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &token, nil
+}
+
+func (r *memoryRefreshTokenRepository) Rotate(ctx context.Context, id, replacedBy uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.Revoked {
+		return false, nil
+	}
+	token.Revoked = true
+	token.RevokedAt = &at
+	token.LastUsedAt = &at
+	token.ReplacedByID = &replacedBy
+	r.tokens[id] = token
+	return true, nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revoke(func(t models.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	r.revoke(func(t models.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []models.RefreshToken
+	for _, t := range r.tokens {
+		if t.UserID == userID && !t.Revoked && t.ExpiresAt.After(now) {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *memoryRefreshTokenRepository) HasActiveFamily(ctx context.Context, userID, familyID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.FamilyID == familyID && !t.Revoked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRefreshTokenRepository) revoke(match func(models.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.tokens {
+		if !t.Revoked && match(t) {
+			t.Revoked = true
+			t.RevokedAt = &now
+			r.tokens[id] = t
+		}
+	}
+}
+
+type memoryLockoutRepository struct {
+	users *memoryUserRepository
+
+	mu     sync.Mutex
+	events []models.AccountLockEvent
+}
+
+// NewMemoryLockoutRepository devuelve un LockoutRepository en memoria sobre
+// los usuarios de users (de NewMemoryUserRepository)
+func NewMemoryLockoutRepository(users UserRepository) LockoutRepository {
+	return &memoryLockoutRepository{users: users.(*memoryUserRepository)}
+}
+
+func (r *memoryLockoutRepository) RecordFailure(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	var attempts int
+	err := r.users.update(userID, func(u *models.User) bool {
+		u.FailedLoginAttempts++
+		u.LastFailedLoginAt = &at
+		attempts = u.FailedLoginAttempts
+		return true
+	})
+	return attempts, err
+}
+
+func (r *memoryLockoutRepository) Lock(ctx context.Context, event *models.AccountLockEvent) error {
+	err := r.users.update(event.UserID, func(u *models.User) bool {
+		u.FailedLoginAttempts = 0
+		u.LockedUntil = event.LockedUntil
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.record(event)
+	return nil
+}
+
+func (r *memoryLockoutRepository) Reset(ctx context.Context, userID uuid.UUID) error {
+	err := r.users.update(userID, resetLockout)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *memoryLockoutRepository) Unlock(ctx context.Context, event *models.AccountLockEvent) error {
+	if err := r.users.update(event.UserID, resetLockout); err != nil {
+		return err
+	}
+	r.record(event)
+	return nil
+}
+
+func (r *memoryLockoutRepository) record(event *models.AccountLockEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.events = append(r.events, *event)
+}
+
+func resetLockout(u *models.User) bool {
+	u.FailedLoginAttempts = 0
+	u.LastFailedLoginAt = nil
+	u.LockedUntil = nil
+	return true
+}
+
+type memoryMFARepository struct {
+	users *memoryUserRepository
+
+	mu       sync.Mutex
+	codes    []models.MFARecoveryCode
+	policies map[string]models.MFARolePolicy
+}
+
+// NewMemoryMFARepository devuelve un MFARepository en memoria sobre los
+// usuarios de users (de NewMemoryUserRepository)
+func NewMemoryMFARepository(users UserRepository) MFARepository {
+	return &memoryMFARepository{users: users.(*memoryUserRepository), policies: make(map[string]models.MFARolePolicy)}
+}
+
+func (r *memoryMFARepository) SetSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	return r.users.update(userID, func(u *models.User) bool {
+		u.TOTPSecret = &secret
+		u.TOTPLastStep = 0
+		return true
+	})
+}
+
+func (r *memoryMFARepository) Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	err := r.users.update(userID, func(u *models.User) bool {
+		u.TOTPEnabled = true
+		u.TOTPLastStep = step
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteCodes(userID)
+	now := time.Now()
+	for _, h := range codeHashes {
+		r.codes = append(r.codes, models.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: h, CreatedAt: now})
+	}
+	return nil
+}
+
+func (r *memoryMFARepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	err := r.users.update(userID, func(u *models.User) bool {
+		u.TOTPEnabled = false
+		u.TOTPSecret = nil
+		u.TOTPLastStep = 0
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteCodes(userID)
+	return nil
+}
+
+func (r *memoryMFARepository) AdvanceStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	advanced := false
+	err := r.users.update(userID, func(u *models.User) bool {
+		if u.TOTPLastStep >= step {
+			return false
+		}
+		u.TOTPLastStep = step
+		advanced = true
+		return true
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return advanced, err
+}
+
+func (r *memoryMFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == codeHash && c.UsedAt == nil {
+			r.codes[i].UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryMFARepository) RolePolicy(ctx context.Context, role string) (*models.MFARolePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.policies[role]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &policy, nil
+}
+
+func (r *memoryMFARepository) SaveRolePolicy(ctx context.Context, policy *models.MFARolePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy.UpdatedAt = time.Now()
+	r.policies[policy.Role] = *policy
+	return nil
+}
+
+func (r *memoryMFARepository) ListRolePolicies(ctx context.Context) ([]models.MFARolePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policies := []models.MFARolePolicy{}
+	for _, p := range r.policies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Role < policies[j].Role })
+	return policies, nil
+}
+
+func (r *memoryMFARepository) deleteCodes(userID uuid.UUID) {
+	codes := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			codes = append(codes, c)
+		}
+	}
+	r.codes = codes
+}
+
+type memoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]models.PasswordResetToken
+}
+
+// NewMemoryPasswordResetRepository devuelve un PasswordResetRepository en memoria
+func NewMemoryPasswordResetRepository() PasswordResetRepository {
+	return &memoryPasswordResetRepository{tokens: make(map[uuid.UUID]models.PasswordResetToken)}
+}
+
+func (r *memoryPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	token.CreatedAt = time.Now()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryPasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPasswordResetRepository) Use(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil || !t.ExpiresAt.After(at) {
+		return false, nil
+	}
+	t.UsedAt = &at
+	r.tokens[id] = t
+	return true, nil
+}
+
+func (r *memoryPasswordResetRepository) UseAll(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.tokens {
+		if t.UserID == userID && t.UsedAt == nil {
+			t.UsedAt = &at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+type memoryInvitationRepository struct {
+	mu          sync.Mutex
+	invitations map[uuid.UUID]models.Invitation
+}
+
+// NewMemoryInvitationRepository devuelve un InvitationRepository en memoria
+func NewMemoryInvitationRepository() InvitationRepository {
+	return &memoryInvitationRepository{invitations: make(map[uuid.UUID]models.Invitation)}
+}
+
+func (r *memoryInvitationRepository) Create(ctx context.Context, inv *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inv.ID == uuid.Nil {
+		inv.ID = uuid.New()
+	} else if _, ok := r.invitations[inv.ID]; ok {
+		return ErrDuplicate
+	}
+	inv.CreatedAt = time.Now()
+	r.invitations[inv.ID] = *inv
+	return nil
+}
+
+func (r *memoryInvitationRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &inv, nil
+}
+
+func (r *memoryInvitationRepository) Consume(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || inv.UsedAt != nil {
+		return false, nil
+	}
+	inv.UsedAt = &at
+	inv.UsedByID = &userID
+	r.invitations[id] = inv
+	return true, nil
+}
+
+type memoryAuditRepository struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+// NewMemoryAuditRepository devuelve un AuditRepository en memoria
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, f AuditFilter) ([]models.AuditEvent, int64, error) {
+	matches := r.filtered(f)
+	return page(matches, f.Page, f.PageSize), int64(len(matches)), nil
+}
+
+func (r *memoryAuditRepository) ListBefore(ctx context.Context, f AuditFilter, before *models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	for _, e := range r.filtered(f) {
+		if len(events) == limit {
+			break
+		}
+		if before == nil || auditOlder(e, *before) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// filtered devuelve los eventos que cumplen f en el orden de List
+func (r *memoryAuditRepository) filtered(f AuditFilter) []models.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matches := []models.AuditEvent{}
+	for _, e := range r.events {
+		if f.ActorID != nil && (e.ActorID == nil || *e.ActorID != *f.ActorID) {
+			continue
+		}
+		if f.Action != "" {
+			if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+				if !strings.HasPrefix(e.Action, prefix) {
+					continue
+				}
+			} else if e.Action != f.Action {
+				continue
+			}
+		}
+		if (f.Target != "" && e.Target != f.Target) ||
+			(f.Outcome != "" && e.Outcome != f.Outcome) ||
+			(f.IP != "" && e.IP != f.IP) ||
+			(f.From != nil && e.CreatedAt.Before(*f.From)) ||
+			(f.To != nil && !e.CreatedAt.Before(*f.To)) {
+			continue
+		}
+		matches = append(matches, e)
+	}
+	sort.Slice(matches, func(i, j int) bool { return auditOlder(matches[j], matches[i]) })
+	return matches
+}
+
+// auditOlder indica si a es anterior a b según (created_at, id)
+func auditOlder(a, b models.AuditEvent) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+// page devuelve la página number (desde 1) de size elementos
+func page[T any](items []T, number, size int) []T {
+	start := (number - 1) * size
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+size, len(items))]
+}
+
+func sameString(a, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
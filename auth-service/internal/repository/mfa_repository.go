@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+// NewMFARepository devuelve el MFARepository de Postgres
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) SetSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	return database.Conn(ctx, r.db).Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error
+}
+
+func (r *mfaRepository) Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, h := range codeHashes {
+			if err := tx.Create(&models.MFARecoveryCode{UserID: userID, CodeHash: h}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *mfaRepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    nil,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+func (r *mfaRepository) AdvanceStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *mfaRepository) RolePolicy(ctx context.Context, role string) (*models.MFARolePolicy, error) {
+	var policy models.MFARolePolicy
+	if err := database.Conn(ctx, r.db).First(&policy, "role = ?", role).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &policy, nil
+}
+
+func (r *mfaRepository) SaveRolePolicy(ctx context.Context, policy *models.MFARolePolicy) error {
+	return database.Conn(ctx, r.db).Save(policy).Error
+}
+
+func (r *mfaRepository) ListRolePolicies(ctx context.Context) ([]models.MFARolePolicy, error) {
+	policies := []models.MFARolePolicy{}
+	err := database.Conn(ctx, r.db).Order("role").Find(&policies).Error
+	return policies, err
+}
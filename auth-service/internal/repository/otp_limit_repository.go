@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type otpLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewOTPLimitRepository devuelve el OTPLimitRepository de Postgres
+func NewOTPLimitRepository(db *gorm.DB) OTPLimitRepository {
+	return &otpLimitRepository{db: db}
+}
+
+func (r *otpLimitRepository) Record(ctx context.Context, kind, key string, at time.Time) error {
+	return database.Conn(ctx, r.db).Create(&models.OTPLimitEvent{Kind: kind, Key: key, CreatedAt: at}).Error
+}
+
+func (r *otpLimitRepository) Since(ctx context.Context, kind, key string, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := database.Conn(ctx, r.db).Model(&models.OTPLimitEvent{}).
+		Where("kind = ? AND key = ? AND created_at > ?", kind, key, since).
+		Order("created_at").
+		Pluck("created_at", &times).Error
+	return times, err
+}
+
+func (r *otpLimitRepository) Clear(ctx context.Context, kind, key string) error {
+	return database.Conn(ctx, r.db).Where("kind = ? AND key = ?", kind, key).Delete(&models.OTPLimitEvent{}).Error
+}
+
+func (r *otpLimitRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return database.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until"}),
+	}).Create(&models.OTPLock{Key: key, LockedUntil: until}).Error
+}
+
+func (r *otpLimitRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var lock models.OTPLock
+	err := database.Conn(ctx, r.db).First(&lock, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return lock.LockedUntil, err
+}
+
+func (r *otpLimitRepository) Purge(ctx context.Context, before time.Time) error {
+	tx := database.Conn(ctx, r.db)
+	if err := tx.Where("created_at <= ?", before).Delete(&models.OTPLimitEvent{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("locked_until <= ?", before).Delete(&models.OTPLock{}).Error
+}
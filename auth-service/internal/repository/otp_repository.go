@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type otpRepository struct {
+	db *gorm.DB
+}
+
+// NewOTPRepository devuelve el OTPRepository de Postgres
+func NewOTPRepository(db *gorm.DB) OTPRepository {
+	return &otpRepository{db: db}
+}
+
+func (r *otpRepository) Create(ctx context.Context, otp *models.OTPCode) error {
+	return database.Conn(ctx, r.db).Create(otp).Error
+}
+
+func (r *otpRepository) FindActive(ctx context.Context, phone string, now time.Time) (*models.OTPCode, error) {
+	var otp models.OTPCode
+	if err := database.Conn(ctx, r.db).
+		Where("phone = ? AND consumed = false AND expires_at > ?", phone, now).
+		Order("issued_at DESC").First(&otp).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &otp, nil
+}
+
+func (r *otpRepository) IncrementAttempts(ctx context.Context, otp *models.OTPCode) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(otp).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("attempts < max_attempts").
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *otpRepository) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(&models.OTPCode{}).
+		Where("id = ? AND consumed = ?", id, false).
+		Update("consumed", true)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
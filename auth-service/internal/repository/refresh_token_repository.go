@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository devuelve el RefreshTokenRepository de Postgres
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return database.Conn(ctx, r.db).Create(token).Error
+}
+
+func (r *refreshTokenRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := database.Conn(ctx, r.db).First(&token, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) Rotate(ctx context.Context, id, replacedBy uuid.UUID, at time.Time) (bool, error) {
+	res := database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked = ?", id, false).
+		Updates(map[string]interface{}{
+			"revoked":        true,
+			"revoked_at":     at,
+			"last_used_at":   at,
+			"replaced_by_id": replacedBy,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.revoke(ctx, "family_id = ?", familyID)
+}
+
+func (r *refreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	return r.revoke(ctx, "user_id = ?", userID)
+}
+
+func (r *refreshTokenRepository) ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := database.Conn(ctx, r.db).
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, now).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *refreshTokenRepository) HasActiveFamily(ctx context.Context, userID, familyID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked = ?", userID, familyID, false).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *refreshTokenRepository) revoke(ctx context.Context, query string, arg interface{}) error {
+	return database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where(query+" AND revoked = ?", arg, false).
+		Updates(map[string]interface{}{"revoked": true, "revoked_at": time.Now()}).Error
+}
@@ -0,0 +1,245 @@
+// Package repository define el acceso a datos del servicio: usuarios y su
+// estado (bloqueo, 2FA), códigos OTP y sus límites, refresh tokens, tokens de recuperación,
+// invitaciones y auditoría. Cada repositorio tiene una implementación GORM (Postgres)
+// y otra en memoria, segura para uso concurrente, para pruebas sin base de
+// datos. Las implementaciones GORM usan la transacción que lleve el contexto
+// (database.WithTx); las de memoria no tienen transacciones.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound  = errors.New("record not found")
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// UserRepository guarda y busca usuarios
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByPhone(ctx context.Context, phone string) (*models.User, error)
+	CreateOperatorProfile(ctx context.Context, profile *models.OperatorProfile) error
+	HasOperatorProfile(ctx context.Context, userID uuid.UUID) (bool, error)
+	// List devuelve la página f.Page (de f.PageSize) de los usuarios que
+	// cumplen f, los más recientes primero, y el total sin paginar
+	List(ctx context.Context, f UserFilter) ([]models.User, int64, error)
+	SetRole(ctx context.Context, id uuid.UUID, role string) error
+	SetStatus(ctx context.Context, id uuid.UUID, status string, until *time.Time) error
+	// LiftSuspension reactiva la cuenta si su suspensión venció antes de now
+	LiftSuspension(ctx context.Context, id uuid.UUID, now time.Time) error
+	// SetPassword cambia el hash de la contraseña y limpia el bloqueo por
+	// logins fallidos
+	SetPassword(ctx context.Context, id uuid.UUID, hash string) error
+	// Delete elimina el usuario y los datos que dependen de él
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// UserFilter son los criterios de UserRepository.List. Email y Phone buscan
+// coincidencias parciales; Status no distingue mayúsculas.
+type UserFilter struct {
+	Role     string
+	Status   string
+	Email    string
+	Phone    string
+	Page     int
+	PageSize int
+}
+
+// LockoutRepository guarda los logins fallidos de cada usuario y los eventos
+// de bloqueo (account_lock_events)
+type LockoutRepository interface {
+	// RecordFailure suma un fallo al usuario en la misma escritura (sin leer
+	// antes el contador) y devuelve el total
+	RecordFailure(ctx context.Context, userID uuid.UUID, at time.Time) (int, error)
+	// Lock bloquea la cuenta hasta event.LockedUntil, reinicia el contador y
+	// guarda el evento
+	Lock(ctx context.Context, event *models.AccountLockEvent) error
+	// Reset limpia el contador y el bloqueo tras un login correcto
+	Reset(ctx context.Context, userID uuid.UUID) error
+	// Unlock limpia el contador y el bloqueo y guarda el evento. Devuelve
+	// ErrNotFound si el usuario no existe.
+	Unlock(ctx context.Context, event *models.AccountLockEvent) error
+}
+
+// MFARepository guarda el segundo factor TOTP de los usuarios, sus códigos de
+// recuperación y la política de 2FA por rol
+type MFARepository interface {
+	// SetSecret guarda un secreto pendiente de confirmación
+	SetSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	// Enable activa TOTP y reemplaza los códigos de recuperación por codeHashes
+	Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error
+	// Disable borra el secreto y los códigos de recuperación
+	Disable(ctx context.Context, userID uuid.UUID) error
+	// AdvanceStep registra step como el último paso TOTP usado. Devuelve
+	// false si ya se usó ese paso o uno posterior: la condición se evalúa en
+	// la misma escritura, así que un código no sirve dos veces aunque llegue
+	// en peticiones simultáneas.
+	AdvanceStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	// UseRecoveryCode marca como usado el código con hash codeHash. Devuelve
+	// false si no existe o ya se usó.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error)
+	RolePolicy(ctx context.Context, role string) (*models.MFARolePolicy, error)
+	SaveRolePolicy(ctx context.Context, policy *models.MFARolePolicy) error
+	// ListRolePolicies devuelve las políticas ordenadas por rol
+	ListRolePolicies(ctx context.Context) ([]models.MFARolePolicy, error)
+}
+
+// PasswordResetRepository guarda los tokens de recuperación de contraseña
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// Use marca el token como usado si sigue pendiente y vigente. Devuelve
+	// false si otra petición lo usó antes o si expiró.
+	Use(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// UseAll marca como usados los tokens pendientes del usuario
+	UseAll(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
+
+// InvitationRepository guarda las invitaciones de registro
+type InvitationRepository interface {
+	Create(ctx context.Context, inv *models.Invitation) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error)
+	// Consume marca la invitación como usada por userID. Devuelve false si
+	// otra petición la consumió antes.
+	Consume(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error)
+}
+
+// AuditRepository guarda y consulta los eventos de auditoría; no los modifica
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	// List devuelve la página f.Page (de f.PageSize) de los eventos que
+	// cumplen f, los más recientes primero, y el total sin paginar
+	List(ctx context.Context, f AuditFilter) ([]models.AuditEvent, int64, error)
+	// ListBefore devuelve hasta limit eventos que cumplen f, los más
+	// recientes primero, empezando después de before (nil = desde el
+	// principio). Pagina por (created_at, id), así que no salta ni repite
+	// eventos entre lotes.
+	ListBefore(ctx context.Context, f AuditFilter, before *models.AuditEvent, limit int) ([]models.AuditEvent, error)
+}
+
+// AuditFilter son los criterios de AuditRepository. Action admite prefijos
+// terminados en "*" (p. ej. "admin.*").
+type AuditFilter struct {
+	ActorID  *uuid.UUID
+	Action   string
+	Target   string
+	Outcome  string
+	IP       string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// OTPRepository guarda los códigos OTP enviados por SMS
+type OTPRepository interface {
+	Create(ctx context.Context, otp *models.OTPCode) error
+	// FindActive devuelve el último código sin consumir y sin expirar del teléfono
+	FindActive(ctx context.Context, phone string, now time.Time) (*models.OTPCode, error)
+	// IncrementAttempts suma un intento al código si todavía le quedan y
+	// actualiza otp.Attempts. Devuelve false si ya agotó MaxAttempts: la
+	// condición se evalúa en la misma escritura, así que intentos simultáneos
+	// no pueden superar el límite.
+	IncrementAttempts(ctx context.Context, otp *models.OTPCode) (bool, error)
+	// Consume marca el código como usado. Devuelve false si otra petición lo
+	// consumió antes.
+	Consume(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// OTPLimitRepository guarda los eventos con los que se limitan los envíos y
+// verificaciones de OTP (ver ratelimit.OTPLimiter) y los bloqueos de
+// teléfonos. Al estar en la base de datos, los límites valen para todas las
+// instancias del servicio y sobreviven a un reinicio.
+type OTPLimitRepository interface {
+	// Record guarda un evento kind (models.OTPLimitSend, ...) de key en at
+	Record(ctx context.Context, kind, key string, at time.Time) error
+	// Since devuelve los momentos de los eventos kind de key posteriores a
+	// since, del más antiguo al más reciente
+	Since(ctx context.Context, kind, key string, since time.Time) ([]time.Time, error)
+	// Clear borra los eventos kind de key
+	Clear(ctx context.Context, kind, key string) error
+	// Lock bloquea key hasta until, reemplazando un bloqueo anterior
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil devuelve el fin del bloqueo de key; el tiempo cero si no tiene
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// Purge borra los eventos y bloqueos que terminaron antes de before
+	Purge(ctx context.Context, before time.Time) error
+}
+
+// RefreshTokenRepository guarda los refresh tokens; una familia (FamilyID)
+// es una sesión
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error)
+	// Rotate revoca el token id si sigue activo y lo marca reemplazado por
+	// replacedBy. Devuelve false si otra petición lo revocó antes.
+	Rotate(ctx context.Context, id, replacedBy uuid.UUID, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+	// ListActive devuelve los tokens vigentes del usuario, el más reciente primero
+	ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.RefreshToken, error)
+	// HasActiveFamily indica si la sesión familyID del usuario sigue abierta
+	HasActiveFamily(ctx context.Context, userID, familyID uuid.UUID) (bool, error)
+}
+
+// Repositories agrupa los repositorios del servicio
+type Repositories struct {
+	Users         UserRepository
+	OTPs          OTPRepository
+	OTPLimits     OTPLimitRepository
+	RefreshTokens RefreshTokenRepository
+	Lockout       LockoutRepository
+	MFA           MFARepository
+	PasswordReset PasswordResetRepository
+	Invitations   InvitationRepository
+	Audit         AuditRepository
+}
+
+// New devuelve los repositorios GORM sobre db
+func New(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:         NewUserRepository(db),
+		OTPs:          NewOTPRepository(db),
+		OTPLimits:     NewOTPLimitRepository(db),
+		RefreshTokens: NewRefreshTokenRepository(db),
+		Lockout:       NewLockoutRepository(db),
+		MFA:           NewMFARepository(db),
+		PasswordReset: NewPasswordResetRepository(db),
+		Invitations:   NewInvitationRepository(db),
+		Audit:         NewAuditRepository(db),
+	}
+}
+
+// NewMemory devuelve repositorios en memoria vacíos. Lockout y MFA trabajan
+// sobre los mismos usuarios que Users.
+func NewMemory() Repositories {
+	users := NewMemoryUserRepository()
+	return Repositories{
+		Users:         users,
+		OTPs:          NewMemoryOTPRepository(),
+		OTPLimits:     NewMemoryOTPLimitRepository(),
+		RefreshTokens: NewMemoryRefreshTokenRepository(),
+		Lockout:       NewMemoryLockoutRepository(users),
+		MFA:           NewMemoryMFARepository(users),
+		PasswordReset: NewMemoryPasswordResetRepository(),
+		Invitations:   NewMemoryInvitationRepository(),
+		Audit:         NewMemoryAuditRepository(),
+	}
+}
+
+// notFound traduce gorm.ErrRecordNotFound a ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,420 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/migrations"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Las dos implementaciones pasan por el mismo contrato. La de GORM necesita
+// Postgres: corre solo con TEST_DB_URL. audit_events no admite DELETE, así
+// que cada caso usa identificadores propios en lugar de vaciar las tablas.
+
+func TestMemoryRepositories(t *testing.T) {
+	testRepositories(t, repository.NewMemory())
+}
+
+func TestGormRepositories(t *testing.T) {
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrate.New(sqlDB, migrations.FS, "auth-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Logf = t.Logf
+	if _, err := runner.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	testRepositories(t, repository.New(db))
+}
+
+func testRepositories(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	now := time.Now()
+
+	newUser := func(t *testing.T, role string) *models.User {
+		t.Helper()
+		email := uuid.NewString() + "@example.com"
+		phone := "+593" + uuid.NewString()[:9]
+		u := &models.User{Email: &email, Phone: &phone, Role: role}
+		if err := repos.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	t.Run("users", func(t *testing.T) {
+		u := newUser(t, "user")
+		if u.ID == uuid.Nil || u.Status != models.UserStatusActive {
+			t.Fatalf("Create did not apply defaults: %+v", u)
+		}
+
+		for name, find := range map[string]func() (*models.User, error){
+			"id":    func() (*models.User, error) { return repos.Users.FindByID(ctx, u.ID) },
+			"email": func() (*models.User, error) { return repos.Users.FindByEmail(ctx, *u.Email) },
+			"phone": func() (*models.User, error) { return repos.Users.FindByPhone(ctx, *u.Phone) },
+		} {
+			got, err := find()
+			if err != nil || got.ID != u.ID {
+				t.Errorf("find by %s = %v, %v", name, got, err)
+			}
+		}
+		if _, err := repos.Users.FindByID(ctx, uuid.New()); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("FindByID(unknown) error = %v, want ErrNotFound", err)
+		}
+
+		dup := &models.User{Email: u.Email, Role: "user"}
+		if err := repos.Users.Create(ctx, dup); !errors.Is(err, repository.ErrDuplicate) {
+			t.Errorf("Create(duplicate email) error = %v, want ErrDuplicate", err)
+		}
+
+		if err := repos.Users.SetRole(ctx, uuid.New(), "admin"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("SetRole(unknown) error = %v, want ErrNotFound", err)
+		}
+		if err := repos.Users.Delete(ctx, u.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Users.Delete(ctx, u.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("second Delete error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("users list", func(t *testing.T) {
+		role := "list-" + uuid.NewString()[:8]
+		var ids []uuid.UUID
+		for i := 0; i < 3; i++ {
+			ids = append(ids, newUser(t, role).ID)
+			time.Sleep(time.Millisecond)
+		}
+		if err := repos.Users.SetStatus(ctx, ids[0], models.UserStatusBanned, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		users, total, err := repos.Users.List(ctx, repository.UserFilter{Role: role, Page: 1, PageSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 || len(users) != 2 || users[0].ID != ids[2] {
+			t.Errorf("List page 1 = %d users (total %d), want the 2 newest of 3", len(users), total)
+		}
+		users, _, err = repos.Users.List(ctx, repository.UserFilter{Role: role, Page: 2, PageSize: 2})
+		if err != nil || len(users) != 1 || users[0].ID != ids[0] {
+			t.Errorf("List page 2 = %v, %v, want the oldest user", users, err)
+		}
+		users, total, err = repos.Users.List(ctx, repository.UserFilter{Role: role, Status: "banned", Page: 1, PageSize: 10})
+		if err != nil || total != 1 || users[0].ID != ids[0] {
+			t.Errorf("List(status=banned) = %v (total %d), %v", users, total, err)
+		}
+	})
+
+	t.Run("suspension", func(t *testing.T) {
+		u := newUser(t, "user")
+		until := now.Add(time.Hour)
+		if err := repos.Users.SetStatus(ctx, u.ID, models.UserStatusSuspended, &until); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Users.LiftSuspension(ctx, u.ID, now); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repos.Users.FindByID(ctx, u.ID); got.Status != models.UserStatusSuspended {
+			t.Errorf("suspension lifted before it expired: %s", got.Status)
+		}
+		if err := repos.Users.LiftSuspension(ctx, u.ID, until.Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repos.Users.FindByID(ctx, u.ID); got.Status != models.UserStatusActive || got.SuspendedUntil != nil {
+			t.Errorf("suspension not lifted: %s until %v", got.Status, got.SuspendedUntil)
+		}
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		u := newUser(t, "user")
+		for want := 1; want <= 2; want++ {
+			if got, err := repos.Lockout.RecordFailure(ctx, u.ID, now); err != nil || got != want {
+				t.Fatalf("RecordFailure = %d, %v, want %d", got, err, want)
+			}
+		}
+		until := now.Add(15 * time.Minute)
+		if err := repos.Lockout.Lock(ctx, &models.AccountLockEvent{UserID: u.ID, Event: models.LockEventLocked, FailedAttempts: 2, LockedUntil: &until}); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := repos.Users.FindByID(ctx, u.ID)
+		if got.FailedLoginAttempts != 0 || got.LockedUntil == nil {
+			t.Errorf("Lock left attempts=%d locked_until=%v", got.FailedLoginAttempts, got.LockedUntil)
+		}
+		if err := repos.Lockout.Reset(ctx, u.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repos.Users.FindByID(ctx, u.ID); got.LockedUntil != nil {
+			t.Errorf("Reset left locked_until=%v", got.LockedUntil)
+		}
+		err := repos.Lockout.Unlock(ctx, &models.AccountLockEvent{UserID: uuid.New(), Event: models.LockEventUnlocked})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Unlock(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("otp", func(t *testing.T) {
+		phone := "+593" + uuid.NewString()[:9]
+		otp := &models.OTPCode{Phone: phone, CodeHash: "hash", MaxAttempts: 2, ExpiresAt: now.Add(5 * time.Minute)}
+		if err := repos.OTPs.Create(ctx, otp); err != nil {
+			t.Fatal(err)
+		}
+		active, err := repos.OTPs.FindActive(ctx, phone, now)
+		if err != nil || active.ID != otp.ID {
+			t.Fatalf("FindActive = %v, %v", active, err)
+		}
+		if _, err := repos.OTPs.FindActive(ctx, phone, otp.ExpiresAt); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("FindActive(after expiry) error = %v, want ErrNotFound", err)
+		}
+
+		for i, want := range []bool{true, true, false} {
+			if ok, err := repos.OTPs.IncrementAttempts(ctx, active); err != nil || ok != want {
+				t.Errorf("IncrementAttempts #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+		if active.Attempts != 2 {
+			t.Errorf("Attempts = %d, want 2", active.Attempts)
+		}
+
+		for i, want := range []bool{true, false} {
+			if ok, err := repos.OTPs.Consume(ctx, otp.ID); err != nil || ok != want {
+				t.Errorf("Consume #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+		if _, err := repos.OTPs.FindActive(ctx, phone, now); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("FindActive(consumed) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("otp limits", func(t *testing.T) {
+		key := "phone:+593" + uuid.NewString()[:9]
+		for _, d := range []time.Duration{-2 * time.Hour, -time.Hour, -time.Minute} {
+			if err := repos.OTPLimits.Record(ctx, models.OTPLimitSend, key, now.Add(d)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repos.OTPLimits.Record(ctx, models.OTPLimitFailedRound, key, now); err != nil {
+			t.Fatal(err)
+		}
+		sends, err := repos.OTPLimits.Since(ctx, models.OTPLimitSend, key, now.Add(-90*time.Minute))
+		if err != nil || len(sends) != 2 || !sends[0].Before(sends[1]) {
+			t.Errorf("Since = %v, %v, want the 2 latest sends, oldest first", sends, err)
+		}
+		if err := repos.OTPLimits.Clear(ctx, models.OTPLimitFailedRound, key); err != nil {
+			t.Fatal(err)
+		}
+		if rounds, _ := repos.OTPLimits.Since(ctx, models.OTPLimitFailedRound, key, time.Time{}); len(rounds) != 0 {
+			t.Errorf("Clear left %d events", len(rounds))
+		}
+		if sends, _ := repos.OTPLimits.Since(ctx, models.OTPLimitSend, key, time.Time{}); len(sends) != 3 {
+			t.Errorf("Clear removed events of another kind: %d left", len(sends))
+		}
+
+		if until, err := repos.OTPLimits.LockedUntil(ctx, key); err != nil || !until.IsZero() {
+			t.Errorf("LockedUntil(unlocked) = %v, %v", until, err)
+		}
+		for _, until := range []time.Time{now.Add(time.Minute), now.Add(time.Hour)} {
+			if err := repos.OTPLimits.Lock(ctx, key, until); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if until, err := repos.OTPLimits.LockedUntil(ctx, key); err != nil || until.Before(now.Add(time.Minute+time.Second)) {
+			t.Errorf("LockedUntil = %v, %v, want the latest lock", until, err)
+		}
+
+		if err := repos.OTPLimits.Purge(ctx, now.Add(-30*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if sends, _ := repos.OTPLimits.Since(ctx, models.OTPLimitSend, key, time.Time{}); len(sends) != 1 {
+			t.Errorf("Purge left %d sends, want 1", len(sends))
+		}
+		if until, _ := repos.OTPLimits.LockedUntil(ctx, key); until.IsZero() {
+			t.Error("Purge removed a lock that has not ended")
+		}
+	})
+
+	t.Run("refresh tokens", func(t *testing.T) {
+		u := newUser(t, "user")
+		family := uuid.New()
+		first := &models.RefreshToken{ID: uuid.New(), UserID: u.ID, FamilyID: family, ExpiresAt: now.Add(time.Hour)}
+		if err := repos.RefreshTokens.Create(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		second := &models.RefreshToken{ID: uuid.New(), UserID: u.ID, FamilyID: family, ExpiresAt: now.Add(time.Hour)}
+		if err := repos.RefreshTokens.Create(ctx, second); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, want := range []bool{true, false} {
+			if ok, err := repos.RefreshTokens.Rotate(ctx, first.ID, second.ID, now); err != nil || ok != want {
+				t.Errorf("Rotate #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+		got, err := repos.RefreshTokens.FindByID(ctx, first.ID)
+		if err != nil || !got.Revoked || got.ReplacedByID == nil || *got.ReplacedByID != second.ID {
+			t.Errorf("rotated token = %+v, %v", got, err)
+		}
+
+		active, err := repos.RefreshTokens.ListActive(ctx, u.ID, now)
+		if err != nil || len(active) != 1 || active[0].ID != second.ID {
+			t.Errorf("ListActive = %v, %v, want only the new token", active, err)
+		}
+		if ok, _ := repos.RefreshTokens.HasActiveFamily(ctx, u.ID, family); !ok {
+			t.Error("HasActiveFamily = false before revocation")
+		}
+		if err := repos.RefreshTokens.RevokeFamily(ctx, family); err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := repos.RefreshTokens.HasActiveFamily(ctx, u.ID, family); ok {
+			t.Error("HasActiveFamily = true after RevokeFamily")
+		}
+	})
+
+	t.Run("mfa", func(t *testing.T) {
+		u := newUser(t, "admin")
+		if err := repos.MFA.SetSecret(ctx, u.ID, "secret"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.MFA.Enable(ctx, u.ID, 10, []string{"code-a", "code-b"}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range []struct {
+			step int64
+			want bool
+		}{{10, false}, {11, true}, {11, false}, {9, false}, {12, true}} {
+			if ok, err := repos.MFA.AdvanceStep(ctx, u.ID, tc.step); err != nil || ok != tc.want {
+				t.Errorf("AdvanceStep(%d) = %v, %v, want %v", tc.step, ok, err, tc.want)
+			}
+		}
+
+		for i, want := range []bool{true, false} {
+			if ok, err := repos.MFA.UseRecoveryCode(ctx, u.ID, "code-a", now); err != nil || ok != want {
+				t.Errorf("UseRecoveryCode #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+		if ok, _ := repos.MFA.UseRecoveryCode(ctx, uuid.New(), "code-b", now); ok {
+			t.Error("UseRecoveryCode accepted another user's code")
+		}
+		if err := repos.MFA.Disable(ctx, u.ID); err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := repos.MFA.UseRecoveryCode(ctx, u.ID, "code-b", now); ok {
+			t.Error("UseRecoveryCode accepted a code after Disable")
+		}
+	})
+
+	t.Run("password reset", func(t *testing.T) {
+		u := newUser(t, "user")
+		valid := &models.PasswordResetToken{UserID: u.ID, TokenHash: uuid.NewString(), ExpiresAt: now.Add(time.Hour)}
+		expired := &models.PasswordResetToken{UserID: u.ID, TokenHash: uuid.NewString(), ExpiresAt: now.Add(-time.Minute)}
+		for _, token := range []*models.PasswordResetToken{valid, expired} {
+			if err := repos.PasswordReset.Create(ctx, token); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repos.PasswordReset.Create(ctx, &models.PasswordResetToken{UserID: u.ID, TokenHash: valid.TokenHash, ExpiresAt: now}); !errors.Is(err, repository.ErrDuplicate) {
+			t.Errorf("Create(duplicate hash) error = %v, want ErrDuplicate", err)
+		}
+		if got, err := repos.PasswordReset.FindByHash(ctx, valid.TokenHash); err != nil || got.ID != valid.ID {
+			t.Errorf("FindByHash = %v, %v", got, err)
+		}
+
+		if ok, _ := repos.PasswordReset.Use(ctx, expired.ID, now); ok {
+			t.Error("Use accepted an expired token")
+		}
+		for i, want := range []bool{true, false} {
+			if ok, err := repos.PasswordReset.Use(ctx, valid.ID, now); err != nil || ok != want {
+				t.Errorf("Use #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+	})
+
+	t.Run("invitations", func(t *testing.T) {
+		admin := newUser(t, "admin")
+		inv := &models.Invitation{ID: uuid.New(), Role: "operador", CreatedByID: admin.ID, ExpiresAt: now.Add(time.Hour)}
+		if err := repos.Invitations.Create(ctx, inv); err != nil {
+			t.Fatal(err)
+		}
+		invited := newUser(t, "operador")
+		for i, want := range []bool{true, false} {
+			if ok, err := repos.Invitations.Consume(ctx, inv.ID, invited.ID, now); err != nil || ok != want {
+				t.Errorf("Consume #%d = %v, %v, want %v", i+1, ok, err, want)
+			}
+		}
+		got, err := repos.Invitations.FindByID(ctx, inv.ID)
+		if err != nil || got.UsedByID == nil || *got.UsedByID != invited.ID {
+			t.Errorf("consumed invitation = %+v, %v", got, err)
+		}
+		if _, err := repos.Invitations.FindByID(ctx, uuid.New()); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("FindByID(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		actor := uuid.New()
+		target := uuid.NewString()
+		for _, e := range []models.AuditEvent{
+			{ActorID: &actor, Action: "admin.role_changed", Target: target, Outcome: "success"},
+			{ActorID: &actor, Action: "admin.user_deleted", Target: target, Outcome: "failure"},
+			{Action: "auth.login", Target: target, Outcome: "success"},
+		} {
+			if err := repos.Audit.Create(ctx, &e); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		tests := []struct {
+			name   string
+			filter repository.AuditFilter
+			want   []string
+		}{
+			{"target", repository.AuditFilter{Target: target}, []string{"auth.login", "admin.user_deleted", "admin.role_changed"}},
+			{"actor", repository.AuditFilter{Target: target, ActorID: &actor}, []string{"admin.user_deleted", "admin.role_changed"}},
+			{"action prefix", repository.AuditFilter{Target: target, Action: "admin.*"}, []string{"admin.user_deleted", "admin.role_changed"}},
+			{"exact action", repository.AuditFilter{Target: target, Action: "auth.login"}, []string{"auth.login"}},
+			{"outcome", repository.AuditFilter{Target: target, Outcome: "failure"}, []string{"admin.user_deleted"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.filter.Page, tt.filter.PageSize = 1, 10
+				events, total, err := repos.Audit.List(ctx, tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != int64(len(tt.want)) || len(events) != len(tt.want) {
+					t.Fatalf("List = %d events (total %d), want %d", len(events), total, len(tt.want))
+				}
+				for i, e := range events {
+					if e.Action != tt.want[i] {
+						t.Errorf("event %d = %s, want %s", i, e.Action, tt.want[i])
+					}
+				}
+			})
+		}
+	})
+}
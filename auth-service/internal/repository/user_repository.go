@@ -1,99 +1,147 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
 
-	"github.com/Andres09xZ/latacunga_clean_app/internal/database"
-	"github.com/Andres09xZ/latacunga_clean_app/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// CreateUser crea un usuario en la base de datos. Recibe la estructura User
-// y la contraseña en claro; la contraseña se hashea antes de guardar.
-func CreateUser(u *models.User, plainPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	hs := string(hash)
-	u.PasswordHash = &hs
-	return database.DB.Create(u).Error
+type userRepository struct {
+	db *gorm.DB
 }
 
-// GetUserByID devuelve el usuario por su ID o nil si no existe.
-func GetUserByID(id uint) (*models.User, error) {
-	var u models.User
-	if err := database.DB.First(&u, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+// NewUserRepository devuelve el UserRepository de Postgres
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
-// GetUserByEmail busca un usuario por email. Devuelve nil,nil si no existe.
-func GetUserByEmail(email string) (*models.User, error) {
-	var u models.User
-	if err := database.DB.Where("email = ?", email).First(&u).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	err := database.Conn(ctx, r.db).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
 	}
-	return &u, nil
+	return err
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(ctx, "email = ?", email)
 }
 
-// UpdateUser actualiza los campos del usuario (suponer que la estructura ya tiene ID).
-// No toca la contraseña; para cambiarla usar ChangePassword.
-func UpdateUser(u *models.User) error {
-	return database.DB.Save(u).Error
+func (r *userRepository) FindByPhone(ctx context.Context, phone string) (*models.User, error) {
+	return r.find(ctx, "phone = ?", phone)
 }
 
-// DeleteUser elimina el usuario por ID (soft delete si GORM está configurado).
-func DeleteUser(id uint) error {
-	return database.DB.Delete(&models.User{}, id).Error
+func (r *userRepository) CreateOperatorProfile(ctx context.Context, profile *models.OperatorProfile) error {
+	return database.Conn(ctx, r.db).Create(profile).Error
 }
 
-// Authenticate verifica email + password. Devuelve el usuario si coincide, nil,nil si
-// credenciales inválidas, o error en caso de fallo de DB.
-func Authenticate(email, plainPassword string) (*models.User, error) {
-	u, err := GetUserByEmail(email)
-	if err != nil {
-		return nil, err
+func (r *userRepository) HasOperatorProfile(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.Conn(ctx, r.db).Model(&models.OperatorProfile{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepository) List(ctx context.Context, f UserFilter) ([]models.User, int64, error) {
+	q := database.Conn(ctx, r.db).Model(&models.User{})
+	if f.Role != "" {
+		q = q.Where("role = ?", f.Role)
 	}
-	if u == nil {
-		// usuario no existe
-		return nil, nil
+	if f.Status != "" {
+		q = q.Where("status = ?", strings.ToUpper(f.Status))
 	}
-	if u.PasswordHash == nil {
-		return nil, nil
+	if f.Email != "" {
+		q = q.Where("email ILIKE ?", "%"+escapeLike(f.Email)+"%")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(*u.PasswordHash), []byte(plainPassword)); err != nil {
-		// contraseña no coincide
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return nil, nil
+	if f.Phone != "" {
+		q = q.Where("phone LIKE ?", "%"+escapeLike(f.Phone)+"%")
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []models.User{}
+	err := q.Order("created_at DESC").
+		Offset((f.Page - 1) * f.PageSize).
+		Limit(f.PageSize).
+		Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepository) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	return r.update(ctx, id, map[string]interface{}{"role": role})
+}
+
+func (r *userRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, until *time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{"status": status, "suspended_until": until})
+}
+
+func (r *userRepository) LiftSuspension(ctx context.Context, id uuid.UUID, now time.Time) error {
+	return database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND status = ? AND suspended_until <= ?", id, models.UserStatusSuspended, now).
+		Updates(map[string]interface{}{"status": models.UserStatusActive, "suspended_until": nil}).Error
+}
+
+func (r *userRepository) SetPassword(ctx context.Context, id uuid.UUID, hash string) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"password_hash":         hash,
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	})
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx := database.Conn(ctx, r.db)
+	for _, dependent := range []interface{}{
+		&models.RefreshToken{},
+		&models.PasswordResetToken{},
+		&models.MFARecoveryCode{},
+		&models.OperatorProfile{},
+	} {
+		if err := tx.Where("user_id = ?", id).Delete(dependent).Error; err != nil {
+			return err
 		}
-		return nil, err
 	}
-	return u, nil
+	res := tx.Delete(&models.User{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-// ChangePassword cambia la contraseña de un usuario (recibe el ID y la nueva contraseña).
-func ChangePassword(id uint, newPlain string) error {
-	u, err := GetUserByID(id)
-	if err != nil {
-		return err
+func (r *userRepository) update(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	res := database.Conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Updates(fields)
+	if res.Error != nil {
+		return res.Error
 	}
-	if u == nil {
-		return gorm.ErrRecordNotFound
+	if res.RowsAffected == 0 {
+		return ErrNotFound
 	}
-	hash, err := bcrypt.GenerateFromPassword([]byte(newPlain), bcrypt.DefaultCost)
-	if err != nil {
-		return err
+	return nil
+}
+
+func (r *userRepository) find(ctx context.Context, query string, arg interface{}) (*models.User, error) {
+	var user models.User
+	if err := database.Conn(ctx, r.db).Where(query, arg).First(&user).Error; err != nil {
+		return nil, notFound(err)
 	}
-	hs := string(hash)
-	u.PasswordHash = &hs
-	return UpdateUser(u)
+	return &user, nil
+}
+
+// escapeLike escapa los comodines de LIKE en las búsquedas parciales
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
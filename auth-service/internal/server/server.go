@@ -1,57 +1,190 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"os"
+	"log/slog"
+	"net/http"
+	"time"
 
 	_ "github.com/Andres09xZ/latacunga_clean_app/auth-service/docs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/database"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/handlers"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/lockout"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mail"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/mfa"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/passwordreset"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/ratelimit"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/sms"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/middleware"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/graceful"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/health"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/metrics"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	files "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
-// Start starts the server
-func Start() {
+const serviceName = "auth-service"
+
+// Start starts the server with a validated configuration (see config.Load)
+// and serves until SIGINT/SIGTERM, then drains in-flight requests
+func Start(cfg *config.Config) {
+	logging.Init(serviceName, cfg.Log)
+
+	// Tracing first so the database plugin and middleware use the exporter
+	shutdownTracing, err := tracing.Init(context.Background(), serviceName, cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+
 	// Initialize database
-	database.InitDB()
+	database.InitDB(cfg.Database)
+	Init(cfg)
+
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		log.Fatal("Failed to get database handle:", err)
+	}
+	probe := health.New(map[string]health.Check{
+		"postgres": health.Postgres(sqlDB),
+	})
+	r := SetupRouter(cfg, probe)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("starting auth service", "addr", srv.Addr)
+	err = graceful.Run(srv, graceful.Options{
+		Timeout:  cfg.ShutdownTimeout,
+		OnSignal: probe.Drain,
+		Cleanup:  []func(context.Context) error{database.Close, shutdownTracing},
+	})
+	if err != nil {
+		log.Fatalf("server failed: %v", err)
+	}
+}
+
+// Init wires the repositories, the command/query bus, signing keys and
+// providers on the open database (see database.InitDB). Tests replace the
+// providers afterwards, e.g. sms.SetSender with an sms.MemorySender.
+func Init(cfg *config.Config) {
+	InitWith(cfg, database.DB, repository.New(database.DB))
+}
+
+// InitWith is Init over the given repositories. db is nil with
+// repository.NewMemory: commands then run without a transaction.
+func InitWith(cfg *config.Config, db *gorm.DB, repos repository.Repositories) {
+	// Repositories and the command/query bus used by the auth handlers
+	auth.InitRepositories(repos, db)
+	handlers.InitBus(db, repos)
 
-	r := gin.Default()
+	// Load JWT signing keys and token lifetimes
+	auth.InitKeys(cfg.JWT, cfg.Development())
+
+	// SMS provider for OTP codes
+	sms.InitSender(cfg.SMS)
+
+	// Mailer for password reset emails
+	mail.InitMailer(cfg.Mail)
+	passwordreset.SetResetURL(cfg.PasswordResetURL)
+
+	// Password login lockout and OTP rate-limit policies
+	lockout.Init(cfg.Lockout)
+	ratelimit.InitOTP(cfg.OTP)
+
+	mfa.SetIssuer(cfg.MFAIssuer)
+}
+
+// SetupRouter builds the HTTP routes; probe serves /livez and /readyz
+func SetupRouter(cfg *config.Config, probe *health.Probe) *gin.Engine {
+	r := gin.New()
+	// c.ClientIP() only honours X-Forwarded-For from these proxies; otherwise
+	// a client could pick the IP the per-IP rate limits and lockout count
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	// Continues the caller's trace and request ID (e.g. report-service's
+	// validate-token call); logs each request as JSON
+	r.Use(logging.Recovery(), logging.RequestIDMiddleware(), tracing.Middleware(serviceName),
+		logging.Middleware(), metrics.Middleware())
 
 	// CORS middleware
-	r.Use(cors.Default())
+	r.Use(corsMiddleware(cfg.CORSOrigins))
+
+	// Liveness and readiness probes, Prometheus metrics
+	probe.Register(r)
+	r.GET("/metrics", metrics.Handler())
+
+	// Public keys for token verification
+	r.GET("/.well-known/jwks.json", handlers.JWKS)
 
 	// Auth routes
 	authGroup := r.Group("/api/v1/auth")
 	{
-		authGroup.POST("/register", handlers.Register)
+		authGroup.POST("/register", middleware.OptionalJWTAuth(handlers.Bus), handlers.Register)
 		authGroup.POST("/login", handlers.Login)
+		authGroup.POST("/refresh", handlers.Refresh)
+		authGroup.POST("/logout", middleware.JWTAuth(handlers.Bus), handlers.Logout)
+		authGroup.POST("/logout/all", middleware.JWTAuth(handlers.Bus), handlers.LogoutAll)
+		authGroup.GET("/sessions", middleware.JWTAuth(handlers.Bus), handlers.ListSessions)
+		authGroup.DELETE("/sessions/:id", middleware.JWTAuth(handlers.Bus), handlers.RevokeSession)
+		authGroup.POST("/validate-token", middleware.JWTAuth(handlers.Bus), handlers.ValidateToken)
 		authGroup.POST("/otp/send", handlers.RequestOTP)
 		authGroup.POST("/otp/verify", handlers.VerifyOTP)
+		authGroup.POST("/password/reset-request", handlers.RequestPasswordReset)
+		authGroup.POST("/password/reset", handlers.ConfirmPasswordReset)
+		authGroup.POST("/mfa/verify", handlers.VerifyMFA)
+		authGroup.POST("/mfa/totp/enroll", middleware.OptionalJWTAuth(handlers.Bus), handlers.EnrollTOTP)
+		authGroup.POST("/mfa/totp/confirm", middleware.OptionalJWTAuth(handlers.Bus), handlers.ConfirmTOTP)
+		authGroup.POST("/mfa/totp/disable", middleware.JWTAuth(handlers.Bus), handlers.DisableTOTP)
 	}
 
-	// Admin routes (example)
+	// Admin routes
 	admin := r.Group("/api/v1/admin")
-	admin.Use(middleware.JWTAuth(), middleware.RequireRole("admin"))
+	admin.Use(middleware.JWTAuth(handlers.Bus))
 	{
-		admin.GET("/reports", func(c *gin.Context) {
-			c.JSON(403, gin.H{"message": "Acceso denegado"})
-		})
+		admin.GET("/users", authz.RequirePermission(authz.UsersRead), handlers.ListUsers)
+		admin.GET("/users/:id", authz.RequirePermission(authz.UsersRead), handlers.GetUser)
+		admin.PUT("/users/:id/role", authz.RequirePermission(authz.UsersManage), handlers.UpdateUserRole)
+		admin.POST("/users/:id/suspend", authz.RequirePermission(authz.UsersManage), handlers.SuspendUser)
+		admin.POST("/users/:id/ban", authz.RequirePermission(authz.UsersManage), handlers.BanUser)
+		admin.POST("/users/:id/reactivate", authz.RequirePermission(authz.UsersManage), handlers.ReactivateUser)
+		admin.DELETE("/users/:id", authz.RequirePermission(authz.UsersManage), handlers.DeleteUser)
+		admin.POST("/users/:id/unlock", authz.RequirePermission(authz.UsersManage), handlers.UnlockUser)
+		admin.GET("/users/:id/sessions", authz.RequirePermission(authz.UsersRead), handlers.ListUserSessions)
+		admin.DELETE("/users/:id/sessions/:sid", authz.RequirePermission(authz.UsersManage), handlers.RevokeUserSession)
+		admin.POST("/invitations", authz.RequirePermission(authz.InvitationsCreate), handlers.CreateInvitation)
+		admin.GET("/mfa/roles", authz.RequirePermission(authz.SecurityManage), handlers.ListMFARolePolicies)
+		admin.PUT("/mfa/roles/:role", authz.RequirePermission(authz.SecurityManage), handlers.SetMFARoleRequirement)
+		admin.GET("/audit", authz.RequirePermission(authz.AuditRead), handlers.ListAuditEvents)
+		admin.GET("/audit/export", authz.RequirePermission(authz.AuditRead), handlers.ExportAuditEvents)
 	}
 
 	// Swagger (especificar URL del spec para evitar problemas de ruta)
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler, ginSwagger.URL("http://localhost:8080/swagger/doc.json")))
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler, ginSwagger.URL(cfg.PublicURL+"/swagger/doc.json")))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	addr := ":" + port
-	log.Printf("starting auth service on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("server failed: %v", err)
+	return r
+}
+
+// corsMiddleware permite los orígenes configurados; "*" equivale a cors.Default()
+func corsMiddleware(origins []string) gin.HandlerFunc {
+	if len(origins) == 1 && origins[0] == "*" {
+		return cors.Default()
 	}
+	c := cors.DefaultConfig()
+	c.AllowOrigins = origins
+	c.AllowHeaders = append(c.AllowHeaders, "Authorization")
+	return cors.New(c)
 }
@@ -0,0 +1,40 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSender agrega cada mensaje como una línea JSON a un archivo.
+// Sirve como buzón local en desarrollo o como cola para un proceso externo.
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSender crea un FileSender que escribe en path
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(ctx context.Context, phone, message string) error {
+	line, err := json.Marshal(Message{Phone: phone, Body: message, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
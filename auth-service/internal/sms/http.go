@@ -0,0 +1,60 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPSender envía mensajes a un gateway SMS con una API JSON
+// (POST {"to", "from", "body"} autenticado con Bearer token).
+type HTTPSender struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+// NewHTTPSender crea un HTTPSender
+func NewHTTPSender(url, token, from string, timeout time.Duration) *HTTPSender {
+	return &HTTPSender{
+		url:    url,
+		token:  token,
+		from:   from,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, phone, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"to":   phone,
+		"from": s.from,
+		"body": message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms: gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms: gateway responded %d", resp.StatusCode)
+	}
+	return nil
+}
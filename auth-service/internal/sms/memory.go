@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message es un mensaje entregado por FileSender o MemorySender
+type Message struct {
+	Phone  string    `json:"phone"`
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// MemorySender guarda los mensajes en memoria. Lo usan las pruebas BDD para
+// leer el código OTP enviado; Err permite simular fallas del proveedor.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+// NewMemorySender crea un MemorySender vacío
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return s.Err
+	}
+	s.messages = append(s.messages, Message{Phone: phone, Body: message, SentAt: time.Now()})
+	return nil
+}
+
+// Messages devuelve una copia de los mensajes enviados
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Last devuelve el último mensaje enviado a phone
+func (s *MemorySender) Last(phone string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Phone == phone {
+			return s.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset borra los mensajes guardados
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
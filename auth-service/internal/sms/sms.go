@@ -0,0 +1,61 @@
+// Package sms envía los mensajes de texto del servicio (códigos OTP).
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/config"
+)
+
+// Sender entrega un mensaje de texto a un teléfono en formato E.164
+type Sender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+var (
+	mu     sync.RWMutex
+	sender Sender
+)
+
+// InitSender configura el proveedor según c.Provider (SMS_PROVIDER):
+//   - "http":   gateway HTTP (SMS_HTTP_URL, SMS_HTTP_TOKEN, SMS_FROM)
+//   - "file":   agrega cada mensaje a SMS_OUTBOX_PATH (por defecto sms_outbox.jsonl), solo APP_ENV=development
+//   - "memory": guarda los mensajes en memoria, solo APP_ENV=development
+//
+// config.Config.Validate ya comprobó los valores.
+func InitSender(c config.SMS) {
+	switch c.Provider {
+	case "http":
+		SetSender(NewHTTPSender(c.HTTPURL, c.HTTPToken, c.From, 10*time.Second))
+	case "file":
+		SetSender(NewFileSender(c.OutboxPath))
+	case "memory":
+		SetSender(NewMemorySender())
+	default:
+		log.Fatalf("unknown SMS_PROVIDER %q", c.Provider)
+	}
+	slog.Info("SMS provider configured", "provider", c.Provider)
+}
+
+// SetSender reemplaza el proveedor en uso
+func SetSender(s Sender) {
+	mu.Lock()
+	defer mu.Unlock()
+	sender = s
+}
+
+// Send entrega el mensaje con el proveedor configurado
+func Send(ctx context.Context, phone, message string) error {
+	mu.RLock()
+	s := sender
+	mu.RUnlock()
+	if s == nil {
+		return fmt.Errorf("sms: no sender configured")
+	}
+	return s.Send(ctx, phone, message)
+}
@@ -0,0 +1,182 @@
+package sms_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/sms"
+)
+
+const phone = "+593987654321"
+
+func TestHTTPSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		wantErr string
+	}{
+		{name: "delivered", token: "gw-token", status: http.StatusAccepted},
+		{name: "without token", status: http.StatusOK},
+		{name: "gateway rejects", token: "gw-token", status: http.StatusBadRequest, wantErr: "gateway responded 400"},
+		{name: "gateway down", token: "gw-token", status: http.StatusBadGateway, wantErr: "gateway responded 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				method, contentType, auth string
+				body                      map[string]string
+			}
+			gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got.method = r.Method
+				got.contentType = r.Header.Get("Content-Type")
+				got.auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&got.body); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer gw.Close()
+
+			err := sms.NewHTTPSender(gw.URL, tt.token, "LATACUNGA", time.Second).Send(context.Background(), phone, "tu código es 123456")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Send = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Send = %v, want %q", err, tt.wantErr)
+			}
+
+			if got.method != http.MethodPost || got.contentType != "application/json" {
+				t.Errorf("request = %s %s, want POST application/json", got.method, got.contentType)
+			}
+			wantAuth := ""
+			if tt.token != "" {
+				wantAuth = "Bearer " + tt.token
+			}
+			if got.auth != wantAuth {
+				t.Errorf("Authorization = %q, want %q", got.auth, wantAuth)
+			}
+			want := map[string]string{"to": phone, "from": "LATACUNGA", "body": "tu código es 123456"}
+			for k, v := range want {
+				if got.body[k] != v {
+					t.Errorf("body[%s] = %q, want %q", k, got.body[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPSenderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer gw.Close()
+	defer close(release)
+
+	err := sms.NewHTTPSender(gw.URL, "", "", 50*time.Millisecond).Send(context.Background(), phone, "hola")
+	if err == nil || !strings.Contains(err.Error(), "gateway request failed") {
+		t.Fatalf("Send to a hung gateway = %v, want a request error", err)
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	s := sms.NewFileSender(path)
+	for _, body := range []string{"primero", "segundo"} {
+		if err := s.Send(context.Background(), phone, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Los mensajes llevan códigos OTP: solo el dueño puede leer el archivo
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("outbox permissions = %o, want 600", perm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var msgs []sms.Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m sms.Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		msgs = append(msgs, m)
+	}
+	if len(msgs) != 2 || msgs[0].Body != "primero" || msgs[1].Body != "segundo" || msgs[1].Phone != phone || msgs[1].SentAt.IsZero() {
+		t.Errorf("outbox = %+v, want both messages in order", msgs)
+	}
+}
+
+func TestMemorySender(t *testing.T) {
+	s := sms.NewMemorySender()
+	ctx := context.Background()
+	other := "+593999999999"
+	for _, m := range []struct{ phone, body string }{{phone, "uno"}, {other, "dos"}, {phone, "tres"}} {
+		if err := s.Send(ctx, m.phone, m.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if last, ok := s.Last(phone); !ok || last.Body != "tres" {
+		t.Errorf("Last(%s) = %+v, %t; want tres", phone, last, ok)
+	}
+	if _, ok := s.Last("+593000000000"); ok {
+		t.Error("Last found a message for an unknown phone")
+	}
+
+	// Messages devuelve una copia
+	msgs := s.Messages()
+	msgs[0].Body = "cambiado"
+	if s.Messages()[0].Body != "uno" {
+		t.Error("modifying Messages() changed the stored messages")
+	}
+
+	s.Err = errors.New("provider down")
+	if err := s.Send(ctx, phone, "cuatro"); !errors.Is(err, s.Err) {
+		t.Errorf("Send with Err set = %v, want %v", err, s.Err)
+	}
+	if n := len(s.Messages()); n != 3 {
+		t.Errorf("a failed send was stored: %d messages", n)
+	}
+
+	s.Reset()
+	if n := len(s.Messages()); n != 0 {
+		t.Errorf("%d messages after Reset", n)
+	}
+}
+
+func TestSend(t *testing.T) {
+	sms.SetSender(nil)
+	if err := sms.Send(context.Background(), phone, "hola"); err == nil {
+		t.Fatal("Send without a sender returned nil")
+	}
+
+	s := sms.NewMemorySender()
+	sms.SetSender(s)
+	t.Cleanup(func() { sms.SetSender(nil) })
+	if err := sms.Send(context.Background(), phone, "hola"); err != nil {
+		t.Fatal(err)
+	}
+	if last, ok := s.Last(phone); !ok || last.Body != "hola" {
+		t.Errorf("message not delivered through the configured sender: %+v", last)
+	}
+}
@@ -0,0 +1,146 @@
+// Package users implementa la administración de cuentas: listado con filtros,
+// cambio de rol, suspensión y eliminación. Cada cambio cierra las sesiones
+// del usuario afectado para que vuelva a autenticarse con sus datos nuevos.
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrNotFound     = errors.New("user not found")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrSelf         = errors.New("admins cannot modify their own account")
+	ErrInsufficient = errors.New("managing admin accounts requires users:manage_admins")
+)
+
+// Filter son los criterios de ListUsers. Email y Phone buscan coincidencias parciales.
+type Filter = repository.UserFilter
+
+// Page es una página de resultados de ListUsers
+type Page struct {
+	Data     []models.User `json:"data"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int64         `json:"total"`
+}
+
+// Actor es el administrador que hace el cambio
+type Actor struct {
+	ID   uuid.UUID
+	Role string
+}
+
+// List devuelve los usuarios que cumplen el filtro, los más recientes primero
+func List(ctx context.Context, repo repository.UserRepository, f Filter) (Page, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = DefaultPageSize
+	}
+	if f.PageSize > MaxPageSize {
+		f.PageSize = MaxPageSize
+	}
+
+	page := Page{Page: f.Page, PageSize: f.PageSize, Data: []models.User{}}
+	data, total, err := repo.List(ctx, f)
+	if err != nil {
+		return page, err
+	}
+	page.Data, page.Total = data, total
+	return page, nil
+}
+
+// Get busca un usuario por ID
+func Get(ctx context.Context, repo repository.UserRepository, id uuid.UUID) (models.User, error) {
+	user, err := repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
+	}
+	return *user, nil
+}
+
+// UpdateRole cambia el rol del usuario. Al pasar a operador se le crea el
+// perfil de operador si no lo tiene.
+func UpdateRole(ctx context.Context, repo repository.UserRepository, actor Actor, id uuid.UUID, role string) (models.User, error) {
+	if !authz.Valid(role) {
+		return models.User{}, ErrInvalidRole
+	}
+	if !authz.CanGrant(actor.Role, role) {
+		return models.User{}, ErrInsufficient
+	}
+
+	return update(ctx, repo, actor, id, func(user *models.User) error {
+		if role == "operador" {
+			exists, err := repo.HasOperatorProfile(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				if err := repo.CreateOperatorProfile(ctx, &models.OperatorProfile{UserID: user.ID}); err != nil {
+					return err
+				}
+			}
+		}
+		user.Role = role
+		return repo.SetRole(ctx, user.ID, role)
+	})
+}
+
+// SetStatus cambia el estado de la cuenta: ACTIVE la reactiva, SUSPENDED la
+// suspende hasta until (nil = indefinidamente) y BANNED la bloquea.
+func SetStatus(ctx context.Context, repo repository.UserRepository, actor Actor, id uuid.UUID, status string, until *time.Time) (models.User, error) {
+	if status != models.UserStatusSuspended {
+		until = nil
+	}
+	return update(ctx, repo, actor, id, func(user *models.User) error {
+		user.Status = status
+		user.SuspendedUntil = until
+		return repo.SetStatus(ctx, user.ID, status, until)
+	})
+}
+
+// Delete elimina la cuenta y los datos que dependen de ella
+func Delete(ctx context.Context, repo repository.UserRepository, actor Actor, id uuid.UUID) error {
+	_, err := update(ctx, repo, actor, id, func(user *models.User) error {
+		return repo.Delete(ctx, user.ID)
+	})
+	return err
+}
+
+// update aplica fn al usuario y luego cierra sus sesiones, todo en la
+// transacción que lleve ctx
+func update(ctx context.Context, repo repository.UserRepository, actor Actor, id uuid.UUID, fn func(user *models.User) error) (models.User, error) {
+	if actor.ID == id {
+		return models.User{}, ErrSelf
+	}
+
+	user, err := Get(ctx, repo, id)
+	if err != nil {
+		return user, err
+	}
+	if !authz.CanGrant(actor.Role, user.Role) {
+		return user, ErrInsufficient
+	}
+	if err := fn(&user); err != nil {
+		return user, err
+	}
+	return user, auth.RevokeUserTokens(ctx, id)
+}
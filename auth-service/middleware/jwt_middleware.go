@@ -2,50 +2,56 @@ package middleware
 
 import (
 	"net/http"
-	"strings"
 
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/account"
 	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/cqrs/queries"
+	"github.com/Andres09xZ/latacunga_clean_app/auth-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	AuthorizationHeader = "Authorization"
-	BearerPrefix        = "bearer"
-	ErrorMissingAuth    = "missing authorization header"
-	ErrorInvalidAuth    = "invalid authorization header"
-	ErrorInvalidToken   = "invalid token"
-)
-
-func JWTAuth() gin.HandlerFunc {
+// JWTAuth exige un access token válido cuyo usuario siga existiendo y activo
+// (queries.ValidateTokenQuery en bus)
+func JWTAuth(bus *cqrs.Bus) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr, err := extractTokenFromHeader(c)
+		tokenStr, err := authz.BearerToken(c.Request)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrorMissingAuth})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authz.ErrorMissingAuth})
 			return
 		}
 
 		claims, err := auth.ValidateToken(tokenStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrorInvalidToken})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authz.ErrorInvalidToken})
+			return
+		}
+
+		// Suspended, banned or deleted accounts lose access immediately
+		if _, err := cqrs.Ask[models.User](c.Request.Context(), bus, queries.ValidateTokenQuery{UserID: claims.UserID}); err != nil {
+			if code := account.Code(err); code != "" {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": code})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authz.ErrorInvalidToken})
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("role", claims.Role)
+		authz.SetClaims(c, claims)
 		c.Next()
 	}
 }
 
-func extractTokenFromHeader(c *gin.Context) (string, error) {
-	authHeader := c.GetHeader(AuthorizationHeader)
-	if authHeader == "" {
-		return "", http.ErrNoLocation
-	}
-
-	parts := strings.Fields(authHeader)
-	if len(parts) != 2 || strings.ToLower(parts[0]) != BearerPrefix {
-		return "", http.ErrNoLocation
+// OptionalJWTAuth autentica la petición solo si trae Authorization; sin
+// cabecera continúa como anónima y con un token inválido responde 401
+func OptionalJWTAuth(bus *cqrs.Bus) gin.HandlerFunc {
+	jwtAuth := JWTAuth(bus)
+	return func(c *gin.Context) {
+		if c.GetHeader(authz.AuthorizationHeader) == "" {
+			c.Next()
+			return
+		}
+		jwtAuth(c)
 	}
-
-	return parts[1], nil
 }
@@ -0,0 +1,9 @@
+-- Migration: Add id_user column to users table
+-- Esquema anterior a la v2: en una base nueva users todavía no existe
+DO $$
+BEGIN
+  IF to_regclass('users') IS NOT NULL THEN
+    ALTER TABLE users ADD COLUMN IF NOT EXISTS id_user VARCHAR(100) UNIQUE;
+    CREATE INDEX IF NOT EXISTS idx_users_id_user ON users(id_user);
+  END IF;
+END$$;
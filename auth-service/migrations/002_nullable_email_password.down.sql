@@ -0,0 +1,3 @@
+-- migrate:irreversible
+-- Las cuentas de ciudadanos (OTP) no tienen email ni contraseña: volver a
+-- exigir NOT NULL obligaría a borrarlas.
@@ -1,23 +0,0 @@
--- Migration: Add refresh_tokens and otp_requests tables
-CREATE TABLE refresh_tokens (
-    id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    token VARCHAR(512) NOT NULL UNIQUE,
-    expires_at TIMESTAMP NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    revoked BOOLEAN DEFAULT FALSE
-);
-
-CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
-CREATE INDEX idx_refresh_tokens_token ON refresh_tokens(token);
-
-CREATE TABLE otp_requests (
-    id SERIAL PRIMARY KEY,
-    phone VARCHAR(20) NOT NULL,
-    code VARCHAR(10) NOT NULL,
-    expires_at TIMESTAMP NOT NULL,
-    attempts INTEGER DEFAULT 0,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE INDEX idx_otp_requests_phone ON otp_requests(phone);
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Migration: Add otp_requests table
+-- La tabla refresh_tokens de esta versión (ids enteros) quedó reemplazada
+-- por la de 005_refresh_token_families y ya no se crea aquí.
+CREATE TABLE IF NOT EXISTS otp_requests (
+    id SERIAL PRIMARY KEY,
+    phone VARCHAR(20) NOT NULL,
+    code VARCHAR(10) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_requests_phone ON otp_requests(phone);
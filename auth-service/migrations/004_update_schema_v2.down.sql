@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS operator_profiles_set_updated_at ON operator_profiles;
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+DROP FUNCTION IF EXISTS trg_set_updated_at();
+
+DROP TABLE IF EXISTS otp_codes;
+DROP TABLE IF EXISTS operator_profiles;
+DROP TABLE IF EXISTS users CASCADE;
+DROP TYPE IF EXISTS user_role;
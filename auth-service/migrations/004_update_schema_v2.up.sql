@@ -1,8 +1,6 @@
 -- Update to schema Version 2
--- Drop old tables if exist
-DROP TABLE IF EXISTS otp_codes CASCADE;
-DROP TABLE IF EXISTS refresh_tokens CASCADE;
-DROP TABLE IF EXISTS users CASCADE;
+-- Las tablas existentes (creadas por AutoMigrate en versiones anteriores) se
+-- conservan; solo se crean las que falten.
 
 -- Extensions
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
@@ -73,4 +71,4 @@ BEGIN
     CREATE TRIGGER operator_profiles_set_updated_at BEFORE UPDATE ON operator_profiles
     FOR EACH ROW EXECUTE FUNCTION trg_set_updated_at();
   END IF;
-END$$;
\ No newline at end of file
+END$$;
@@ -0,0 +1,15 @@
+-- Migration: Registration invitations and account creator
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_by_id UUID;
+
+CREATE TABLE IF NOT EXISTS invitations (
+  id UUID PRIMARY KEY,                  -- jti del token de invitación
+  email TEXT,                           -- NULL: cualquier email
+  role TEXT NOT NULL,
+  created_by_id UUID NOT NULL,          -- admin que emitió la invitación
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  used_by_id UUID,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_created_by_id ON invitations (created_by_id);
@@ -0,0 +1,22 @@
+-- Migration: TOTP two-factor authentication
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;  -- evita reutilizar un código
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,       -- SHA-256 del código
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
+
+-- Roles que exigen 2FA
+CREATE TABLE IF NOT EXISTS mfa_role_policies (
+  role TEXT PRIMARY KEY,
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_by_id UUID,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,7 @@
+-- Migration: account status enforcement (ACTIVE, SUSPENDED, BANNED)
+UPDATE users SET status = 'ACTIVE' WHERE status IS NULL OR status = '';
+ALTER TABLE users ALTER COLUMN status SET DEFAULT 'ACTIVE';
+ALTER TABLE users ALTER COLUMN status SET NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ;  -- NULL = suspensión indefinida
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users (status);
@@ -0,0 +1,11 @@
+ALTER TABLE operator_profiles DROP COLUMN IF EXISTS badge_id;
+
+DROP INDEX IF EXISTS idx_users_role;
+
+-- El enum de la v2 no tiene trabajador ni super_admin: esas cuentas pasan al
+-- rol inferior más cercano que sí existe, nunca a uno con más permisos
+UPDATE users SET role = 'user' WHERE role = 'trabajador';
+UPDATE users SET role = 'admin' WHERE role = 'super_admin';
+
+CREATE TYPE user_role AS ENUM ('user','operador','admin');
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::user_role;
@@ -0,0 +1,9 @@
+-- Migration: roles as TEXT and operator badge
+-- El enum user_role de la v2 no admite trabajador ni super_admin; los roles
+-- válidos los define shared/authz.
+ALTER TABLE users ALTER COLUMN role TYPE TEXT USING role::text;
+DROP TYPE IF EXISTS user_role;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
+
+ALTER TABLE operator_profiles ADD COLUMN IF NOT EXISTS badge_id TEXT;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS otp_locks;
+DROP TABLE IF EXISTS otp_limit_events;
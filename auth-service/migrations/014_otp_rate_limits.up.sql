@@ -0,0 +1,15 @@
+-- Migration: OTP rate limits shared by every instance of the service
+CREATE TABLE IF NOT EXISTS otp_limit_events (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,                    -- send | verify_failed | failed_round
+  key TEXT NOT NULL,                     -- phone:+593..., ip:203.0.113.7
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_limit_events_kind_key ON otp_limit_events (kind, key, created_at);
+CREATE INDEX IF NOT EXISTS idx_otp_limit_events_created_at ON otp_limit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS otp_locks (
+  key TEXT PRIMARY KEY,                  -- phone:+593...
+  locked_until TIMESTAMPTZ NOT NULL
+);
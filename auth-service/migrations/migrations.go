@@ -0,0 +1,8 @@
+// Package migrations embebe los archivos SQL del esquema de auth-service.
+// Ver shared/migrate para el formato y cómo se aplican.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
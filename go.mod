@@ -1,3 +1,7 @@
 module github.com/Andres09xZ/latacunga_clean_app
 
 go 1.24.5
+
+require github.com/Andres09xZ/latacunga_clean_app/shared v0.0.0-00010101000000-000000000000
+
+replace github.com/Andres09xZ/latacunga_clean_app/shared => ./shared
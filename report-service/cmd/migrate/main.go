@@ -0,0 +1,30 @@
+// Command migrate aplica o revierte las migraciones de report-service.
+//
+//	go run ./cmd/migrate [-dry-run] [up | down -steps N | status]
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database.Connect(cfg.Database.URL)
+	runner, err := database.NewMigrator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := migrate.RunCLI(context.Background(), runner, os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signer es una llave de firma de auth-service con su kid
+type signer struct {
+	kid    string
+	method jwt.SigningMethod
+	key    interface{}
+	jwk    jwk
+}
+
+func rsaSigner(t *testing.T, kid string) signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer{kid: kid, method: jwt.SigningMethodRS256, key: key, jwk: jwk{
+		Kty: "RSA", Kid: kid, Alg: "RS256",
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}
+}
+
+func edSigner(t *testing.T, kid string) signer {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer{kid: kid, method: jwt.SigningMethodEdDSA, key: priv, jwk: jwk{
+		Kty: "OKP", Kid: kid, Alg: "EdDSA", Crv: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(pub),
+	}}
+}
+
+// sign firma un token con la llave s; tokenType y exp varían por caso
+func (s signer) sign(t *testing.T, tokenType string, exp time.Duration) string {
+	t.Helper()
+	token := jwt.NewWithClaims(s.method, authz.Claims{
+		UserID:    "7f1c0d4e-0000-0000-0000-000000000001",
+		Role:      authz.RoleOperador,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+		},
+	})
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// jwksServer publica un JWKS que el test puede cambiar y cuenta las descargas
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []jwk
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, signers ...signer) *jwksServer {
+	s := &jwksServer{}
+	s.publish(signers...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string][]jwk{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(signers ...signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+	for _, sg := range signers {
+		s.keys = append(s.keys, sg.jwk)
+	}
+}
+
+func newCache(t *testing.T, srv *jwksServer) *KeyCache {
+	keys := NewKeyCache(srv.URL, srv.Client())
+	keys.Start(t.Context(), time.Hour)
+	return keys
+}
+
+func TestVerify(t *testing.T) {
+	rs, ed := rsaSigner(t, "rsa-1"), edSigner(t, "ed-1")
+	srv := newJWKSServer(t, rs, ed)
+	verifier := NewVerifier(newCache(t, srv))
+
+	// Firmado con la llave Ed25519 pero anunciando el kid de la RSA
+	mismatch := signer{kid: rs.kid, method: ed.method, key: ed.key}
+	// Firmado con una llave que auth-service no publica
+	unpublished := rsaSigner(t, "rsa-1")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "RS256 access token", token: rs.sign(t, authz.TokenTypeAccess, time.Hour)},
+		{name: "EdDSA access token", token: ed.sign(t, authz.TokenTypeAccess, time.Hour)},
+		{name: "refresh token", token: rs.sign(t, authz.TokenTypeRefresh, time.Hour), wantErr: ErrWrongTokenType},
+		{name: "expired", token: rs.sign(t, authz.TokenTypeAccess, -time.Minute), wantErr: jwt.ErrTokenExpired},
+		{name: "alg does not match the kid", token: mismatch.sign(t, authz.TokenTypeAccess, time.Hour), wantErr: jwt.ErrTokenUnverifiable},
+		{name: "unknown kid", token: edSigner(t, "ed-9").sign(t, authz.TokenTypeAccess, time.Hour), wantErr: ErrUnknownKey},
+		{name: "wrong key for the kid", token: unpublished.sign(t, authz.TokenTypeAccess, time.Hour), wantErr: jwt.ErrTokenSignatureInvalid},
+		{
+			name:    "HMAC token",
+			token:   signer{kid: rs.kid, method: jwt.SigningMethodHS256, key: []byte("secret")}.sign(t, authz.TokenTypeAccess, time.Hour),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{name: "garbage", token: "not-a-jwt", wantErr: jwt.ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.Role != authz.RoleOperador {
+					t.Errorf("role = %q", claims.Role)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Un kid desconocido dispara un refresco: una llave nueva de auth-service se
+// acepta sin esperar al refresco periódico
+func TestKeyCacheRefreshOnMiss(t *testing.T) {
+	old, next := rsaSigner(t, "k1"), edSigner(t, "k2")
+	srv := newJWKSServer(t, old)
+	verifier := NewVerifier(newCache(t, srv))
+
+	srv.publish(old, next)
+	if _, err := verifier.Verify(context.Background(), next.sign(t, authz.TokenTypeAccess, time.Hour)); err != nil {
+		t.Fatalf("token with the new kid: %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("%d JWKS fetches, want the initial one and one refresh", got)
+	}
+	// Las llaves conocidas no provocan descargas
+	if _, err := verifier.Verify(context.Background(), old.sign(t, authz.TokenTypeAccess, time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("%d JWKS fetches after a known kid, want 2", got)
+	}
+}
+
+// Los kids inventados no pueden forzar una descarga del JWKS por petición
+func TestKeyCacheRefreshRateLimit(t *testing.T) {
+	srv := newJWKSServer(t, rsaSigner(t, "k1"))
+	keys := newCache(t, srv)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keys.Key(context.Background(), "forged", "RS256"); !errors.Is(err, ErrUnknownKey) {
+				t.Errorf("err = %v, want ErrUnknownKey", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := keys.Key(context.Background(), "forged-again", "RS256"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want ErrUnknownKey", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("%d JWKS fetches for %d unknown kids, want the initial one and one refresh", got, n+1)
+	}
+
+	// Pasado el intervalo mínimo se vuelve a refrescar
+	keys.mu.Lock()
+	keys.lastAttempt = time.Now().Add(-keys.minRefreshInterval)
+	keys.mu.Unlock()
+	if _, err := keys.Key(context.Background(), "forged", "RS256"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want ErrUnknownKey", err)
+	}
+	if got := srv.fetches.Load(); got != 3 {
+		t.Errorf("%d JWKS fetches after the interval, want 3", got)
+	}
+}
+
+// Tras la rotación, un refresco deja de aceptar la llave retirada del JWKS
+func TestKeyCacheRotation(t *testing.T) {
+	old, next := rsaSigner(t, "k1"), rsaSigner(t, "k2")
+	srv := newJWKSServer(t, old)
+	keys := newCache(t, srv)
+	verifier := NewVerifier(keys)
+	oldToken := old.sign(t, authz.TokenTypeAccess, time.Hour)
+
+	srv.publish(next)
+	if err := keys.refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), next.sign(t, authz.TokenTypeAccess, time.Hour)); err != nil {
+		t.Errorf("token with the new key: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token with the retired key: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+// Si auth-service no responde se conservan las llaves ya cargadas
+func TestKeyCacheKeepsKeysOnFailure(t *testing.T) {
+	key := rsaSigner(t, "k1")
+	srv := newJWKSServer(t, key)
+	keys := newCache(t, srv)
+	srv.Close()
+
+	if err := keys.refresh(context.Background()); err == nil {
+		t.Fatal("refresh against a stopped server succeeded")
+	}
+	if _, err := NewVerifier(keys).Verify(context.Background(), key.sign(t, authz.TokenTypeAccess, time.Hour)); err != nil {
+		t.Errorf("cached key lost after a failed refresh: %v", err)
+	}
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwk     jwk
+		wantAlg string
+	}{
+		{name: "RSA", jwk: rsaSigner(t, "r").jwk, wantAlg: "RS256"},
+		{name: "Ed25519", jwk: edSigner(t, "e").jwk, wantAlg: "EdDSA"},
+		{name: "unsupported curve", jwk: jwk{Kty: "OKP", Crv: "X25519", X: "AAAA"}},
+		{name: "short Ed25519 key", jwk: jwk{Kty: "OKP", Crv: "Ed25519", X: "AAAA"}},
+		{name: "EC key", jwk: jwk{Kty: "EC", Crv: "P-256"}},
+		{name: "bad base64", jwk: jwk{Kty: "RSA", N: "!!", E: "AQAB"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.jwk.publicKey()
+			if tt.wantAlg == "" {
+				if err == nil {
+					t.Fatalf("accepted %+v", tt.jwk)
+				}
+				return
+			}
+			if err != nil || key.alg != tt.wantAlg {
+				t.Fatalf("alg = %q, err = %v, want %s", key.alg, err, tt.wantAlg)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey se devuelve cuando el kid del token no está en el JWKS de auth-service
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type publicKey struct {
+	alg string
+	key interface{}
+}
+
+// KeyCache mantiene en memoria las llaves públicas publicadas por auth-service
+// en /.well-known/jwks.json. Se refresca periódicamente y cuando llega un token
+// con un kid desconocido (rotación de llaves).
+type KeyCache struct {
+	url    string
+	client *http.Client
+
+	// minRefreshInterval limita los refrescos provocados por kids desconocidos
+	minRefreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]publicKey
+	lastAttempt time.Time // último refresco por kid desconocido
+	refreshing  chan struct{}
+}
+
+// NewKeyCache crea una caché vacía para el JWKS publicado en url
+func NewKeyCache(url string, client *http.Client) *KeyCache {
+	return &KeyCache{
+		url:                url,
+		client:             client,
+		minRefreshInterval: 30 * time.Second,
+		keys:               map[string]publicKey{},
+	}
+}
+
+// Start carga las llaves y las refresca cada interval hasta que ctx termine.
+// Un fallo en la carga inicial no es fatal: auth-service puede arrancar después.
+func (k *KeyCache) Start(ctx context.Context, interval time.Duration) {
+	if err := k.refresh(ctx); err != nil {
+		slog.Error("failed to fetch JWKS", "url", k.url, "error", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.refresh(ctx); err != nil {
+					slog.Warn("failed to refresh JWKS", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Key devuelve la llave pública para kid. Si no está en caché lanza un
+// refresco en segundo plano y espera a que termine o a que ctx expire.
+func (k *KeyCache) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	if key, ok := k.lookup(kid); ok {
+		return matchAlg(key, alg)
+	}
+
+	select {
+	case <-k.triggerRefresh():
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if key, ok := k.lookup(kid); ok {
+		return matchAlg(key, alg)
+	}
+	return nil, ErrUnknownKey
+}
+
+func matchAlg(key publicKey, alg string) (interface{}, error) {
+	if key.alg != alg {
+		return nil, fmt.Errorf("algorithm %s does not match key (%s)", alg, key.alg)
+	}
+	return key.key, nil
+}
+
+func (k *KeyCache) lookup(kid string) (publicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// triggerRefresh inicia un refresco si no hay uno en curso y devuelve un canal
+// que se cierra al terminar. Las peticiones concurrentes comparten el mismo refresco.
+func (k *KeyCache) triggerRefresh() <-chan struct{} {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.refreshing != nil {
+		return k.refreshing
+	}
+	if time.Since(k.lastAttempt) < k.minRefreshInterval {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	done := make(chan struct{})
+	k.refreshing = done
+	k.lastAttempt = time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := k.refresh(ctx); err != nil {
+			slog.Warn("failed to refresh JWKS", "error", err)
+		}
+		k.mu.Lock()
+		k.refreshing = nil
+		k.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (k *KeyCache) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, j := range set.Keys {
+		key, err := j.publicKey()
+		if err != nil {
+			slog.Warn("skipping JWK", "kid", j.Kid, "error", err)
+			continue
+		}
+		keys[j.Kid] = key
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+func (j jwk) publicKey() (publicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return publicKey{}, err
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return publicKey{alg: "RS256", key: pub}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return publicKey{}, fmt.Errorf("unsupported curve %s", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return publicKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("invalid Ed25519 key size")
+		}
+		return publicKey{alg: "EdDSA", key: ed25519.PublicKey(x)}, nil
+	default:
+		return publicKey{}, fmt.Errorf("unsupported key type %s", j.Kty)
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrWrongTokenType se devuelve cuando se presenta un refresh token como access token
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// Verifier valida access tokens localmente con las llaves de la caché
+type Verifier struct {
+	keys *KeyCache
+}
+
+// NewVerifier crea un Verifier sobre la caché de llaves
+func NewVerifier(keys *KeyCache) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// Verify comprueba firma, expiración y tipo del token
+func (v *Verifier) Verify(ctx context.Context, tokenStr string) (*authz.Claims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
+	token, err := parser.ParseWithClaims(tokenStr, &authz.Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*authz.Claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if claims.TokenType != authz.TokenTypeAccess {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
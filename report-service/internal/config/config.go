@@ -0,0 +1,94 @@
+// Package config define la configuración tipada de report-service. Se carga
+// una sola vez al arrancar (Load) y cada paquete recibe su parte.
+package config
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
+)
+
+// EnvFiles son los .env que se prueban en orden al arrancar
+var EnvFiles = []string{".env", "../.env", "../../.env"}
+
+// Config es la configuración completa del servicio
+type Config struct {
+	Port int `yaml:"port" env:"PORT" default:"8081"`
+	// ShutdownTimeout es el plazo para drenar peticiones y eventos al recibir SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"15s"`
+	// TrustedProxies son los proxies (IP o CIDR) cuyo X-Forwarded-For se
+	// acepta como IP del cliente; vacío no confía en ninguno
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	Database Database `yaml:"database"`
+	Auth     Auth     `yaml:"auth"`
+	// RabbitMQURL es el broker de los eventos de reportes; vacío no los emite
+	RabbitMQURL string `yaml:"rabbitmq_url" env:"RABBITMQ_URL"`
+
+	Log     logging.Config `yaml:"log"`
+	Tracing tracing.Config `yaml:"tracing"`
+}
+
+// Database configura la conexión a Postgres
+type Database struct {
+	URL string `yaml:"url" env:"DB_URL"`
+	// Migrate aplica las migraciones pendientes al arrancar (DB_MIGRATE=off lo desactiva)
+	Migrate bool `yaml:"migrate" env:"DB_MIGRATE" default:"on"`
+}
+
+// Auth configura la validación de tokens contra auth-service (ver middleware.JWTAuth)
+type Auth struct {
+	ServiceURL string `yaml:"service_url" env:"AUTH_SERVICE_URL"`
+	// JWKSURL por defecto es ServiceURL + "/.well-known/jwks.json"
+	JWKSURL             string        `yaml:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env:"JWKS_REFRESH_INTERVAL" default:"15m"`
+	// Introspection consulta además validate-token en las rutas sensibles a la
+	// revocación (ver middleware.Introspect). Sin ella solo se verifica la
+	// firma: el token de un usuario suspendido, baneado o eliminado sigue
+	// aceptándose hasta que vence (JWT_EXPIRATION_HOURS en auth-service). Aun
+	// con introspección, cerrar una sesión solo revoca su refresh token: el
+	// access token ya emitido vale hasta que vence.
+	Introspection bool `yaml:"introspection" env:"AUTH_INTROSPECTION" default:"off"`
+	// IntrospectionCacheTTL es cuánto se reutiliza la respuesta de validate-token
+	// para un mismo token
+	IntrospectionCacheTTL time.Duration `yaml:"introspection_cache_ttl" env:"AUTH_INTROSPECTION_CACHE_TTL" default:"30s"`
+}
+
+// Load lee .env, CONFIG_FILE y el entorno, y valida el resultado
+func Load() (*Config, error) {
+	var c Config
+	if err := config.Load(&c, config.Options{EnvFiles: EnvFiles}); err != nil {
+		return nil, err
+	}
+	if c.Auth.JWKSURL == "" && c.Auth.ServiceURL != "" {
+		c.Auth.JWKSURL = strings.TrimSuffix(c.Auth.ServiceURL, "/") + "/.well-known/jwks.json"
+	}
+	return &c, nil
+}
+
+// Validate comprueba todos los valores y devuelve todos los errores juntos
+func (c *Config) Validate() error {
+	errs := []error{
+		config.Port("PORT", c.Port),
+		config.Positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout),
+		config.Proxies("TRUSTED_PROXIES", c.TrustedProxies),
+		config.Required("DB_URL", c.Database.URL),
+		config.Required("AUTH_SERVICE_URL", c.Auth.ServiceURL),
+		config.URL("AUTH_SERVICE_URL", c.Auth.ServiceURL),
+		config.URL("AUTH_JWKS_URL", c.Auth.JWKSURL),
+		config.Positive("JWKS_REFRESH_INTERVAL", c.Auth.JWKSRefreshInterval),
+		config.Positive("AUTH_INTROSPECTION_CACHE_TTL", c.Auth.IntrospectionCacheTTL),
+		config.URL("RABBITMQ_URL", c.RabbitMQURL, "amqp", "amqps"),
+		c.Log.Validate(),
+		c.Tracing.Validate(),
+	}
+	// DB_URL también puede ser un DSN clave=valor; solo se valida como URL si lo es
+	if strings.Contains(c.Database.URL, "://") {
+		errs = append(errs, config.URL("DB_URL", c.Database.URL, "postgres", "postgresql"))
+	}
+	return errors.Join(errs...)
+}
@@ -1,26 +1,67 @@
 package database
 
 import (
+	"context"
 	"log"
-	"os"
+	"log/slog"
 
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/migrations"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
 
-func Connect() {
-	dsn := os.Getenv("DB_URL")
-	if dsn == "" {
-		log.Fatal("DB_URL environment variable is not set")
-	}
-
+// Connect abre la conexión a dsn (DB_URL)
+func Connect(dsn string) {
 	var err error
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	// Un span por consulta; sin los valores de los parámetros, que pueden traer datos personales
+	if err := DB.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		log.Fatalf("Failed to enable database tracing: %v", err)
+	}
+
+	slog.Info("connected to PostgreSQL")
+}
+
+// Migrate aplica las migraciones pendientes si enabled. DB_MIGRATE=off lo
+// desactiva (p. ej. si se migra con cmd/migrate antes del despliegue).
+func Migrate(enabled bool) {
+	if !enabled {
+		slog.Info("DB_MIGRATE=off, skipping migrations")
+		return
+	}
+	runner, err := NewMigrator()
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	applied, err := runner.Up(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	slog.Info("database schema up to date", "applied", len(applied))
+}
 
-	log.Println("Connected to PostgreSQL")
+// NewMigrator devuelve el runner de las migraciones embebidas de report-service
+func NewMigrator() (*migrate.Runner, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, migrations.FS, "report-service")
+}
+
+// Close cierra la conexión; se llama al apagar el servicio, después de drenar
+// las peticiones en curso
+func Close(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
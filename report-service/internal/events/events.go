@@ -0,0 +1,212 @@
+// Package events publica en RabbitMQ los reportes creados. La publicación
+// corre en segundo plano para no demorar la respuesta; Wait permite que el
+// apagado espere a que terminen las publicaciones en curso. Cada mensaje
+// lleva en sus cabeceras el contexto de traza W3C de la petición que creó el
+// reporte, para que los consumidores continúen la traza.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// dialTimeout limita la conexión al broker de cada publicación y del check
+const dialTimeout = 5 * time.Second
+
+// queueName es la cola de los eventos de reportes
+const queueName = "reports"
+
+var tracer = tracing.Tracer("github.com/Andres09xZ/latacunga_clean_app/report-service/internal/events")
+
+var publishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "report_events_publish_failures_total",
+	Help: "Report events that could not be published to RabbitMQ, by failed stage.",
+}, []string{"stage"})
+
+var (
+	// rabbitURL es el broker de los eventos (RABBITMQ_URL); lo fija Init
+	rabbitURL string
+	inFlight  sync.WaitGroup
+)
+
+// Init fija el broker al que se emiten los reportes creados; vacío no emite eventos
+func Init(url string) {
+	rabbitURL = url
+}
+
+// Enabled indica si hay un broker configurado
+func Enabled() bool {
+	return rabbitURL != ""
+}
+
+// Publish emite el evento del reporte en segundo plano. El evento continúa la
+// traza de ctx pero no se cancela cuando termina la petición.
+func Publish(ctx context.Context, report models.Report) {
+	ctx = context.WithoutCancel(ctx)
+	inFlight.Add(1)
+	go func() {
+		defer inFlight.Done()
+		emitReportEvent(ctx, report)
+	}()
+}
+
+// Wait espera a que terminen las publicaciones en curso o a que ctx expire
+func Wait(ctx context.Context) error {
+	return wait(ctx, &inFlight)
+}
+
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check comprueba que el broker acepte conexiones (para /readyz)
+func Check(ctx context.Context) error {
+	conn, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func dial(ctx context.Context) (*amqp.Connection, error) {
+	return amqp.DialConfig(rabbitURL, amqp.Config{
+		Dial: func(network, addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+			defer cancel()
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			// El handshake AMQP también debe respetar el plazo
+			if deadline, ok := ctx.Deadline(); ok {
+				if err := conn.SetDeadline(deadline); err != nil {
+					conn.Close()
+					return nil, err
+				}
+			}
+			return conn, nil
+		},
+	})
+}
+
+func emitReportEvent(ctx context.Context, report models.Report) {
+	if rabbitURL == "" {
+		slog.DebugContext(ctx, "RABBITMQ_URL not set, skipping event emission")
+		return
+	}
+
+	ctx, span := tracer.Start(ctx, queueName+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemRabbitmq, semconv.MessagingDestinationName(queueName)))
+	defer span.End()
+
+	_, dialSpan := tracer.Start(ctx, "amqp dial")
+	conn, err := dial(ctx)
+	dialSpan.End()
+	if err != nil {
+		fail(ctx, span, "connect", "failed to connect to RabbitMQ", err)
+		return
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		fail(ctx, span, "channel", "failed to open channel", err)
+		return
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclare(
+		queueName, // name
+		false,     // durable
+		false,     // delete when unused
+		false,     // exclusive
+		false,     // no-wait
+		nil,       // arguments
+	)
+	if err != nil {
+		fail(ctx, span, "declare", "failed to declare queue", err)
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		fail(ctx, span, "marshal", "failed to marshal report", err)
+		return
+	}
+
+	err = ch.PublishWithContext(ctx,
+		"",     // exchange
+		q.Name, // routing key
+		false,  // mandatory
+		false,  // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Headers:     traceHeaders(ctx),
+			Body:        body,
+		})
+	if err != nil {
+		fail(ctx, span, "publish", "failed to publish message", err)
+	}
+}
+
+// fail registra el fallo de una etapa de la publicación en el log, las
+// métricas y el span
+func fail(ctx context.Context, span trace.Span, stage, msg string, err error) {
+	slog.ErrorContext(ctx, msg, "stage", stage, "error", err)
+	publishFailures.WithLabelValues(stage).Inc()
+	span.RecordError(err)
+	span.SetStatus(codes.Error, stage)
+}
+
+// traceHeaders devuelve las cabeceras del mensaje con el contexto de traza de ctx
+func traceHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+	return headers
+}
+
+// headerCarrier adapta las cabeceras AMQP a propagation.TextMapCarrier
+type headerCarrier amqp.Table
+
+func (h headerCarrier) Get(key string) string {
+	v, _ := h[key].(string)
+	return v
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h[key] = value
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
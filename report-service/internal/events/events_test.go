@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPublishWithoutBroker(t *testing.T) {
+	Init("")
+	if Enabled() {
+		t.Fatal("Enabled with an empty RABBITMQ_URL")
+	}
+	for i := 0; i < 3; i++ {
+		Publish(context.Background(), models.Report{Description: "basura acumulada"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// Un puerto sin nadie escuchando
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	Init("amqp://guest:guest@" + addr + "/")
+	t.Cleanup(func() { Init("") })
+	if !Enabled() {
+		t.Fatal("Enabled = false with RABBITMQ_URL set")
+	}
+	if err := Check(context.Background()); err == nil {
+		t.Fatal("Check against a closed port returned nil")
+	}
+}
+
+func TestPublishFailureMetric(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	Init("amqp://guest:guest@" + addr + "/")
+	t.Cleanup(func() { Init("") })
+
+	before := failures(t, "connect")
+	emitReportEvent(context.Background(), models.Report{Description: "basura acumulada"})
+	if got := failures(t, "connect") - before; got != 1 {
+		t.Errorf("connect failures increased by %v, want 1", got)
+	}
+}
+
+// failures devuelve report_events_publish_failures_total para la etapa stage
+func failures(t *testing.T, stage string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "report_events_publish_failures_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "stage" && l.GetValue() == stage {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestWait(t *testing.T) {
+	// Un WaitGroup propio: el wait vencido deja una goroutine esperándolo y
+	// el de las publicaciones no podría reutilizarse en el resto de los tests
+	var wg sync.WaitGroup
+
+	// Una publicación colgada (p. ej. un broker que no responde)
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := wait(ctx, &wg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait with a stuck publication = %v, want context.DeadlineExceeded", err)
+	}
+
+	wg.Done()
+	if err := wait(context.Background(), &wg); err != nil {
+		t.Fatalf("Wait after the publication finished = %v, want nil", err)
+	}
+}
+
+func TestTraceHeaders(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	headers := traceHeaders(ctx)
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := headers["traceparent"]; got != want {
+		t.Fatalf("traceparent = %v, want %s", got, want)
+	}
+
+	// Un consumidor extrae el mismo contexto de las cabeceras
+	got := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(headers)))
+	if got.TraceID() != traceID || got.SpanID() != spanID || !got.IsRemote() {
+		t.Errorf("extracted span context = %+v, want trace %s span %s", got, traceID, spanID)
+	}
+
+	// Sin traza activa no se agregan cabeceras
+	if headers := traceHeaders(context.Background()); len(headers) != 0 {
+		t.Errorf("headers without a span = %v, want none", headers)
+	}
+}
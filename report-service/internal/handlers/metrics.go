@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var reportsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "reports_created_total",
+	Help: "Reports stored, by type (acopio, critico).",
+}, []string{"type"})
+
+func countCreated(reports ...models.Report) {
+	for _, r := range reports {
+		reportsCreated.WithLabelValues(r.Type).Inc()
+	}
+}
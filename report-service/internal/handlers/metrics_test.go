@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// created devuelve reports_created_total para el tipo de reporte
+func created(t *testing.T, reportType string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "reports_created_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "type" && l.GetValue() == reportType {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestReportsCreatedMetric(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		repo        repository.ReportRepository
+		wantAcopio  float64
+		wantCritico float64
+	}{
+		{name: "single report", path: "/api/v1/reports", body: `{"type":"critico","description":"a"}`, wantCritico: 1},
+		{
+			name:        "batch",
+			path:        "/api/v1/reports/batch",
+			body:        `[{"type":"acopio","description":"a"},{"type":"acopio","description":"b"},{"type":"critico","description":"c"}]`,
+			wantAcopio:  2,
+			wantCritico: 1,
+		},
+		// Solo cuentan los reportes guardados
+		{name: "invalid report", path: "/api/v1/reports", body: `{"type":"otro","description":"a"}`},
+		{name: "database down", path: "/api/v1/reports/batch", body: `[{"type":"acopio","description":"a"}]`, repo: failingRepository{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.repo
+			if repo == nil {
+				repo = repository.NewMemoryReportRepository()
+			}
+			acopio, critico := created(t, "acopio"), created(t, "critico")
+
+			do(router(repo, true), http.MethodPost, tt.path, tt.body)
+
+			if got := created(t, "acopio") - acopio; got != tt.wantAcopio {
+				t.Errorf("acopio increased by %v, want %v", got, tt.wantAcopio)
+			}
+			if got := created(t, "critico") - critico; got != tt.wantCritico {
+				t.Errorf("critico increased by %v, want %v", got, tt.wantCritico)
+			}
+		})
+	}
+}
@@ -1,15 +1,13 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
-	"os"
 
-	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/events"
 	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
 	"github.com/gin-gonic/gin"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type createReportRequest struct {
@@ -21,58 +19,12 @@ type createReportRequest struct {
 
 type createBatchReportRequest []createReportRequest
 
-func emitReportEvent(report models.Report) {
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	if rabbitURL == "" {
-		log.Println("RABBITMQ_URL not set, skipping event emission")
-		return
-	}
-
-	conn, err := amqp.Dial(rabbitURL)
-	if err != nil {
-		log.Printf("Failed to connect to RabbitMQ: %v", err)
-		return
-	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Printf("Failed to open channel: %v", err)
-		return
-	}
-	defer ch.Close()
-
-	q, err := ch.QueueDeclare(
-		"reports", // name
-		false,     // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
-		log.Printf("Failed to declare queue: %v", err)
-		return
-	}
-
-	body, err := json.Marshal(report)
-	if err != nil {
-		log.Printf("Failed to marshal report: %v", err)
-		return
-	}
+// reports guarda los reportes; lo fija InitRepository
+var reports repository.ReportRepository
 
-	err = ch.Publish(
-		"",     // exchange
-		q.Name, // routing key
-		false,  // mandatory
-		false,  // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
-	if err != nil {
-		log.Printf("Failed to publish message: %v", err)
-	}
+// InitRepository fija el repositorio de reportes de los handlers
+func InitRepository(r repository.ReportRepository) {
+	reports = r
 }
 
 // CreateReport crea un nuevo reporte.
@@ -94,14 +46,14 @@ func CreateReport(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID := authz.UserID(c)
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
 
 	report := models.Report{
-		UserID:      userID.(string),
+		UserID:      userID,
 		Type:        req.Type,
 		Description: req.Description,
 		Location:    req.Location,
@@ -109,12 +61,13 @@ func CreateReport(c *gin.Context) {
 		Status:      "Pendiente",
 	}
 
-	if err := database.DB.Create(&report).Error; err != nil {
+	if err := reports.Create(c.Request.Context(), &report); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create report"})
 		return
 	}
 
-	go emitReportEvent(report)
+	countCreated(report)
+	events.Publish(c.Request.Context(), report)
 
 	c.JSON(http.StatusCreated, report)
 }
@@ -129,12 +82,12 @@ func CreateReport(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/reports [get]
 func ListReports(c *gin.Context) {
-	var reports []models.Report
-	if err := database.DB.Find(&reports).Error; err != nil {
+	list, err := reports.List(c.Request.Context())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
-	c.JSON(http.StatusOK, reports)
+	c.JSON(http.StatusOK, list)
 }
 
 // CreateBatchReports crea múltiples reportes en batch.
@@ -156,33 +109,34 @@ func CreateBatchReports(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID := authz.UserID(c)
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
 
-	var reports []models.Report
+	batch := make([]models.Report, 0, len(req))
 	for _, r := range req {
 		report := models.Report{
-			UserID:      userID.(string),
+			UserID:      userID,
 			Type:        r.Type,
 			Description: r.Description,
 			Location:    r.Location,
 			PhotoURL:    r.PhotoURL,
 			Status:      "Pendiente",
 		}
-		reports = append(reports, report)
+		batch = append(batch, report)
 	}
 
-	if err := database.DB.Create(&reports).Error; err != nil {
+	if err := reports.CreateBatch(c.Request.Context(), batch); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reports"})
 		return
 	}
 
-	for _, r := range reports {
-		go emitReportEvent(r)
+	countCreated(batch...)
+	for _, r := range batch {
+		events.Publish(c.Request.Context(), r)
 	}
 
-	c.JSON(http.StatusCreated, reports)
+	c.JSON(http.StatusCreated, batch)
 }
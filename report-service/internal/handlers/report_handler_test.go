@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/handlers"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/gin-gonic/gin"
+)
+
+const userID = "0b7e3c1a-5d2f-4e8a-9c6b-1f2e3d4c5b6a"
+
+// failingRepository simula una base de datos caída
+type failingRepository struct{}
+
+var errDB = errors.New("connection refused")
+
+func (failingRepository) Create(context.Context, *models.Report) error       { return errDB }
+func (failingRepository) CreateBatch(context.Context, []models.Report) error { return errDB }
+func (failingRepository) List(context.Context) ([]models.Report, error)      { return nil, errDB }
+
+// router monta las rutas de reportes sobre repo; authenticated simula el
+// middleware JWT
+func router(repo repository.ReportRepository, authenticated bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handlers.InitRepository(repo)
+	auth := func(c *gin.Context) {
+		if authenticated {
+			authz.SetClaims(c, &authz.Claims{UserID: userID, Role: authz.RoleUser})
+		}
+	}
+	r := gin.New()
+	r.POST("/api/v1/reports", auth, handlers.CreateReport)
+	r.POST("/api/v1/reports/batch", auth, handlers.CreateBatchReports)
+	r.GET("/api/v1/reports", auth, handlers.ListReports)
+	return r
+}
+
+func do(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateReport(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		unauth        bool
+		repo          repository.ReportRepository
+		wantStatus    int
+		wantPersisted int
+	}{
+		{name: "valid report", body: `{"type":"acopio","description":"bolsas","location":"POINT(-78.6 -0.9)"}`, wantStatus: http.StatusCreated, wantPersisted: 1},
+		{name: "unknown type", body: `{"type":"otro","description":"bolsas"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing description", body: `{"type":"critico"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed JSON", body: `{"type":`, wantStatus: http.StatusBadRequest},
+		{name: "anonymous", body: `{"type":"acopio","description":"bolsas"}`, unauth: true, wantStatus: http.StatusUnauthorized},
+		{name: "database down", body: `{"type":"acopio","description":"bolsas"}`, repo: failingRepository{}, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := repository.NewMemoryReportRepository()
+			repo := tt.repo
+			if repo == nil {
+				repo = memory
+			}
+
+			w := do(router(repo, !tt.unauth), http.MethodPost, "/api/v1/reports", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			stored, _ := memory.List(context.Background())
+			if len(stored) != tt.wantPersisted {
+				t.Fatalf("%d reports stored, want %d", len(stored), tt.wantPersisted)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var got models.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != stored[0].ID || got.UserID != userID || got.Status != "Pendiente" {
+				t.Errorf("response = %+v, want the stored report of the caller", got)
+			}
+		})
+	}
+}
+
+func TestCreateBatchReports(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		repo       repository.ReportRepository
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "several reports", body: `[{"type":"acopio","description":"a"},{"type":"critico","description":"b"}]`, wantStatus: http.StatusCreated, wantCount: 2},
+		{name: "empty batch", body: `[]`, wantStatus: http.StatusCreated},
+		// Un reporte inválido rechaza el lote entero
+		{name: "one invalid report", body: `[{"type":"acopio","description":"a"},{"type":"otro","description":"b"}]`, wantStatus: http.StatusBadRequest},
+		{name: "not a list", body: `{"type":"acopio","description":"a"}`, wantStatus: http.StatusBadRequest},
+		{name: "database down", body: `[{"type":"acopio","description":"a"}]`, repo: failingRepository{}, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := repository.NewMemoryReportRepository()
+			repo := tt.repo
+			if repo == nil {
+				repo = memory
+			}
+
+			w := do(router(repo, true), http.MethodPost, "/api/v1/reports/batch", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			stored, _ := memory.List(context.Background())
+			if len(stored) != tt.wantCount {
+				t.Errorf("%d reports stored, want %d", len(stored), tt.wantCount)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var got []models.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("response has %d reports, want %d", len(got), tt.wantCount)
+			}
+			for _, r := range got {
+				if r.ID == "" || r.UserID != userID {
+					t.Errorf("response report = %+v, want an id and the caller", r)
+				}
+			}
+		})
+	}
+}
+
+func TestListReports(t *testing.T) {
+	memory := repository.NewMemoryReportRepository()
+	r := router(memory, true)
+	for _, body := range []string{`{"type":"acopio","description":"a"}`, `{"type":"critico","description":"b"}`} {
+		if w := do(r, http.MethodPost, "/api/v1/reports", body); w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body)
+		}
+	}
+
+	w := do(r, http.MethodGet, "/api/v1/reports", "")
+	var got []models.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK || len(got) != 2 {
+		t.Errorf("status = %d, %d reports (%v), want 200 and 2", w.Code, len(got), err)
+	}
+
+	if w := do(router(failingRepository{}, true), http.MethodGet, "/api/v1/reports", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d with the database down, want 500", w.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/google/uuid"
+)
+
+type memoryReportRepository struct {
+	mu      sync.RWMutex
+	reports []models.Report
+}
+
+// NewMemoryReportRepository devuelve un ReportRepository en memoria. Aplica
+// los mismos valores por defecto que el esquema (id y estado Pendiente).
+func NewMemoryReportRepository() ReportRepository {
+	return &memoryReportRepository{}
+}
+
+func (r *memoryReportRepository) Create(ctx context.Context, report *models.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.add(report)
+	return nil
+}
+
+func (r *memoryReportRepository) CreateBatch(ctx context.Context, reports []models.Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range reports {
+		r.add(&reports[i])
+	}
+	return nil
+}
+
+func (r *memoryReportRepository) List(ctx context.Context) ([]models.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Report(nil), r.reports...), nil
+}
+
+func (r *memoryReportRepository) add(report *models.Report) {
+	if report.ID == "" {
+		report.ID = uuid.NewString()
+	}
+	if report.Status == "" {
+		report.Status = "Pendiente"
+	}
+	now := time.Now()
+	report.CreatedAt, report.UpdatedAt = now, now
+	r.reports = append(r.reports, *report)
+}
@@ -0,0 +1,46 @@
+// Package repository define el acceso a datos de los reportes, con una
+// implementación GORM (Postgres/PostGIS) y otra en memoria, segura para uso
+// concurrente, para pruebas sin base de datos.
+package repository
+
+import (
+	"context"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReportRepository guarda y lista reportes
+type ReportRepository interface {
+	Create(ctx context.Context, report *models.Report) error
+	// CreateBatch guarda todos los reportes o ninguno; un lote vacío no hace nada
+	CreateBatch(ctx context.Context, reports []models.Report) error
+	List(ctx context.Context) ([]models.Report, error)
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository devuelve el ReportRepository de Postgres
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *models.Report) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *reportRepository) CreateBatch(ctx context.Context, reports []models.Report) error {
+	// GORM devuelve error al insertar un slice vacío
+	if len(reports) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&reports).Error
+}
+
+func (r *reportRepository) List(ctx context.Context) ([]models.Report, error) {
+	var reports []models.Report
+	err := r.db.WithContext(ctx).Find(&reports).Error
+	return reports, err
+}
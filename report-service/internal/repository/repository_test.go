@@ -0,0 +1,151 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/repository"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/migrations"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Las dos implementaciones pasan por el mismo contrato. La de GORM necesita
+// Postgres con PostGIS: corre solo con TEST_DB_URL.
+
+func TestMemoryReportRepository(t *testing.T) {
+	testReportRepository(t, func(*testing.T) repository.ReportRepository {
+		return repository.NewMemoryReportRepository()
+	})
+}
+
+func TestGormReportRepository(t *testing.T) {
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrate.New(sqlDB, migrations.FS, "report-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Logf = t.Logf
+	if _, err := runner.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	testReportRepository(t, func(t *testing.T) repository.ReportRepository {
+		if err := db.Exec("DELETE FROM reports").Error; err != nil {
+			t.Fatal(err)
+		}
+		return repository.NewReportRepository(db)
+	})
+}
+
+func report(userID, kind string) models.Report {
+	return models.Report{UserID: userID, Type: kind, Description: "bolsas en la vereda", Location: "POINT(-78.6167 -0.9333)"}
+}
+
+// testReportRepository comprueba el contrato de ReportRepository; newRepo
+// devuelve un repositorio vacío
+func testReportRepository(t *testing.T, newRepo func(t *testing.T) repository.ReportRepository) {
+	user := uuid.NewString()
+
+	t.Run("create fills the schema defaults", func(t *testing.T) {
+		repo := newRepo(t)
+		r := report(user, "acopio")
+		if err := repo.Create(context.Background(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.ID == "" || r.Status != "Pendiente" || r.CreatedAt.IsZero() {
+			t.Errorf("created report = %+v, want an id, status Pendiente and timestamps", r)
+		}
+		list, err := repo.List(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != r.ID || list[0].UserID != user || list[0].Type != "acopio" {
+			t.Errorf("list = %+v, want the created report", list)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			batch []models.Report
+		}{
+			{name: "several reports", batch: []models.Report{report(user, "acopio"), report(user, "critico"), report(user, "acopio")}},
+			{name: "one report", batch: []models.Report{report(user, "critico")}},
+			{name: "empty", batch: []models.Report{}},
+			{name: "nil", batch: nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				repo := newRepo(t)
+				if err := repo.CreateBatch(context.Background(), tt.batch); err != nil {
+					t.Fatalf("CreateBatch: %v", err)
+				}
+				ids := map[string]bool{}
+				for _, r := range tt.batch {
+					if r.ID == "" || r.Status != "Pendiente" {
+						t.Errorf("batch report = %+v, want an id and status Pendiente", r)
+					}
+					ids[r.ID] = true
+				}
+				list, err := repo.List(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(list) != len(tt.batch) || len(ids) != len(tt.batch) {
+					t.Fatalf("listed %d reports with %d distinct ids, want %d", len(list), len(ids), len(tt.batch))
+				}
+				for _, r := range list {
+					if !ids[r.ID] {
+						t.Errorf("listed unknown report %s", r.ID)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("list empty", func(t *testing.T) {
+		list, err := newRepo(t).List(context.Background())
+		if err != nil || len(list) != 0 {
+			t.Errorf("list = %v, %v, want none", list, err)
+		}
+	})
+
+	t.Run("concurrent creates", func(t *testing.T) {
+		repo := newRepo(t)
+		const n = 20
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r := report(user, "acopio")
+				if err := repo.Create(context.Background(), &r); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		list, err := repo.List(context.Background())
+		if err != nil || len(list) != n {
+			t.Errorf("listed %d reports (%v), want %d", len(list), err, n)
+		}
+	})
+}
@@ -1,40 +1,97 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"time"
 
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/config"
 	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/database"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/events"
 	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/handlers"
-	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/models"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/repository"
 	"github.com/Andres09xZ/latacunga_clean_app/report-service/middleware"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/graceful"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/health"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/metrics"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// Start arranca el servidor Gin para report-service.
-func Start() {
-	database.Connect()
-	database.DB.AutoMigrate(&models.Report{})
+const serviceName = "report-service"
 
-	r := gin.Default()
+// Start arranca el servidor Gin para report-service con una configuración
+// ya validada (ver config.Load) y lo sirve hasta recibir SIGTERM.
+func Start(cfg *config.Config) {
+	logging.Init(serviceName, cfg.Log)
 
-	// Health
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	shutdownTracing, err := tracing.Init(context.Background(), serviceName, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
+	database.Connect(cfg.Database.URL)
+	database.Migrate(cfg.Database.Migrate)
+	handlers.InitRepository(repository.NewReportRepository(database.DB))
+	events.Init(cfg.RabbitMQURL)
+	jwtAuth := middleware.JWTAuth(cfg.Auth)
+	introspect := middleware.Introspect(cfg.Auth)
+
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database handle: %v", err)
+	}
+	// auth-service no es un check: el JWKS se guarda en caché y una caída
+	// suya no debe sacar del balanceador a todas las réplicas
+	checks := map[string]health.Check{"postgres": health.Postgres(sqlDB)}
+	if events.Enabled() {
+		checks["rabbitmq"] = events.Check
+	}
+	probe := health.New(checks)
+
+	r := gin.New()
+	// X-Forwarded-For solo se acepta de los proxies configurados
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(logging.Recovery(), logging.RequestIDMiddleware(), tracing.Middleware(serviceName),
+		logging.Middleware(), metrics.Middleware())
+
+	// Health and Prometheus metrics
+	r.GET("/health", probe.Livez)
+	probe.Register(r)
+	r.GET("/metrics", metrics.Handler())
 
 	// Reports routes (assume JWT middleware from auth-service or shared)
-	r.POST("/api/v1/reports", middleware.JWTAuth(), middleware.RequireRole("user"), handlers.CreateReport)
-	r.POST("/api/v1/reports/batch", middleware.JWTAuth(), middleware.RequireRole("user"), handlers.CreateBatchReports)
-	r.GET("/api/v1/reports", middleware.JWTAuth(), middleware.RequireRole("admin", "operator"), handlers.ListReports)
+	r.POST("/api/v1/reports", jwtAuth, authz.RequirePermission(authz.ReportsCreate), handlers.CreateReport)
+	r.POST("/api/v1/reports/batch", jwtAuth, authz.RequirePermission(authz.ReportsCreate), handlers.CreateBatchReports)
+	// Listar todos los reportes es sensible a la revocación: con
+	// AUTH_INTROSPECTION=on se comprueba además con auth-service
+	r.GET("/api/v1/reports", jwtAuth, introspect, authz.RequirePermission(authz.ReportsReadAll), handlers.ListReports)
 
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	addr := ":8081" // different port
-	log.Printf("starting report service on %s", addr)
-	if err := r.Run(addr); err != nil {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("starting report service", "addr", srv.Addr)
+	err = graceful.Run(srv, graceful.Options{
+		Timeout:  cfg.ShutdownTimeout,
+		OnSignal: probe.Drain,
+		// Los eventos de los reportes recién creados se publican antes de cerrar
+		Cleanup: []func(context.Context) error{events.Wait, database.Close, shutdownTracing},
+	})
+	if err != nil {
 		log.Fatalf("server failed: %v", err)
 	}
 }
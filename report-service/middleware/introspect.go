@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// introspectTimeout acota la espera a auth-service; pasado el plazo se siguen
+// los claims verificados localmente
+const introspectTimeout = 2 * time.Second
+
+// maxIntrospectEntries limita la caché de resultados
+const maxIntrospectEntries = 10000
+
+type validateResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// Error es el código de estado de la cuenta cuando auth-service responde 403
+	// (ACCOUNT_SUSPENDED, ACCOUNT_BANNED, ACCOUNT_INACTIVE)
+	Error string `json:"error,omitempty"`
+}
+
+// Introspect consulta a auth-service (/api/v1/auth/validate-token) si el
+// token sigue siendo válido: usuario existente, activo y con el mismo rol. Va
+// después de JWTAuth y solo en las rutas sensibles a la revocación. Con
+// AUTH_INTROSPECTION=off no hace nada.
+//
+// Cada respuesta se guarda AUTH_INTROSPECTION_CACHE_TTL. Si auth-service no
+// responde se conservan los claims verificados localmente: una caída de
+// auth-service no tumba report-service.
+func Introspect(c config.Auth) gin.HandlerFunc {
+	if !c.Introspection {
+		return func(c *gin.Context) { c.Next() }
+	}
+	// El transporte propaga la traza (traceparent) y el X-Request-ID a auth-service
+	client := &http.Client{Timeout: introspectTimeout, Transport: tracing.Transport(logging.Transport(nil))}
+	return newIntrospector(c.ServiceURL, client, c.IntrospectionCacheTTL).handle
+}
+
+type introspector struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspection
+}
+
+// introspection es una respuesta definitiva de auth-service (200, 401 o 403)
+type introspection struct {
+	resp    validateResponse
+	status  int
+	expires time.Time
+}
+
+func newIntrospector(serviceURL string, client *http.Client, ttl time.Duration) *introspector {
+	return &introspector{
+		url:    serviceURL + "/api/v1/auth/validate-token",
+		client: client,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  map[[sha256.Size]byte]introspection{},
+	}
+}
+
+func (i *introspector) handle(c *gin.Context) {
+	tokenStr, err := authz.BearerToken(c.Request)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, ok := i.check(c.Request.Context(), tokenStr)
+	if !ok {
+		// auth-service no contestó: vale la verificación local
+		c.Next()
+		return
+	}
+	switch res.status {
+	case http.StatusOK:
+		// El rol puede haber cambiado desde que se emitió el token
+		c.Set(authz.KeyRole, res.resp.Role)
+		c.Next()
+	case http.StatusForbidden:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": res.resp.Error})
+	default:
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authz.ErrorInvalidToken})
+	}
+}
+
+// check devuelve la respuesta de auth-service para el token, de la caché si
+// es reciente. ok es false si auth-service no dio una respuesta definitiva.
+func (i *introspector) check(ctx context.Context, tokenStr string) (introspection, bool) {
+	key := sha256.Sum256([]byte(tokenStr))
+	now := i.now()
+
+	i.mu.Lock()
+	res, ok := i.cache[key]
+	i.mu.Unlock()
+	if ok && now.Before(res.expires) {
+		return res, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, introspectTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, status, err := introspect(ctx, i.client, i.url, tokenStr)
+	validateTokenDuration.WithLabelValues(introspectResult(status, err)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		slog.WarnContext(ctx, "token introspection failed, using local verification", "error", err)
+		return introspection{}, false
+	}
+
+	res = introspection{resp: resp, status: status, expires: now.Add(i.ttl)}
+	i.mu.Lock()
+	if len(i.cache) >= maxIntrospectEntries {
+		i.prune(now)
+	}
+	i.cache[key] = res
+	i.mu.Unlock()
+	return res, true
+}
+
+// prune quita las entradas vencidas y, si aún no hay sitio, vacía la caché.
+// Se llama con mu tomado.
+func (i *introspector) prune(now time.Time) {
+	for key, res := range i.cache {
+		if !now.Before(res.expires) {
+			delete(i.cache, key)
+		}
+	}
+	if len(i.cache) >= maxIntrospectEntries {
+		clear(i.cache)
+	}
+}
+
+// introspect consulta a auth-service si el token sigue siendo válido. Devuelve
+// 200, 401 o 403 (con valResp.Error como código del estado de la cuenta), o
+// un error si auth-service no respondió con uno de ellos.
+func introspect(ctx context.Context, client *http.Client, url, tokenStr string) (validateResponse, int, error) {
+	var valResp validateResponse
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return valResp, 0, err
+	}
+	req.Header.Set(authz.AuthorizationHeader, "Bearer "+tokenStr)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return valResp, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&valResp); err != nil {
+			return valResp, 0, err
+		}
+		return valResp, http.StatusOK, nil
+	case http.StatusUnauthorized:
+		return valResp, http.StatusUnauthorized, nil
+	case http.StatusForbidden:
+		if err := json.NewDecoder(resp.Body).Decode(&valResp); err != nil || valResp.Error == "" {
+			valResp.Error = "ACCOUNT_INACTIVE"
+		}
+		return valResp, http.StatusForbidden, nil
+	default:
+		return valResp, 0, fmt.Errorf("validate-token answered %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// authService responde validate-token con status y body, y cuenta las llamadas
+func authService(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/api/v1/auth/validate-token" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// serve pasa una petición por la ruta con los claims locales de un operador y
+// devuelve el status y el rol que llega al handler
+func serve(handler gin.HandlerFunc) (int, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var role string
+	r.GET("/", func(c *gin.Context) {
+		authz.SetClaims(c, &authz.Claims{UserID: "u1", Role: authz.RoleOperador})
+	}, handler, func(c *gin.Context) {
+		role = authz.Role(c)
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, role
+}
+
+func TestIntrospect(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantRole   string
+	}{
+		{name: "active user", status: http.StatusOK, body: `{"user_id":"u1","role":"admin"}`, wantStatus: http.StatusNoContent, wantRole: authz.RoleAdmin},
+		{name: "revoked token", status: http.StatusUnauthorized, wantStatus: http.StatusUnauthorized},
+		{name: "suspended user", status: http.StatusForbidden, body: `{"error":"ACCOUNT_SUSPENDED"}`, wantStatus: http.StatusForbidden},
+		// auth-service caído o con errores: vale la verificación local
+		{name: "auth-service error", status: http.StatusInternalServerError, wantStatus: http.StatusNoContent, wantRole: authz.RoleOperador},
+		{name: "auth-service overloaded", status: http.StatusServiceUnavailable, wantStatus: http.StatusNoContent, wantRole: authz.RoleOperador},
+		{name: "unreadable answer", status: http.StatusOK, body: "<html>", wantStatus: http.StatusNoContent, wantRole: authz.RoleOperador},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := authService(t, tt.status, tt.body)
+			status, role := serve(newIntrospector(srv.URL, srv.Client(), time.Minute).handle)
+			if status != tt.wantStatus || role != tt.wantRole {
+				t.Errorf("status = %d, role = %q, want %d, %q", status, role, tt.wantStatus, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestIntrospectUnreachable(t *testing.T) {
+	srv, _ := authService(t, http.StatusOK, "")
+	srv.Close()
+
+	status, role := serve(newIntrospector(srv.URL, srv.Client(), time.Minute).handle)
+	if status != http.StatusNoContent || role != authz.RoleOperador {
+		t.Errorf("status = %d, role = %q with auth-service down, want the local claims", status, role)
+	}
+}
+
+func TestIntrospectCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+	}{
+		{name: "valid answers are cached", status: http.StatusOK, wantCalls: 1},
+		{name: "rejections are cached", status: http.StatusForbidden, wantCalls: 1},
+		// Un fallo no se guarda: la siguiente petición vuelve a consultar
+		{name: "failures are not cached", status: http.StatusBadGateway, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := authService(t, tt.status, `{"role":"operador","error":"ACCOUNT_BANNED"}`)
+			i := newIntrospector(srv.URL, srv.Client(), time.Minute)
+			now := time.Now()
+			i.now = func() time.Time { return now }
+
+			for n := 0; n < 3; n++ {
+				serve(i.handle)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("%d calls to auth-service, want %d", got, tt.wantCalls)
+			}
+
+			// Vencido el TTL se vuelve a consultar
+			now = now.Add(time.Minute)
+			serve(i.handle)
+			if got := calls.Load(); got != tt.wantCalls+1 {
+				t.Errorf("%d calls after the TTL, want %d", got, tt.wantCalls+1)
+			}
+		})
+	}
+}
+
+func TestIntrospectDisabled(t *testing.T) {
+	srv, calls := authService(t, http.StatusUnauthorized, "")
+	status, _ := serve(Introspect(config.Auth{ServiceURL: srv.URL}))
+	if status != http.StatusNoContent || calls.Load() != 0 {
+		t.Errorf("status = %d, %d calls with AUTH_INTROSPECTION=off", status, calls.Load())
+	}
+}
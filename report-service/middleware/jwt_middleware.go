@@ -1,87 +1,47 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/auth"
+	"github.com/Andres09xZ/latacunga_clean_app/report-service/internal/config"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/logging"
+	"github.com/Andres09xZ/latacunga_clean_app/shared/tracing"
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	AuthorizationHeader = "Authorization"
-	BearerPrefix        = "bearer"
-	ErrorMissingAuth    = "missing authorization header"
-	ErrorInvalidAuth    = "invalid authorization header"
-	ErrorInvalidToken   = "invalid token"
-	ErrorAuthService    = "auth service error"
-)
-
-type validateResponse struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+// JWTAuth valida el access token localmente con las llaves públicas de
+// auth-service (JWKS en caché), sin llamar a auth-service en cada petición. El
+// token de un usuario suspendido o eliminado sigue valiendo hasta que vence;
+// las rutas que no aceptan ese margen añaden Introspect.
+func JWTAuth(c config.Auth) gin.HandlerFunc {
+	// El transporte propaga la traza (traceparent) y el X-Request-ID a auth-service
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(logging.Transport(nil))}
+	keys := auth.NewKeyCache(c.JWKSURL, client)
+	keys.Start(context.Background(), c.JWKSRefreshInterval)
+	return verifyJWT(auth.NewVerifier(keys))
 }
 
-func JWTAuth() gin.HandlerFunc {
-	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
-	if authServiceURL == "" {
-		panic("AUTH_SERVICE_URL environment variable is not set")
-	}
-
-	client := &http.Client{Timeout: 10 * time.Second}
-
+func verifyJWT(verifier *auth.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr, err := extractTokenFromHeader(c)
+		tokenStr, err := authz.BearerToken(c.Request)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Call auth-service to validate token
-		req, err := http.NewRequest("POST", authServiceURL+"/api/v1/auth/validate-token", nil)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+		claims, err := verifier.Verify(ctx, tokenStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": ErrorAuthService})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authz.ErrorInvalidToken})
 			return
 		}
-		req.Header.Set("Authorization", "Bearer "+tokenStr)
 
-		resp, err := client.Do(req)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": ErrorAuthService})
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrorInvalidToken})
-			return
-		}
-
-		var valResp validateResponse
-		if err := json.NewDecoder(resp.Body).Decode(&valResp); err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": ErrorAuthService})
-			return
-		}
-
-		c.Set("user_id", valResp.UserID)
-		c.Set("role", valResp.Role)
+		authz.SetClaims(c, claims)
 		c.Next()
 	}
 }
-
-func extractTokenFromHeader(c *gin.Context) (string, error) {
-	auth := c.GetHeader(AuthorizationHeader)
-	if auth == "" {
-		return "", http.ErrNoLocation
-	}
-
-	parts := strings.Fields(auth)
-	if len(parts) != 2 || strings.ToLower(parts[0]) != BearerPrefix {
-		return "", http.ErrNoLocation
-	}
-
-	return parts[1], nil
-}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var validateTokenDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "auth_validate_token_duration_seconds",
+	Help:    "Latency of the validate-token call to auth-service, by result.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"result"})
+
+// introspectResult resume el resultado de introspect
+func introspectResult(status int, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case status == http.StatusOK:
+		return "valid"
+	case status == http.StatusUnauthorized:
+		return "invalid"
+	default:
+		return "inactive"
+	}
+}
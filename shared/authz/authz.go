@@ -0,0 +1,4 @@
+// Package authz reúne lo que auth-service y report-service deben entender
+// igual: las claims de los tokens, los roles y permisos, la extracción del
+// bearer token y las claves del contexto de Gin donde queda la identidad.
+package authz
@@ -0,0 +1,38 @@
+package authz
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	AuthorizationHeader = "Authorization"
+	BearerPrefix        = "bearer"
+)
+
+// Mensajes de error de autenticación, iguales en ambos servicios
+const (
+	ErrorMissingAuth  = "missing authorization header"
+	ErrorInvalidAuth  = "invalid authorization header"
+	ErrorInvalidToken = "invalid token"
+)
+
+var (
+	ErrMissingAuth = errors.New(ErrorMissingAuth)
+	ErrInvalidAuth = errors.New(ErrorInvalidAuth)
+)
+
+// BearerToken extrae el token de la cabecera "Authorization: Bearer <token>"
+func BearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get(AuthorizationHeader)
+	if header == "" {
+		return "", ErrMissingAuth
+	}
+
+	parts := strings.Fields(header)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != BearerPrefix {
+		return "", ErrInvalidAuth
+	}
+	return parts[1], nil
+}
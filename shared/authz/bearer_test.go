@@ -0,0 +1,81 @@
+package authz_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		want    string
+		wantErr error
+	}{
+		{name: "bearer token", header: []string{"Bearer abc.def.ghi"}, want: "abc.def.ghi"},
+		{name: "scheme is case insensitive", header: []string{"bEaReR abc"}, want: "abc"},
+		{name: "extra spaces", header: []string{"  Bearer   abc  "}, want: "abc"},
+		{name: "missing header", wantErr: authz.ErrMissingAuth},
+		{name: "empty header", header: []string{""}, wantErr: authz.ErrMissingAuth},
+		{name: "scheme only", header: []string{"Bearer"}, wantErr: authz.ErrInvalidAuth},
+		{name: "scheme and blank token", header: []string{"Bearer "}, wantErr: authz.ErrInvalidAuth},
+		{name: "token without scheme", header: []string{"abc.def.ghi"}, wantErr: authz.ErrInvalidAuth},
+		{name: "basic auth", header: []string{"Basic dXNlcjpwYXNz"}, wantErr: authz.ErrInvalidAuth},
+		{name: "token with spaces", header: []string{"Bearer abc def"}, wantErr: authz.ErrInvalidAuth},
+		{name: "scheme without separator", header: []string{"Bearerabc"}, wantErr: authz.ErrInvalidAuth},
+		// Solo cuenta la primera cabecera, como en net/http
+		{name: "repeated header", header: []string{"Bearer first", "Bearer second"}, want: "first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, h := range tt.header {
+				r.Header.Add(authz.AuthorizationHeader, h)
+			}
+
+			got, err := authz.BearerToken(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("token = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if authz.Authenticated(c) || authz.UserID(c) != "" || authz.Role(c) != "" {
+		t.Fatal("a fresh context looks authenticated")
+	}
+
+	authz.SetClaims(c, &authz.Claims{UserID: "u1", Email: "a@example.com", Role: authz.RoleAdmin, SessionID: "s1"})
+	if !authz.Authenticated(c) {
+		t.Error("Authenticated = false after SetClaims")
+	}
+	tests := []struct {
+		name, got, want string
+	}{
+		{"UserID", authz.UserID(c), "u1"},
+		{"Email", authz.Email(c), "a@example.com"},
+		{"Role", authz.Role(c), authz.RoleAdmin},
+		{"SessionID", authz.SessionID(c), "s1"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	// Las claves son las que leen los handlers de ambos servicios
+	if c.GetString(authz.KeyUserID) != "u1" || c.GetString(authz.KeyRole) != authz.RoleAdmin {
+		t.Error("SetClaims did not use the shared context keys")
+	}
+}
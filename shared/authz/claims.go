@@ -0,0 +1,20 @@
+package authz
+
+import "github.com/golang-jwt/jwt/v5"
+
+// Tipos de token (claim token_type)
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Claims son las claims de los tokens que emite auth-service
+type Claims struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`
+	// SessionID (sid) es la familia de refresh tokens de la que sale el access token
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
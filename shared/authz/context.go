@@ -0,0 +1,44 @@
+package authz
+
+import "github.com/gin-gonic/gin"
+
+// Claves del contexto de Gin donde los middlewares JWT dejan la identidad
+const (
+	KeyUserID    = "user_id"
+	KeyEmail     = "email"
+	KeyRole      = "role"
+	KeySessionID = "session_id"
+)
+
+// SetClaims guarda la identidad del token en el contexto
+func SetClaims(c *gin.Context, claims *Claims) {
+	c.Set(KeyUserID, claims.UserID)
+	c.Set(KeyEmail, claims.Email)
+	c.Set(KeyRole, claims.Role)
+	c.Set(KeySessionID, claims.SessionID)
+}
+
+// UserID devuelve el ID del usuario autenticado, o "" si la petición es anónima
+func UserID(c *gin.Context) string {
+	return c.GetString(KeyUserID)
+}
+
+// Email devuelve el email (o teléfono) del usuario autenticado
+func Email(c *gin.Context) string {
+	return c.GetString(KeyEmail)
+}
+
+// Role devuelve el rol del usuario autenticado
+func Role(c *gin.Context) string {
+	return c.GetString(KeyRole)
+}
+
+// SessionID devuelve la sesión del access token
+func SessionID(c *gin.Context) string {
+	return c.GetString(KeySessionID)
+}
+
+// Authenticated indica si un middleware JWT autenticó la petición
+func Authenticated(c *gin.Context) bool {
+	return UserID(c) != ""
+}
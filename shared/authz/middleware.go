@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole exige que el rol del token sea uno de los indicados
+// (sin herencia; para la jerarquía usar RequirePermission)
+func RequireRole(allowed ...string) gin.HandlerFunc {
+	allowedMap := map[string]bool{}
+	for _, r := range allowed {
+		allowedMap[normalize(r)] = true
+	}
+
+	return func(c *gin.Context) {
+		if !allowedMap[normalize(Role(c))] {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission exige que el rol del token tenga todos los permisos
+// indicados, propios o heredados de los roles inferiores
+func RequirePermission(required ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := Role(c)
+		for _, p := range required {
+			if !Has(role, p) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions", "permission": p})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package authz
+
+import "strings"
+
+// Roles que emite auth-service. Un rol hereda los permisos de todos los
+// roles inferiores: super_admin > admin > operador > trabajador > user.
+const (
+	RoleUser       = "user"
+	RoleTrabajador = "trabajador"
+	RoleOperador   = "operador"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "super_admin"
+)
+
+// Permission es un permiso con formato recurso:acción
+type Permission string
+
+const (
+	ReportsCreate       Permission = "reports:create"
+	ReportsReadOwn      Permission = "reports:read_own"
+	ReportsUpdateStatus Permission = "reports:update_status"
+	ReportsReadAll      Permission = "reports:read_all"
+	ReportsAssign       Permission = "reports:assign"
+	ReportsApprove      Permission = "reports:approve"
+	UsersRead           Permission = "users:read"
+	UsersManage         Permission = "users:manage"
+	UsersManageAdmins   Permission = "users:manage_admins"
+	InvitationsCreate   Permission = "invitations:create"
+	SecurityManage      Permission = "security:manage"
+	AuditRead           Permission = "audit:read"
+)
+
+// hierarchy va del rol con menos privilegios al de más
+var hierarchy = []string{RoleUser, RoleTrabajador, RoleOperador, RoleAdmin, RoleSuperAdmin}
+
+// granted son los permisos propios de cada rol, sin contar los heredados
+var granted = map[string][]Permission{
+	RoleUser:       {ReportsCreate, ReportsReadOwn},
+	RoleTrabajador: {ReportsUpdateStatus},
+	RoleOperador:   {ReportsReadAll, ReportsAssign, ReportsApprove},
+	RoleAdmin:      {UsersRead, UsersManage, InvitationsCreate, SecurityManage, AuditRead},
+	RoleSuperAdmin: {UsersManageAdmins},
+}
+
+// Roles devuelve todos los roles, de menor a mayor privilegio
+func Roles() []string {
+	return append([]string(nil), hierarchy...)
+}
+
+// Valid indica si role es un rol conocido
+func Valid(role string) bool {
+	return Level(role) >= 0
+}
+
+// Level devuelve la posición del rol en la jerarquía (0 = user) o -1 si no existe
+func Level(role string) int {
+	role = normalize(role)
+	for i, r := range hierarchy {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// AtLeast indica si role está en la jerarquía a la altura de min o por encima
+func AtLeast(role, min string) bool {
+	l := Level(role)
+	return l >= 0 && l >= Level(min)
+}
+
+// Has indica si role tiene el permiso, propio o heredado
+func Has(role string, perm Permission) bool {
+	l := Level(role)
+	for i := 0; i <= l; i++ {
+		for _, p := range granted[hierarchy[i]] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanGrant indica si actor puede crear cuentas con role o asignárselo. Nadie
+// otorga un rol por encima del suyo, y los roles que administran usuarios
+// solo los otorga quien tiene users:manage_admins.
+func CanGrant(actor, role string) bool {
+	return AtLeast(actor, role) && (!Has(role, UsersManage) || Has(actor, UsersManageAdmins))
+}
+
+// Of devuelve todos los permisos de role, incluidos los heredados
+func Of(role string) []Permission {
+	var perms []Permission
+	for i := 0; i <= Level(role); i++ {
+		perms = append(perms, granted[hierarchy[i]]...)
+	}
+	return perms
+}
+
+func normalize(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
@@ -0,0 +1,217 @@
+package authz_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/authz"
+	"github.com/gin-gonic/gin"
+)
+
+var allPermissions = []authz.Permission{
+	authz.ReportsCreate, authz.ReportsReadOwn, authz.ReportsUpdateStatus, authz.ReportsReadAll,
+	authz.ReportsAssign, authz.ReportsApprove, authz.UsersRead, authz.UsersManage,
+	authz.UsersManageAdmins, authz.InvitationsCreate, authz.SecurityManage, authz.AuditRead,
+}
+
+func TestHas(t *testing.T) {
+	// Matriz completa rol × permiso: cada rol tiene lo suyo y lo de los inferiores
+	user := []authz.Permission{authz.ReportsCreate, authz.ReportsReadOwn}
+	trabajador := append(user[:len(user):len(user)], authz.ReportsUpdateStatus)
+	operador := append(trabajador[:len(trabajador):len(trabajador)], authz.ReportsReadAll, authz.ReportsAssign, authz.ReportsApprove)
+	admin := append(operador[:len(operador):len(operador)], authz.UsersRead, authz.UsersManage, authz.InvitationsCreate, authz.SecurityManage, authz.AuditRead)
+	superAdmin := append(admin[:len(admin):len(admin)], authz.UsersManageAdmins)
+
+	tests := []struct {
+		role string
+		want []authz.Permission
+	}{
+		{authz.RoleUser, user},
+		{authz.RoleTrabajador, trabajador},
+		{authz.RoleOperador, operador},
+		{authz.RoleAdmin, admin},
+		{authz.RoleSuperAdmin, superAdmin},
+		{" Admin ", admin},
+		{"operator", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		want := map[authz.Permission]bool{}
+		for _, p := range tt.want {
+			want[p] = true
+		}
+		for _, p := range allPermissions {
+			if got := authz.Has(tt.role, p); got != want[p] {
+				t.Errorf("Has(%q, %s) = %t, want %t", tt.role, p, got, want[p])
+			}
+		}
+		if got := authz.Of(tt.role); len(got) != len(tt.want) {
+			t.Errorf("Of(%q) = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestHierarchy(t *testing.T) {
+	roles := authz.Roles()
+	want := []string{authz.RoleUser, authz.RoleTrabajador, authz.RoleOperador, authz.RoleAdmin, authz.RoleSuperAdmin}
+	if len(roles) != len(want) {
+		t.Fatalf("Roles() = %v, want %v", roles, want)
+	}
+	for i, r := range want {
+		if roles[i] != r || authz.Level(r) != i || !authz.Valid(r) {
+			t.Errorf("role %q: Roles()[%d] = %q, Level = %d", r, i, roles[i], authz.Level(r))
+		}
+	}
+	// Roles devuelve una copia
+	roles[0] = "root"
+	if authz.Roles()[0] != authz.RoleUser {
+		t.Error("modifying Roles() changed the hierarchy")
+	}
+
+	tests := []struct {
+		role, min string
+		want      bool
+	}{
+		{authz.RoleAdmin, authz.RoleOperador, true},
+		{authz.RoleOperador, authz.RoleOperador, true},
+		{authz.RoleTrabajador, authz.RoleOperador, false},
+		{"SUPER_ADMIN", authz.RoleAdmin, true},
+		{"operator", authz.RoleUser, false},
+		{"", authz.RoleUser, false},
+		{authz.RoleUser, "unknown", true},
+	}
+	for _, tt := range tests {
+		if got := authz.AtLeast(tt.role, tt.min); got != tt.want {
+			t.Errorf("AtLeast(%q, %q) = %t, want %t", tt.role, tt.min, got, tt.want)
+		}
+	}
+	for _, role := range []string{"operator", "root", "", "super admin"} {
+		if authz.Valid(role) || authz.Level(role) != -1 {
+			t.Errorf("Valid(%q) = true, want false", role)
+		}
+	}
+}
+
+func TestCanGrant(t *testing.T) {
+	tests := []struct {
+		actor, role string
+		want        bool
+	}{
+		// admin administra cuentas sin privilegios de administración
+		{authz.RoleAdmin, authz.RoleUser, true},
+		{authz.RoleAdmin, authz.RoleTrabajador, true},
+		{authz.RoleAdmin, authz.RoleOperador, true},
+		// pero no crea pares ni superiores
+		{authz.RoleAdmin, authz.RoleAdmin, false},
+		{authz.RoleAdmin, authz.RoleSuperAdmin, false},
+		// super_admin otorga cualquier rol, incluido el suyo
+		{authz.RoleSuperAdmin, authz.RoleAdmin, true},
+		{authz.RoleSuperAdmin, authz.RoleSuperAdmin, true},
+		{authz.RoleSuperAdmin, authz.RoleUser, true},
+		// nadie otorga un rol por encima del propio
+		{authz.RoleOperador, authz.RoleAdmin, false},
+		{authz.RoleTrabajador, authz.RoleOperador, false},
+		{authz.RoleUser, authz.RoleTrabajador, false},
+		{authz.RoleOperador, authz.RoleOperador, true},
+		// sin rol conocido no se otorga nada
+		{"", authz.RoleUser, false},
+		{"operator", authz.RoleUser, false},
+	}
+	for _, tt := range tests {
+		if got := authz.CanGrant(tt.actor, tt.role); got != tt.want {
+			t.Errorf("CanGrant(%q, %q) = %t, want %t", tt.actor, tt.role, got, tt.want)
+		}
+	}
+
+	// Propiedad: nunca se otorga un rol superior al del actor
+	for _, actor := range authz.Roles() {
+		for _, role := range authz.Roles() {
+			if authz.CanGrant(actor, role) && authz.Level(role) > authz.Level(actor) {
+				t.Errorf("CanGrant(%q, %q) escalates privileges", actor, role)
+			}
+		}
+	}
+}
+
+// serve ejecuta mw con el rol role ya autenticado y devuelve la respuesta
+func serve(role string, mw gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if role != "" {
+			authz.SetClaims(c, &authz.Claims{UserID: "u1", Role: role})
+		}
+	}, mw, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		perms    []authz.Permission
+		wantCode int
+		// wantPerm es el permiso que falta, informado en la respuesta
+		wantPerm authz.Permission
+	}{
+		{name: "own permission", role: authz.RoleUser, perms: []authz.Permission{authz.ReportsCreate}, wantCode: http.StatusNoContent},
+		{name: "inherited permission", role: authz.RoleAdmin, perms: []authz.Permission{authz.ReportsApprove}, wantCode: http.StatusNoContent},
+		{name: "all permissions", role: authz.RoleOperador, perms: []authz.Permission{authz.ReportsReadAll, authz.ReportsAssign}, wantCode: http.StatusNoContent},
+		{name: "missing permission", role: authz.RoleTrabajador, perms: []authz.Permission{authz.ReportsReadAll}, wantCode: http.StatusForbidden, wantPerm: authz.ReportsReadAll},
+		{
+			name:     "one of several missing",
+			role:     authz.RoleAdmin,
+			perms:    []authz.Permission{authz.UsersManage, authz.UsersManageAdmins},
+			wantCode: http.StatusForbidden,
+			wantPerm: authz.UsersManageAdmins,
+		},
+		{name: "unknown role", role: "operator", perms: []authz.Permission{authz.ReportsCreate}, wantCode: http.StatusForbidden, wantPerm: authz.ReportsCreate},
+		{name: "anonymous", perms: []authz.Permission{authz.ReportsCreate}, wantCode: http.StatusForbidden, wantPerm: authz.ReportsCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.role, authz.RequirePermission(tt.perms...))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantPerm == "" {
+				return
+			}
+			var body struct {
+				Error      string           `json:"error"`
+				Permission authz.Permission `json:"permission"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Permission != tt.wantPerm {
+				t.Errorf("permission = %q, want %q", body.Permission, tt.wantPerm)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		allowed  []string
+		wantCode int
+	}{
+		{authz.RoleAdmin, []string{authz.RoleAdmin}, http.StatusNoContent},
+		{"ADMIN", []string{" admin "}, http.StatusNoContent},
+		{authz.RoleOperador, []string{authz.RoleAdmin, authz.RoleOperador}, http.StatusNoContent},
+		// Sin herencia: super_admin no pasa un RequireRole(admin)
+		{authz.RoleSuperAdmin, []string{authz.RoleAdmin}, http.StatusForbidden},
+		{"", []string{authz.RoleUser}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := serve(tt.role, authz.RequireRole(tt.allowed...)); w.Code != tt.wantCode {
+			t.Errorf("RequireRole(%v) with role %q: status = %d, want %d", tt.allowed, tt.role, w.Code, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package config carga la configuración de los servicios en structs tipados.
+//
+// Los valores salen, de menor a mayor prioridad, de la etiqueta default del
+// campo, de un archivo YAML opcional (CONFIG_FILE) y de las variables de
+// entorno indicadas por la etiqueta env. Los archivos .env se cargan en el
+// entorno antes de leerlo, sin pisar las variables ya definidas.
+//
+//	type Config struct {
+//		Port string        `yaml:"port" env:"PORT" default:"8080"`
+//		TTL  time.Duration `yaml:"ttl" env:"TOKEN_TTL" default:"15m"`
+//	}
+//
+// Si el struct implementa Validate() error, Load lo llama al final: los
+// servicios no deben arrancar con una configuración inválida.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv es la variable con la ruta del archivo YAML de configuración
+const FileEnv = "CONFIG_FILE"
+
+// Options indica de dónde leer la configuración
+type Options struct {
+	// EnvFiles son los .env candidatos; se carga el primero que exista
+	EnvFiles []string
+	// File es el YAML a leer; vacío usa CONFIG_FILE y, si tampoco está, no se lee ninguno
+	File string
+}
+
+// Validator lo implementan las configuraciones que comprueban sus valores
+type Validator interface {
+	Validate() error
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Load llena dst (puntero a struct) y lo valida
+func Load(dst any, opts Options) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: %T is not a pointer to a struct", dst)
+	}
+
+	for _, path := range opts.EnvFiles {
+		if err := godotenv.Load(path); err == nil {
+			break
+		}
+	}
+
+	if err := walk(v.Elem(), fromTag("default")); err != nil {
+		return err
+	}
+	file := opts.File
+	if file == "" {
+		file = os.Getenv(FileEnv)
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, dst); err != nil {
+			return fmt.Errorf("config: %s: %w", file, err)
+		}
+	}
+	if err := walk(v.Elem(), fromEnv); err != nil {
+		return err
+	}
+
+	if val, ok := dst.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+	}
+	return nil
+}
+
+// source devuelve el valor crudo de un campo y el nombre con el que reportar
+// errores; ok es false si no aporta valor
+type source func(f reflect.StructField) (raw, name string, ok bool)
+
+func fromTag(tag string) source {
+	return func(f reflect.StructField) (string, string, bool) {
+		raw, ok := f.Tag.Lookup(tag)
+		name := f.Tag.Get("env")
+		if name == "" {
+			name = f.Name
+		}
+		return raw, name, ok
+	}
+}
+
+func fromEnv(f reflect.StructField) (string, string, bool) {
+	name := f.Tag.Get("env")
+	if name == "" {
+		return "", "", false
+	}
+	raw, ok := os.LookupEnv(name)
+	return raw, name, ok && raw != ""
+}
+
+// walk recorre los campos de v (y de sus structs anidados) fijando los que
+// src provee. Reúne todos los errores para mostrarlos juntos.
+func walk(v reflect.Value, src source) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			if err := walk(fv, src); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		raw, name, ok := src(f)
+		if !ok {
+			continue
+		}
+		if err := set(fv, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func set(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := parseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseBool acepta además on/off, que es como se documenta DB_MIGRATE
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean %q", raw)
+	}
+	return b, nil
+}
@@ -0,0 +1,166 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+)
+
+type nested struct {
+	URL string `yaml:"url" env:"TEST_DB_URL"`
+}
+
+type testConfig struct {
+	Port    int           `yaml:"port" env:"TEST_PORT" default:"8080"`
+	Name    string        `yaml:"name" env:"TEST_NAME" default:"svc"`
+	TTL     time.Duration `yaml:"ttl" env:"TEST_TTL" default:"15m"`
+	Migrate bool          `yaml:"migrate" env:"TEST_MIGRATE" default:"on"`
+	Ratio   float64       `yaml:"ratio" env:"TEST_RATIO" default:"0.5"`
+	Origins []string      `yaml:"origins" env:"TEST_ORIGINS" default:"*"`
+	DB      nested        `yaml:"db"`
+	// sin env: solo default o YAML
+	Internal string `yaml:"internal" default:"x"`
+
+	invalid error
+}
+
+func (c *testConfig) Validate() error { return c.invalid }
+
+func TestLoad(t *testing.T) {
+	yamlFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(yamlFile, []byte("port: 9000\nname: from-yaml\ndb:\n  url: postgres://yaml\ninternal: y\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		want testConfig
+	}{
+		{
+			name: "defaults",
+			want: testConfig{Port: 8080, Name: "svc", TTL: 15 * time.Minute, Migrate: true, Ratio: 0.5, Origins: []string{"*"}, Internal: "x"},
+		},
+		{
+			name: "YAML overrides defaults",
+			file: yamlFile,
+			want: testConfig{Port: 9000, Name: "from-yaml", TTL: 15 * time.Minute, Migrate: true, Ratio: 0.5, Origins: []string{"*"}, DB: nested{URL: "postgres://yaml"}, Internal: "y"},
+		},
+		{
+			name: "environment overrides YAML",
+			file: yamlFile,
+			env: map[string]string{
+				"TEST_PORT":    "9100",
+				"TEST_TTL":     "90s",
+				"TEST_MIGRATE": "off",
+				"TEST_RATIO":   "0.25",
+				"TEST_ORIGINS": "https://a.example, https://b.example,",
+				"TEST_DB_URL":  "postgres://env",
+			},
+			want: testConfig{
+				Port: 9100, Name: "from-yaml", TTL: 90 * time.Second, Ratio: 0.25,
+				Origins:  []string{"https://a.example", "https://b.example"},
+				DB:       nested{URL: "postgres://env"},
+				Internal: "y",
+			},
+		},
+		{
+			name: "empty variables are ignored",
+			env:  map[string]string{"TEST_NAME": ""},
+			want: testConfig{Port: 8080, Name: "svc", TTL: 15 * time.Minute, Migrate: true, Ratio: 0.5, Origins: []string{"*"}, Internal: "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.FileEnv, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var got testConfig
+			if err := config.Load(&got, config.Options{File: tt.file}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		invalid error
+		file    string
+		want    []string
+	}{
+		{
+			name: "every bad value is reported",
+			env:  map[string]string{"TEST_PORT": "eighty", "TEST_TTL": "15", "TEST_MIGRATE": "maybe"},
+			want: []string{`TEST_PORT: invalid integer "eighty"`, `TEST_TTL: invalid duration "15"`, `TEST_MIGRATE: invalid boolean "maybe"`},
+		},
+		{
+			name:    "Validate rejects the result",
+			invalid: errors.New("TEST_NAME is required"),
+			want:    []string{"invalid configuration", "TEST_NAME is required"},
+		},
+		{name: "missing YAML file", file: filepath.Join(t.TempDir(), "missing.yaml"), want: []string{"missing.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.FileEnv, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			c := testConfig{invalid: tt.invalid}
+			err := config.Load(&c, config.Options{File: tt.file})
+			if err == nil {
+				t.Fatal("Load accepted an invalid configuration")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadNotAStructPointer(t *testing.T) {
+	var c testConfig
+	if err := config.Load(c, config.Options{}); err == nil {
+		t.Error("Load accepted a struct value")
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("TEST_NAME=from-dotenv\nTEST_PORT=7000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.FileEnv, "")
+	// Las variables ya definidas ganan sobre el .env
+	t.Setenv("TEST_PORT", "7100")
+	// godotenv escribe en el entorno del proceso: se restaura al terminar
+	t.Setenv("TEST_NAME", "")
+	os.Unsetenv("TEST_NAME")
+
+	var c testConfig
+	if err := config.Load(&c, config.Options{EnvFiles: []string{filepath.Join(dir, "missing.env"), envFile}}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "from-dotenv" || c.Port != 7100 {
+		t.Errorf("name = %q, port = %d; want from-dotenv and 7100", c.Name, c.Port)
+	}
+}
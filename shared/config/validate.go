@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Las funciones de validación devuelven nil si el valor es correcto o un
+// error que nombra la variable. Se combinan con errors.Join en Validate.
+
+// Required exige que value no esté vacío
+func Required(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	return nil
+}
+
+// MinLength exige al menos n caracteres (secretos, tokens)
+func MinLength(name, value string, n int) error {
+	if len(value) < n {
+		return fmt.Errorf("%s must be at least %d characters long", name, n)
+	}
+	return nil
+}
+
+// URL exige una URL absoluta con uno de los esquemas indicados (http y https
+// si no se indica ninguno). Un valor vacío es válido: combinar con Required.
+func URL(name, value string, schemes ...string) error {
+	if value == "" {
+		return nil
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("%s must be an absolute %v URL, got %q", name, schemes, value)
+	}
+	return nil
+}
+
+// Positive exige una duración mayor que cero
+func Positive(name string, d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("%s must be a positive duration, got %s", name, d)
+	}
+	return nil
+}
+
+// PositiveInt exige un entero mayor que cero
+func PositiveInt(name string, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%s must be greater than zero, got %d", name, n)
+	}
+	return nil
+}
+
+// Port exige un puerto TCP válido
+func Port(name string, port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("%s must be a TCP port, got %d", name, port)
+	}
+	return nil
+}
+
+// OneOf exige que value sea uno de allowed
+func OneOf(name, value string, allowed ...string) error {
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("%s must be one of %v, got %q", name, allowed, value)
+	}
+	return nil
+}
+
+// Origins exige orígenes CORS de la forma esquema://host[:puerto], sin ruta,
+// o "*" como único valor
+func Origins(name string, origins []string) error {
+	if len(origins) == 1 && origins[0] == "*" {
+		return nil
+	}
+	for _, o := range origins {
+		u, err := url.Parse(o)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("%s: invalid origin %q (expected scheme://host[:port] or a single *)", name, o)
+		}
+	}
+	return nil
+}
+
+// Proxies exige direcciones IP o rangos CIDR (p. ej. 10.0.0.0/8)
+func Proxies(name string, proxies []string) error {
+	for _, p := range proxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				return fmt.Errorf("%s: invalid IP or CIDR %q", name, p)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "required present", err: config.Required("DB_URL", "postgres://db")},
+		{name: "required empty", err: config.Required("DB_URL", ""), wantErr: true},
+		{name: "min length", err: config.MinLength("TOKEN", "0123456789abcdef", 16)},
+		{name: "too short", err: config.MinLength("TOKEN", "short", 16), wantErr: true},
+		{name: "http URL", err: config.URL("PUBLIC_URL", "https://api.example.com/base")},
+		{name: "empty URL is left to Required", err: config.URL("PUBLIC_URL", "")},
+		{name: "relative URL", err: config.URL("PUBLIC_URL", "/api"), wantErr: true},
+		{name: "URL with another scheme", err: config.URL("PUBLIC_URL", "ftp://example.com"), wantErr: true},
+		{name: "URL with an allowed scheme", err: config.URL("DB_URL", "postgres://db:5432/app", "postgres", "postgresql")},
+		{name: "URL with a disallowed scheme", err: config.URL("DB_URL", "mysql://db/app", "postgres", "postgresql"), wantErr: true},
+		{name: "positive duration", err: config.Positive("TTL", time.Second)},
+		{name: "zero duration", err: config.Positive("TTL", 0), wantErr: true},
+		{name: "positive int", err: config.PositiveInt("N", 1)},
+		{name: "negative int", err: config.PositiveInt("N", -1), wantErr: true},
+		{name: "port", err: config.Port("PORT", 8080)},
+		{name: "port zero", err: config.Port("PORT", 0), wantErr: true},
+		{name: "port too high", err: config.Port("PORT", 65536), wantErr: true},
+		{name: "one of", err: config.OneOf("APP_ENV", "staging", "development", "staging", "production")},
+		{name: "not one of", err: config.OneOf("APP_ENV", "prod", "development", "staging", "production"), wantErr: true},
+		{name: "wildcard origin", err: config.Origins("CORS_ORIGINS", []string{"*"})},
+		{name: "origins", err: config.Origins("CORS_ORIGINS", []string{"https://app.example.com", "http://localhost:3000"})},
+		{name: "wildcard mixed with origins", err: config.Origins("CORS_ORIGINS", []string{"*", "https://app.example.com"}), wantErr: true},
+		{name: "origin with a path", err: config.Origins("CORS_ORIGINS", []string{"https://app.example.com/"}), wantErr: true},
+		{name: "origin without scheme", err: config.Origins("CORS_ORIGINS", []string{"app.example.com"}), wantErr: true},
+		{name: "proxies", err: config.Proxies("TRUSTED_PROXIES", []string{"10.0.0.1", "10.0.0.0/8", "::1"})},
+		{name: "invalid proxy", err: config.Proxies("TRUSTED_PROXIES", []string{"proxy.internal"}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %t", tt.err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package graceful sirve un http.Server hasta recibir SIGINT o SIGTERM y lo
+// apaga de forma ordenada: deja de aceptar conexiones, espera a que terminen
+// las peticiones en curso y después corre las tareas de limpieza (esperar
+// publicadores en segundo plano, cerrar la base de datos).
+package graceful
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Options configura el apagado
+type Options struct {
+	// Timeout es el tiempo total para drenar peticiones y correr Cleanup
+	Timeout time.Duration
+	// OnSignal se llama al recibir la señal, antes de cerrar el servidor
+	// (p. ej. health.Probe.Drain)
+	OnSignal func()
+	// Cleanup corre en orden después de drenar las peticiones
+	Cleanup []func(ctx context.Context) error
+}
+
+// Run sirve srv y bloquea hasta que se apaga. Devuelve el error del servidor
+// si no pudo arrancar, o los errores del apagado (p. ej. si venció Timeout).
+func Run(srv *http.Server, opts Options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	// Una segunda señal termina el proceso sin esperar
+	stop()
+	slog.Info("shutdown signal received, draining", "timeout", opts.Timeout)
+
+	if opts.OnSignal != nil {
+		opts.OnSignal()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	errs := []error{srv.Shutdown(shutdownCtx)}
+	for _, cleanup := range opts.Cleanup {
+		errs = append(errs, cleanup(shutdownCtx))
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
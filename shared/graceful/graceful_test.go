@@ -0,0 +1,162 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/graceful"
+)
+
+// serve corre Run con handler en un puerto libre y le envía SIGTERM cuando
+// ya atiende peticiones; inFlight se lanza justo antes de la señal
+func serve(t *testing.T, handler http.Handler, opts graceful.Options, inFlight func(url string)) error {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	srv := &http.Server{Addr: addr, Handler: handler}
+	result := make(chan error, 1)
+	go func() { result <- graceful.Run(srv, opts) }()
+
+	// Run registra la señal antes de servir: una vez que responde, SIGTERM
+	// llega a Run y no termina el proceso de test
+	url := "http://" + addr
+	for i := 0; ; i++ {
+		resp, err := http.Get(url + "/ready")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if inFlight != nil {
+		inFlight(url)
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after SIGTERM")
+		return nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	var order []string
+	opts := graceful.Options{
+		Timeout:  time.Second,
+		OnSignal: func() { order = append(order, "signal") },
+		Cleanup: []func(context.Context) error{
+			func(context.Context) error { order = append(order, "events"); return nil },
+			func(context.Context) error { order = append(order, "database"); return nil },
+		},
+	}
+	if err := serve(t, http.NotFoundHandler(), opts, nil); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	want := []string{"signal", "events", "database"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestRunDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	status := make(chan int, 1)
+	err := serve(t, mux, graceful.Options{Timeout: 2 * time.Second}, func(url string) {
+		go func() {
+			resp, err := http.Get(url + "/slow")
+			if err != nil {
+				status <- 0
+				return
+			}
+			resp.Body.Close()
+			status <- resp.StatusCode
+		}()
+		<-started
+	})
+	if err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	if got := <-status; got != http.StatusNoContent {
+		t.Errorf("in-flight request status = %d, want 204", got)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("/stuck", func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	})
+
+	cleanupErr := errors.New("close failed")
+	var cleanupCtxErr error
+	opts := graceful.Options{
+		Timeout: 100 * time.Millisecond,
+		Cleanup: []func(context.Context) error{func(ctx context.Context) error {
+			cleanupCtxErr = ctx.Err()
+			return cleanupErr
+		}},
+	}
+	err := serve(t, mux, opts, func(url string) {
+		go func() {
+			if resp, err := http.Get(url + "/stuck"); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		<-started
+	})
+
+	// Vence el plazo con una petición colgada: Run lo informa junto con los
+	// errores de la limpieza, que igual se ejecuta
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run = %v, want context.DeadlineExceeded", err)
+	}
+	if !errors.Is(err, cleanupErr) {
+		t.Errorf("Run = %v, want the cleanup error", err)
+	}
+	if !errors.Is(cleanupCtxErr, context.DeadlineExceeded) {
+		t.Errorf("cleanup ctx err = %v, want the expired shutdown context", cleanupCtxErr)
+	}
+}
+
+func TestRunListenError(t *testing.T) {
+	srv := &http.Server{Addr: "127.0.0.1:-1"}
+	if err := graceful.Run(srv, graceful.Options{Timeout: time.Second}); err == nil {
+		t.Fatal("Run with an invalid address returned nil")
+	}
+}
@@ -0,0 +1,119 @@
+// Package health expone las sondas de los servicios:
+//
+//   - /livez responde 200 mientras el proceso atiende peticiones
+//   - /readyz corre los checks de las dependencias propias (Postgres,
+//     RabbitMQ...) y responde 503 si alguno falla o si el servicio se
+//     está apagando, para que el balanceador deje de enviarle tráfico
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check comprueba una dependencia; devuelve nil si está disponible
+type Check func(ctx context.Context) error
+
+// CheckTimeout es el tiempo máximo de cada check en /readyz
+const CheckTimeout = 3 * time.Second
+
+// Probe guarda los checks de /readyz y el estado de apagado
+type Probe struct {
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// New crea una sonda con los checks indicados por nombre
+func New(checks map[string]Check) *Probe {
+	return &Probe{checks: checks}
+}
+
+// Register agrega /livez y /readyz a r
+func (p *Probe) Register(r gin.IRoutes) {
+	r.GET("/livez", p.Livez)
+	r.GET("/readyz", p.Readyz)
+}
+
+// Drain marca el servicio como no listo; se llama al recibir la señal de apagado
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+// Livez responde 200 si el proceso está vivo
+func (p *Probe) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz corre todos los checks en paralelo y responde 200 solo si todos pasan
+func (p *Probe) Readyz(c *gin.Context) {
+	if p.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), CheckTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]string, len(p.checks))
+		ready   = true
+	)
+	for name, check := range p.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := "ok"
+			if err := check(ctx); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = status
+			if status != "ok" {
+				ready = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": results})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": results})
+}
+
+// Postgres comprueba la conexión con un ping
+func Postgres(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// HTTP comprueba que url responda con un status 2xx
+func HTTP(client *http.Client, url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
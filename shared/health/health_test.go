@@ -0,0 +1,113 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/health"
+	"github.com/gin-gonic/gin"
+)
+
+type readyzBody struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func get(t *testing.T, p *health.Probe, path string) (int, readyzBody) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	p.Register(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body readyzBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return w.Code, body
+}
+
+func ok(context.Context) error { return nil }
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]health.Check
+		wantCode   int
+		wantChecks map[string]string
+	}{
+		{name: "no checks", wantCode: http.StatusOK, wantChecks: map[string]string{}},
+		{
+			name:       "all checks pass",
+			checks:     map[string]health.Check{"postgres": ok, "rabbitmq": ok},
+			wantCode:   http.StatusOK,
+			wantChecks: map[string]string{"postgres": "ok", "rabbitmq": "ok"},
+		},
+		{
+			name: "one check fails",
+			checks: map[string]health.Check{
+				"postgres": ok,
+				"rabbitmq": func(context.Context) error { return errors.New("connection refused") },
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"postgres": "ok", "rabbitmq": "connection refused"},
+		},
+		{
+			name: "checks get a deadline",
+			checks: map[string]health.Check{"postgres": func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); !ok {
+					return errors.New("no deadline")
+				}
+				return nil
+			}},
+			wantCode:   http.StatusOK,
+			wantChecks: map[string]string{"postgres": "ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(t, health.New(tt.checks), "/readyz")
+			if code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%+v)", code, tt.wantCode, body)
+			}
+			if len(body.Checks) != len(tt.wantChecks) {
+				t.Fatalf("checks = %v, want %v", body.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if body.Checks[name] != want {
+					t.Errorf("check %s = %q, want %q", name, body.Checks[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	called := false
+	p := health.New(map[string]health.Check{"postgres": func(context.Context) error {
+		called = true
+		return nil
+	}})
+	if code, _ := get(t, p, "/readyz"); code != http.StatusOK {
+		t.Fatalf("readyz before Drain = %d, want 200", code)
+	}
+
+	called = false
+	p.Drain()
+	code, body := get(t, p, "/readyz")
+	if code != http.StatusServiceUnavailable || body.Status != "shutting down" {
+		t.Errorf("readyz while draining = %d %q, want 503 shutting down", code, body.Status)
+	}
+	if called {
+		t.Error("checks ran while draining")
+	}
+	// El proceso sigue vivo mientras drena
+	if code, _ := get(t, p, "/livez"); code != http.StatusOK {
+		t.Errorf("livez while draining = %d, want 200", code)
+	}
+}
@@ -0,0 +1,99 @@
+// Package logging configura el logging estructurado de los servicios con
+// slog: JSON (o texto) a stdout, nivel configurable, request ID y trace ID en
+// cada línea y enmascarado de datos personales y secretos (ver redact.go)
+// antes de escribir.
+//
+// Init reemplaza el logger por defecto, así que lo que todavía se escribe con
+// el paquete log también sale estructurado y enmascarado.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formatos admitidos
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config es la parte de logging de la configuración de cada servicio
+type Config struct {
+	// Level es debug, info, warn o error
+	Level string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	// Format es json (producción) o text (desarrollo)
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+// Validate comprueba la configuración; los servicios la incluyen en la suya
+func (c Config) Validate() error {
+	var level slog.Level
+	return errors.Join(
+		config.OneOf("LOG_FORMAT", c.Format, FormatJSON, FormatText),
+		wrapLevel(level.UnmarshalText([]byte(c.Level))),
+	)
+}
+
+func wrapLevel(err error) error {
+	if err != nil {
+		return fmt.Errorf("LOG_LEVEL must be debug, info, warn or error: %w", err)
+	}
+	return nil
+}
+
+// Init instala el logger por defecto del servicio y lo devuelve. c ya debe
+// estar validada.
+func Init(service string, c Config) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var h slog.Handler
+	if c.Format == FormatText {
+		h = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		h = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	logger := slog.New(&contextHandler{Handler: h}).With("service", service)
+	slog.SetDefault(logger)
+
+	// Los mensajes de depuración de Gin (rutas registradas) también pasan por slog
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	return logger
+}
+
+// contextHandler agrega a cada registro el request ID y el trace ID del contexto
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
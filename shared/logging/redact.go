@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted reemplaza los valores secretos
+const Redacted = "[REDACTED]"
+
+// secretKeys son atributos cuyo valor nunca se escribe
+var secretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"mfa_token":     true,
+	"otp":           true,
+	"code":          true,
+	"secret":        true,
+	"authorization": true,
+}
+
+// Patrones que se enmascaran dentro de cualquier texto (mensaje, errores,
+// atributos), en este orden: primero los tokens, que pueden contener dígitos
+// que los demás patrones confundirían.
+var (
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	// Códigos OTP: 4 a 8 dígitos poco después de "código", "code", "otp" o "pin"
+	otpPattern   = regexp.MustCompile(`(?i)((?:otp|code|c[oó]digo|pin)\D{0,30}?)\b\d{4,8}\b`)
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+)`)
+	// Teléfonos E.164 (+593987654321) y celulares locales (0987654321)
+	phonePattern = regexp.MustCompile(`\+\d{7,15}\b|\b09\d{8}\b`)
+)
+
+// Redact enmascara teléfonos, emails, tokens y códigos OTP en s
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = otpPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = emailPattern.ReplaceAllString(s, "${1}***@${2}")
+	s = phonePattern.ReplaceAllStringFunc(s, maskPhone)
+	return s
+}
+
+// maskPhone deja visibles los dos últimos dígitos para poder correlacionar
+func maskPhone(p string) string {
+	if len(p) <= 2 {
+		return Redacted
+	}
+	return strings.Repeat("*", len(p)-2) + p[len(p)-2:]
+}
+
+// redactAttr es el ReplaceAttr de los handlers: se aplica a cada atributo,
+// incluido el mensaje, antes de escribirlo
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(x.Error()))
+		case interface{ String() string }:
+			return slog.String(a.Key, Redact(x.String()))
+		}
+	}
+	return a
+}
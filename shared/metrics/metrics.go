@@ -0,0 +1,47 @@
+// Package metrics expone las métricas Prometheus comunes a los servicios:
+// la latencia de cada petición HTTP por ruta y el endpoint /metrics. Las
+// métricas propias de cada servicio (logins, reportes...) se registran en el
+// registro por defecto desde sus paquetes y salen por el mismo endpoint.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute agrupa las peticiones a rutas inexistentes para no crear
+// una serie por cada URL
+const unmatchedRoute = "unmatched"
+
+var requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_request_duration_seconds",
+	Help:    "Latency of HTTP requests by route.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// Middleware mide cada petición. La ruta es el patrón de Gin
+// (/api/v1/users/:id), no la URL, para mantener acotadas las series.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		requestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler sirve /metrics
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
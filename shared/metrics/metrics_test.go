@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// observations devuelve cuántas peticiones se midieron con esas etiquetas
+func observations(t *testing.T, method, route, status string) uint64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"method": method, "route": route, "status": status}
+	for _, f := range families {
+		if f.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if want[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/reports", func(c *gin.Context) { c.Status(http.StatusUnprocessableEntity) })
+
+	tests := []struct {
+		method, url   string
+		route, status string
+		wantCode      int
+	}{
+		// La ruta es el patrón, no la URL con el id
+		{http.MethodGet, "/api/v1/users/42", "/api/v1/users/:id", "200", http.StatusOK},
+		{http.MethodGet, "/api/v1/users/43", "/api/v1/users/:id", "200", http.StatusOK},
+		{http.MethodPost, "/api/v1/reports", "/api/v1/reports", "422", http.StatusUnprocessableEntity},
+		// Las rutas inexistentes comparten una sola serie
+		{http.MethodGet, "/wp-admin/setup.php", unmatchedRoute, "404", http.StatusNotFound},
+		{http.MethodGet, "/.env", unmatchedRoute, "404", http.StatusNotFound},
+	}
+	before := map[[3]string]uint64{}
+	want := map[[3]string]uint64{}
+	for _, tt := range tests {
+		key := [3]string{tt.method, tt.route, tt.status}
+		if _, ok := before[key]; !ok {
+			before[key] = observations(t, tt.method, tt.route, tt.status)
+		}
+		want[key]++
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.wantCode {
+			t.Fatalf("%s %s = %d, want %d", tt.method, tt.url, w.Code, tt.wantCode)
+		}
+	}
+	for key, n := range want {
+		if got := observations(t, key[0], key[1], key[2]) - before[key]; got != n {
+			t.Errorf("%v observed %d times, want %d", key, got, n)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/metrics", Handler())
+
+	// La primera petición crea la serie de /metrics; la segunda la expone
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("/metrics = %d", w.Code)
+		}
+		if i == 0 {
+			continue
+		}
+		body := w.Body.String()
+		want := `http_request_duration_seconds_count{method="GET",route="/metrics",status="200"}`
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics does not expose %s", want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RunCLI implementa el comando cmd/migrate de cada servicio:
+//
+//	migrate [-dry-run] up
+//	migrate [-dry-run] down [-steps N]
+//	migrate status
+func RunCLI(ctx context.Context, r *Runner, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "show the SQL that would run without executing it")
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cmd := "up"
+	if fs.NArg() > 0 {
+		cmd = fs.Arg(0)
+		// Las opciones también pueden ir después del comando: "down -steps 2"
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	r.DryRun = *dryRun
+
+	switch cmd {
+	case "up":
+		done, err := r.Up(ctx)
+		report(out, "applied", done, r.DryRun)
+		return err
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+		done, err := r.Down(ctx, *steps)
+		report(out, "reverted", done, r.DryRun)
+		return err
+	case "status":
+		return status(ctx, r, out)
+	default:
+		return fmt.Errorf("unknown command %q (use up, down or status)", cmd)
+	}
+}
+
+func report(out io.Writer, verb string, done []Migration, dryRun bool) {
+	if dryRun {
+		verb = "would be " + verb
+	}
+	fmt.Fprintf(out, "%d migrations %s\n", len(done), verb)
+	for _, m := range done {
+		fmt.Fprintf(out, "  %03d_%s\n", m.Version, m.Name)
+	}
+}
+
+func status(ctx context.Context, r *Runner, out io.Writer) error {
+	applied, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	versions := map[int]bool{}
+	for _, m := range r.migrations {
+		versions[m.Version] = true
+		state := "pending"
+		if a, ok := applied[m.Version]; ok {
+			state = "applied " + a.AppliedAt.Format("2006-01-02 15:04:05")
+			if a.Checksum != m.Checksum {
+				state += " (MODIFIED)"
+			}
+		}
+		if m.Irreversible {
+			state += " (irreversible)"
+		}
+		fmt.Fprintf(out, "%03d_%s\t%s\n", m.Version, m.Name, state)
+	}
+
+	var unknown []int
+	for v := range applied {
+		if !versions[v] {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Ints(unknown)
+	for _, v := range unknown {
+		fmt.Fprintf(out, "%03d_%s\tapplied, unknown to this binary\n", v, applied[v].Name)
+	}
+	return nil
+}
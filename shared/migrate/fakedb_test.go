@@ -0,0 +1,215 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeDB imita lo que el Runner usa de PostgreSQL: advisory locks de sesión,
+// la tabla schema_migrations (clave service, version) y transacciones. Los scripts de migración solo
+// se registran; uno que contenga "FAIL" devuelve error.
+type fakeDB struct {
+	mu       sync.Mutex
+	table    bool
+	rows     map[rowKey]appliedRow
+	executed []string
+
+	locks map[int64]*sync.Mutex
+	// holding cuenta quién tiene cada lock; maxHolding es el máximo simultáneo
+	holding    map[int64]int
+	maxHolding int
+	lockIDs    []int64
+}
+
+type rowKey struct {
+	service string
+	version int
+}
+
+type appliedRow struct {
+	name, checksum string
+	at             time.Time
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{rows: map[rowKey]appliedRow{}, locks: map[int64]*sync.Mutex{}, holding: map[int64]int{}}
+}
+
+// open devuelve una conexión nueva a la base (como otra réplica del servicio)
+func (db *fakeDB) open() *sql.DB { return sql.OpenDB(connector{db}) }
+
+// versions devuelve las versiones registradas de auth-service, el servicio de newRunner
+func (db *fakeDB) versions() []int { return db.versionsOf("auth-service") }
+
+func (db *fakeDB) versionsOf(service string) []int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var v []int
+	for key := range db.rows {
+		if key.service == service {
+			v = append(v, key.version)
+		}
+	}
+	sort.Ints(v)
+	return v
+}
+
+func (db *fakeDB) scripts() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]string(nil), db.executed...)
+}
+
+func (db *fakeDB) lock(id int64) {
+	db.mu.Lock()
+	l, ok := db.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		db.locks[id] = l
+	}
+	db.lockIDs = append(db.lockIDs, id)
+	db.mu.Unlock()
+
+	l.Lock()
+	db.mu.Lock()
+	db.holding[id]++
+	if db.holding[id] > db.maxHolding {
+		db.maxHolding = db.holding[id]
+	}
+	db.mu.Unlock()
+}
+
+func (db *fakeDB) unlock(id int64) {
+	db.mu.Lock()
+	db.holding[id]--
+	l := db.locks[id]
+	db.mu.Unlock()
+	l.Unlock()
+}
+
+type connector struct{ db *fakeDB }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: c.db}, nil }
+func (c connector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	db *fakeDB
+	// pending son las escrituras de la transacción abierta
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	for _, fn := range c.pending {
+		fn()
+	}
+	c.db.mu.Unlock()
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+// write aplica fn ahora o, dentro de una transacción, al confirmarla
+func (c *fakeConn) write(fn func()) {
+	if c.inTx {
+		c.pending = append(c.pending, fn)
+		return
+	}
+	c.db.mu.Lock()
+	fn()
+	c.db.mu.Unlock()
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	db := c.db
+	switch {
+	case strings.Contains(query, "pg_advisory_lock("):
+		db.lock(args[0].Value.(int64))
+	case strings.Contains(query, "pg_advisory_unlock("):
+		db.unlock(args[0].Value.(int64))
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		c.write(func() { db.table = true })
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		key := rowKey{service: args[0].Value.(string), version: int(args[1].Value.(int64))}
+		row := appliedRow{name: args[2].Value.(string), checksum: args[3].Value.(string), at: time.Now()}
+		c.write(func() { db.rows[key] = row })
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		key := rowKey{service: args[0].Value.(string), version: int(args[1].Value.(int64))}
+		c.write(func() { delete(db.rows, key) })
+	default:
+		if strings.Contains(query, "FAIL") {
+			return nil, errors.New("syntax error")
+		}
+		// Un respiro para que dos runners sin lock se pisen
+		time.Sleep(time.Millisecond)
+		c.write(func() { db.executed = append(db.executed, strings.TrimSpace(query)) })
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	db := c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "to_regclass('schema_migrations')"):
+		return &fakeRows{cols: []string{"exists"}, values: [][]driver.Value{{db.table}}}, nil
+	case strings.Contains(query, "FROM schema_migrations"):
+		rows := &fakeRows{cols: []string{"version", "name", "checksum", "applied_at"}}
+		service := args[0].Value.(string)
+		for key, r := range db.rows {
+			if key.service == service {
+				rows.values = append(rows.values, []driver.Value{int64(key.version), r.name, r.checksum, r.at})
+			}
+		}
+		sort.Slice(rows.values, func(i, j int) bool { return rows.values[i][0].(int64) < rows.values[j][0].(int64) })
+		return rows, nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+type fakeRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,349 @@
+// Package migrate aplica migraciones SQL versionadas a PostgreSQL.
+//
+// Las migraciones son pares de archivos NNN_nombre.up.sql / NNN_nombre.down.sql
+// embebidos en el binario de cada servicio. Las aplicadas quedan registradas en
+// schema_migrations, por servicio y versión, con el checksum del archivo up; si
+// un archivo ya aplicado cambia, Up se niega a continuar. Varios servicios
+// pueden compartir la base de datos: cada uno ve solo sus filas. Un advisory
+// lock por servicio evita que dos réplicas migren a la vez.
+//
+// Una migración que no se puede revertir sin perder datos lleva en su archivo
+// down solo el comentario IrreversibleMarker; Down se niega a bajar de ella.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrMissingDown      = errors.New("migration has no down file")
+	ErrIrreversible     = errors.New("migration is irreversible")
+)
+
+// IrreversibleMarker es la primera línea del archivo down de una migración
+// irreversible; el resto del archivo explica por qué
+const IrreversibleMarker = "-- migrate:irreversible"
+
+// Migration es una versión del esquema
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+	// Irreversible indica que el down es IrreversibleMarker
+	Irreversible bool
+}
+
+// Applied es una fila de schema_migrations
+type Applied struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Runner aplica las migraciones de un servicio sobre una base de datos
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+	service    string
+	lockID     int64
+
+	// DryRun solo muestra lo que se aplicaría, sin ejecutar nada
+	DryRun bool
+	// Logf recibe el progreso; por defecto log.Printf
+	Logf func(format string, args ...interface{})
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load lee las migraciones de la raíz de fsys, ordenadas por versión
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNN_name.up.sql or NNN_name.down.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+			mig.Irreversible = strings.HasPrefix(strings.TrimSpace(mig.Down), IrreversibleMarker)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// New crea un Runner. service identifica al servicio: separa sus migraciones
+// en schema_migrations y su advisory lock de los de otros servicios.
+func New(db *sql.DB, fsys fs.FS, service string) (*Runner, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + service))
+	return &Runner{
+		db:         db,
+		migrations: migrations,
+		service:    service,
+		lockID:     int64(h.Sum64()),
+		Logf:       log.Printf,
+	}, nil
+}
+
+// Migrations devuelve las migraciones conocidas por el binario
+func (r *Runner) Migrations() []Migration {
+	return r.migrations
+}
+
+// Up aplica las migraciones pendientes en orden, cada una en su transacción.
+// Devuelve las migraciones aplicadas (o que se aplicarían en DryRun).
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(applied); err != nil {
+			return err
+		}
+
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if r.DryRun {
+				r.Logf("migrate: would apply %03d_%s\n%s", m.Version, m.Name, m.Up)
+				done = append(done, m)
+				continue
+			}
+			r.Logf("migrate: applying %03d_%s", m.Version, m.Name)
+			if err := r.exec(ctx, conn, m.Up,
+				`INSERT INTO schema_migrations (service, version, name, checksum) VALUES ($1, $2, $3, $4)`,
+				r.service, m.Version, m.Name, m.Checksum); err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down revierte las últimas steps migraciones aplicadas, de la más nueva a la
+// más vieja. Si alguna no tiene down o es irreversible no revierte ninguna.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(applied); err != nil {
+			return err
+		}
+
+		var revert []Migration
+		for i := len(r.migrations) - 1; i >= 0 && len(revert) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			switch {
+			case m.Down == "":
+				return fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, ErrMissingDown)
+			case m.Irreversible:
+				return fmt.Errorf("migration %03d_%s: %w, cannot go below version %d", m.Version, m.Name, ErrIrreversible, m.Version)
+			}
+			revert = append(revert, m)
+		}
+
+		for _, m := range revert {
+			if r.DryRun {
+				r.Logf("migrate: would revert %03d_%s\n%s", m.Version, m.Name, m.Down)
+				done = append(done, m)
+				continue
+			}
+			r.Logf("migrate: reverting %03d_%s", m.Version, m.Name)
+			if err := r.exec(ctx, conn, m.Down,
+				`DELETE FROM schema_migrations WHERE service = $1 AND version = $2`, r.service, m.Version); err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status devuelve las migraciones registradas en la base de datos
+func (r *Runner) Status(ctx context.Context) (map[int]Applied, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return r.applied(ctx, conn)
+}
+
+// Pending devuelve las migraciones que Up aplicaría
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// withLock ejecuta fn con el advisory lock tomado sobre una conexión dedicada;
+// el lock es de sesión, así que se toma y libera en la misma conexión
+func (r *Runner) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, r.lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, r.lockID); err != nil {
+			r.Logf("migrate: release lock: %v", err)
+		}
+	}()
+
+	if !r.DryRun {
+		if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	service TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (service, version)
+)`); err != nil {
+			return err
+		}
+	}
+	return fn(conn)
+}
+
+// applied lee las filas del servicio en schema_migrations; sin la tabla
+// (primer arranque o DryRun) no hay ninguna
+func (r *Runner) applied(ctx context.Context, conn *sql.Conn) (map[int]Applied, error) {
+	applied := map[int]Applied{}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations WHERE service = $1 ORDER BY version`, r.service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.Version] = a
+	}
+	return applied, rows.Err()
+}
+
+// verify comprueba que las migraciones aplicadas no cambiaron desde entonces
+func (r *Runner) verify(applied map[int]Applied) error {
+	known := map[int]bool{}
+	for _, m := range r.migrations {
+		known[m.Version] = true
+		if a, ok := applied[m.Version]; ok && a.Checksum != m.Checksum {
+			return fmt.Errorf("%03d_%s: %w (checksum %s, file %s)", m.Version, m.Name, ErrChecksumMismatch, a.Checksum, m.Checksum)
+		}
+	}
+	for v, a := range applied {
+		if !known[v] {
+			r.Logf("migrate: warning: database has migration %03d_%s unknown to this binary", v, a.Name)
+		}
+	}
+	return nil
+}
+
+// exec ejecuta la migración y el registro en schema_migrations en una transacción
+func (r *Runner) exec(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,457 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/migrate"
+)
+
+// files arma un sistema de archivos de migraciones a partir de nombre → SQL
+func files(m map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, sql := range m {
+		fsys[name] = &fstest.MapFile{Data: []byte(sql)}
+	}
+	return fsys
+}
+
+var schema = map[string]string{
+	"001_users.up.sql":       "CREATE TABLE users ();",
+	"001_users.down.sql":     "DROP TABLE users;",
+	"002_sessions.up.sql":    "CREATE TABLE sessions ();",
+	"002_sessions.down.sql":  "DROP TABLE sessions;",
+	"003_audit.up.sql":       "CREATE TABLE audit ();",
+	"003_audit.down.sql":     "DROP TABLE audit;",
+	"README.md":              "not a migration",
+	"seed/001_seed.up.sql":   "INSERT INTO users DEFAULT VALUES;",
+	"seed/001_seed.down.sql": "DELETE FROM users;",
+}
+
+// with devuelve schema con cambios; un valor vacío quita el archivo
+func with(changes map[string]string) map[string]string {
+	m := map[string]string{}
+	for k, v := range schema {
+		m[k] = v
+	}
+	for k, v := range changes {
+		if v == "" {
+			delete(m, k)
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+func newRunner(t *testing.T, db *fakeDB, migrations map[string]string) *migrate.Runner {
+	t.Helper()
+	r, err := migrate.New(db.open(), files(migrations), "auth-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Logf = t.Logf
+	return r
+}
+
+func versions(ms []migrate.Migration) []int {
+	var v []int
+	for _, m := range ms {
+		v = append(v, m.Version)
+	}
+	return v
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []int
+		wantErr string
+	}{
+		{name: "sorted by version, other files ignored", files: schema, want: []int{1, 2, 3}},
+		{name: "down is optional", files: with(map[string]string{"003_audit.down.sql": ""}), want: []int{1, 2, 3}},
+		{name: "bad file name", files: with(map[string]string{"4_x.sql": "SELECT 1;"}), wantErr: "name must be"},
+		{name: "down without up", files: with(map[string]string{"004_orphan.down.sql": "SELECT 1;"}), wantErr: "has no up file"},
+		{name: "two names for a version", files: with(map[string]string{"003_other.down.sql": "SELECT 1;"}), wantErr: "has two names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := migrate.Load(files(tt.files))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := versions(ms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadChecksumAndIrreversible(t *testing.T) {
+	ms, err := migrate.Load(files(with(map[string]string{
+		"003_audit.down.sql": "\n" + migrate.IrreversibleMarker + "\n-- drops audit history\n",
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte(schema["001_users.up.sql"]))
+	if ms[0].Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("checksum = %s, want the sha256 of the up file", ms[0].Checksum)
+	}
+	if ms[0].Irreversible || !ms[2].Irreversible {
+		t.Errorf("irreversible = %t, %t; want only 003", ms[0].Irreversible, ms[2].Irreversible)
+	}
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+
+	done, err := newRunner(t, db, with(map[string]string{"003_audit.up.sql": "", "003_audit.down.sql": ""})).Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("applied %v, want [1 2]", got)
+	}
+
+	// Un binario más nuevo aplica solo lo pendiente
+	r := newRunner(t, db, schema)
+	pending, err := r.Pending(ctx)
+	if err != nil || !reflect.DeepEqual(versions(pending), []int{3}) {
+		t.Fatalf("pending = %v, %v; want [3]", versions(pending), err)
+	}
+	done, err = r.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("applied %v, want [3]", got)
+	}
+	if got := db.scripts(); len(got) != 3 || got[2] != schema["003_audit.up.sql"] {
+		t.Errorf("executed %q", got)
+	}
+
+	status, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range r.Migrations() {
+		if status[m.Version].Checksum != m.Checksum {
+			t.Errorf("%03d recorded checksum %s, want %s", m.Version, status[m.Version].Checksum, m.Checksum)
+		}
+	}
+}
+
+func TestUpChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		// after son los archivos del binario que migra después de aplicar 001 y 002
+		after   map[string]string
+		wantErr error
+		want    []int
+	}{
+		{name: "unchanged", after: schema, want: []int{1, 2, 3}},
+		{
+			name:    "applied up file modified",
+			after:   with(map[string]string{"002_sessions.up.sql": "CREATE TABLE sessions (id int);"}),
+			wantErr: migrate.ErrChecksumMismatch,
+			want:    []int{1, 2},
+		},
+		{
+			name:  "applied down file modified",
+			after: with(map[string]string{"002_sessions.down.sql": "DROP TABLE IF EXISTS sessions;"}),
+			want:  []int{1, 2, 3},
+		},
+		{
+			name:  "pending up file modified",
+			after: with(map[string]string{"003_audit.up.sql": "CREATE TABLE audit (id int);"}),
+			want:  []int{1, 2, 3},
+		},
+		{
+			// La base tiene una migración que el binario no conoce: se avisa y se sigue
+			name:  "applied migration unknown to the binary",
+			after: with(map[string]string{"001_users.up.sql": "", "001_users.down.sql": ""}),
+			want:  []int{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newFakeDB()
+			if _, err := newRunner(t, db, with(map[string]string{"003_audit.up.sql": "", "003_audit.down.sql": ""})).Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := newRunner(t, db, tt.after).Up(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := db.versions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applied versions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Una migración que falla no queda registrada ni deja cambios; las
+// anteriores sí quedan aplicadas
+func TestUpFailure(t *testing.T) {
+	db := newFakeDB()
+	r := newRunner(t, db, with(map[string]string{"002_sessions.up.sql": "CREATE TABLE sessions (); FAIL"}))
+
+	done, err := r.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "002_sessions") {
+		t.Fatalf("err = %v, want the failing migration named", err)
+	}
+	if got := versions(done); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("applied %v, want [1]", got)
+	}
+	if got := db.versions(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("recorded %v, want [1]", got)
+	}
+}
+
+func TestDown(t *testing.T) {
+	irreversible := "-- migrate:irreversible\n-- audit history cannot be restored\n"
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		steps    int
+		want     []int
+		wantLeft []int
+		wantErr  error
+	}{
+		{name: "one step", files: schema, steps: 1, want: []int{3}, wantLeft: []int{1, 2}},
+		{name: "newest first", files: schema, steps: 2, want: []int{3, 2}, wantLeft: []int{1}},
+		{name: "more steps than applied", files: schema, steps: 10, want: []int{3, 2, 1}},
+		{
+			name:     "irreversible migration in range",
+			files:    with(map[string]string{"002_sessions.down.sql": irreversible}),
+			steps:    2,
+			wantErr:  migrate.ErrIrreversible,
+			wantLeft: []int{1, 2, 3},
+		},
+		{
+			name:     "irreversible migration below range",
+			files:    with(map[string]string{"002_sessions.down.sql": irreversible}),
+			steps:    1,
+			want:     []int{3},
+			wantLeft: []int{1, 2},
+		},
+		{
+			name:     "missing down file",
+			files:    with(map[string]string{"003_audit.down.sql": ""}),
+			steps:    1,
+			wantErr:  migrate.ErrMissingDown,
+			wantLeft: []int{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newFakeDB()
+			r := newRunner(t, db, tt.files)
+			if _, err := r.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			done, err := r.Down(ctx, tt.steps)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := versions(done); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reverted %v, want %v", got, tt.want)
+			}
+			if got := db.versions(); !reflect.DeepEqual(got, tt.wantLeft) {
+				t.Errorf("left applied %v, want %v", got, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	r := newRunner(t, db, schema)
+	r.DryRun = true
+
+	done, err := r.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("would apply %v, want [1 2 3]", got)
+	}
+	if db.table || len(db.scripts()) != 0 {
+		t.Error("dry run touched the database")
+	}
+}
+
+// Dos réplicas que arrancan a la vez: el advisory lock serializa las
+// migraciones y cada una se aplica una sola vez
+func TestAdvisoryLock(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+
+	const replicas = 4
+	var wg sync.WaitGroup
+	applied := make([][]migrate.Migration, replicas)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := migrate.New(db.open(), files(schema), "auth-service")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			r.Logf = t.Logf
+			if applied[i], err = r.Up(ctx); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if db.maxHolding != 1 {
+		t.Errorf("%d replicas held the migration lock at once", db.maxHolding)
+	}
+	total := 0
+	for _, done := range applied {
+		total += len(done)
+	}
+	if scripts := db.scripts(); total != 3 || len(scripts) != 3 {
+		t.Errorf("applied %d migrations running %d scripts, want 3 and 3", total, len(scripts))
+	}
+}
+
+func TestAdvisoryLockPerService(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	for _, service := range []string{"auth-service", "report-service", "auth-service"} {
+		r, err := migrate.New(db.open(), files(schema), service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Logf = t.Logf
+		if _, err := r.Pending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := db.lockIDs
+	if len(ids) != 3 || ids[0] != ids[2] || ids[0] == ids[1] {
+		t.Errorf("lock ids = %v, want one per service", ids)
+	}
+}
+
+// Dos servicios sobre la misma base de datos: cada uno lleva su propia
+// numeración en schema_migrations aunque las versiones coincidan
+func TestServicesShareDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	report := map[string]string{
+		"001_reports.up.sql":   "CREATE TABLE reports ();",
+		"001_reports.down.sql": "DROP TABLE reports;",
+	}
+	runner := func(service string, migrations map[string]string) *migrate.Runner {
+		r, err := migrate.New(db.open(), files(migrations), service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Logf = t.Logf
+		return r
+	}
+
+	auth, reports := runner("auth-service", schema), runner("report-service", report)
+	if done, err := auth.Up(ctx); err != nil || len(done) != 3 {
+		t.Fatalf("auth-service applied %d migrations: %v", len(done), err)
+	}
+	// El 001 de report-service no choca con el 001 de auth-service
+	done, err := reports.Up(ctx)
+	if err != nil {
+		t.Fatalf("report-service: %v", err)
+	}
+	if got := versions(done); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("report-service applied %v, want [1]", got)
+	}
+	if !strings.Contains(strings.Join(db.scripts(), "\n"), "CREATE TABLE reports") {
+		t.Error("report-service 001 was not run")
+	}
+
+	// Cada servicio solo ve y revierte lo suyo
+	if pending, err := auth.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("auth-service pending = %v, %v", versions(pending), err)
+	}
+	if _, err := reports.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.versionsOf("report-service"); len(got) != 0 {
+		t.Errorf("report-service left %v applied", got)
+	}
+	if got := db.versions(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("auth-service versions = %v after reverting report-service, want [1 2 3]", got)
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "up by default", want: "3 migrations applied"},
+		{name: "dry run", args: []string{"-dry-run", "up"}, want: "3 migrations would be applied"},
+		{name: "down with steps after the command", args: []string{"down", "-steps", "2"}, want: "2 migrations reverted"},
+		{name: "invalid steps", args: []string{"down", "-steps", "0"}, wantErr: "-steps must be at least 1"},
+		{name: "unknown command", args: []string{"sideways"}, wantErr: "unknown command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newFakeDB()
+			if tt.args != nil && tt.args[0] == "down" {
+				if _, err := newRunner(t, db, schema).Up(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			err := migrate.RunCLI(ctx, newRunner(t, db, schema), tt.args, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package tracing configura OpenTelemetry en los servicios: el proveedor de
+// trazas con su exportador, la propagación W3C trace-context entre servicios
+// y la instrumentación de Gin y de los clientes HTTP.
+//
+// Con el exportador "none" (por defecto) no se exporta nada, pero el contexto
+// de traza se sigue propagando para que los servicios siguientes lo continúen.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Andres09xZ/latacunga_clean_app/shared/config"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exportadores admitidos
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Exporters son los valores válidos de Config.Exporter
+var Exporters = []string{ExporterNone, ExporterOTLP, ExporterStdout}
+
+// Config es la parte de trazas de la configuración de cada servicio
+// (ver shared/config)
+type Config struct {
+	// Exporter es none, otlp o stdout
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"`
+	// Endpoint es la URL base del colector OTLP/HTTP (p. ej. http://otel-collector:4318)
+	Endpoint string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// SampleRatio es la fracción de trazas nuevas que se muestrean (0 a 1);
+	// las que llegan con un padre respetan su decisión
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1"`
+}
+
+// Validate comprueba la configuración; los servicios la incluyen en la suya
+func (c Config) Validate() error {
+	errs := []error{
+		config.OneOf("OTEL_TRACES_EXPORTER", c.Exporter, Exporters...),
+		config.URL("OTEL_EXPORTER_OTLP_ENDPOINT", c.Endpoint),
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %g", c.SampleRatio))
+	}
+	return errors.Join(errs...)
+}
+
+// Init instala el proveedor de trazas global y el propagador W3C. Devuelve la
+// función que vacía y cierra el exportador al apagar el servicio.
+func Init(ctx context.Context, service string, c Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch c.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		// Sin Endpoint el exportador usa sus valores por defecto (localhost:4318)
+		var opts []otlptracehttp.Option
+		if c.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(strings.TrimSuffix(c.Endpoint, "/")+"/v1/traces"))
+		}
+		var err error
+		exporter, err = otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: %w", err)
+		}
+	case ExporterStdout:
+		var err error
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", c.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer devuelve el tracer con el que cada paquete crea sus spans
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Middleware crea un span por petición, continuando la traza del cliente si
+// trae la cabecera traceparent
+func Middleware(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service)
+}
+
+// Transport envuelve base (nil usa http.DefaultTransport) para que cada
+// petición saliente tenga su span y lleve la cabecera traceparent
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}